@@ -0,0 +1,189 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/artwork"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/storage"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+// artworkCacheControl lets clients and proxies keep artwork forever: files are
+// named after the hash of their content, so a new upload gets a new url.
+const artworkCacheControl = "public, max-age=31536000, immutable"
+
+// uploadArtworkHandler replaces a podcast's cover art with the image uploaded
+// in the multipart field "artwork", storing it along with its thumbnails.
+func (app *application) uploadArtworkHandler(ctx *gin.Context) {
+	podcastId := app.readPodcastId(ctx)
+	if podcastId == 0 {
+		return
+	}
+
+	maxBytes := app.config.artwork.maxBytes
+
+	// Leave room for the multipart headers around the file.
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBytes+64<<10)
+
+	v := validator.New()
+
+	fh, err := ctx.FormFile("artwork")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesError):
+			v.AddError("artwork", fmt.Sprintf("must not be larger than %d bytes", maxBytes))
+			app.failedValidationResponse(ctx, v.Errors)
+		case errors.Is(err, http.ErrMissingFile):
+			v.AddError("artwork", "must be provided")
+			app.failedValidationResponse(ctx, v.Errors)
+		default:
+			app.badRequestResponse(ctx, err)
+		}
+		return
+	}
+
+	if v.Check(fh.Size <= maxBytes, "artwork", fmt.Sprintf("must not be larger than %d bytes", maxBytes)); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+	defer f.Close()
+
+	upload, err := io.ReadAll(io.LimitReader(f, maxBytes))
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	podcast, err := app.models.Podcast.FindById(podcastId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	limits := artwork.Limits{MinDimension: app.config.artwork.minDimension, MaxDimension: app.config.artwork.maxDimension}
+
+	result, err := artwork.Process(upload, limits)
+	if err != nil {
+		switch {
+		case errors.Is(err, artwork.ErrUnsupportedType):
+			v.AddError("artwork", "must be a JPEG or PNG image")
+		case errors.Is(err, artwork.ErrTooSmall):
+			v.AddError("artwork", fmt.Sprintf("must be at least %dx%d pixels", limits.MinDimension, limits.MinDimension))
+		case errors.Is(err, artwork.ErrTooLarge):
+			v.AddError("artwork", fmt.Sprintf("must be at most %dx%d pixels", limits.MaxDimension, limits.MaxDimension))
+		default:
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	podcast.Artwork, err = app.storeArtwork(ctx, result)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	if err := app.models.Podcast.SetArtwork(podcast); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": podcast})
+}
+
+// storeArtwork saves the original and thumbnails under the hash of the
+// original. Files replaced on a podcast are kept, since another podcast may
+// have been given the same image.
+func (app *application) storeArtwork(ctx *gin.Context, result *artwork.Result) (*data.Artwork, error) {
+	store := func(file artwork.File) (data.ArtworkImage, error) {
+		key := path.Join("artwork", result.Hash, file.Name)
+		if err := app.storage.Put(ctx.Request.Context(), key, file.Data, file.ContentType); err != nil {
+			return data.ArtworkImage{}, err
+		}
+		return data.ArtworkImage{Url: "/v1/" + key, Width: file.Width, Height: file.Height}, nil
+	}
+
+	original, err := store(result.Original)
+	if err != nil {
+		return nil, err
+	}
+
+	art := &data.Artwork{
+		Hash:   result.Hash,
+		Url:    original.Url,
+		Width:  original.Width,
+		Height: original.Height,
+		Sizes:  []data.ArtworkImage{},
+	}
+
+	for _, file := range result.Sizes {
+		image, err := store(file)
+		if err != nil {
+			return nil, err
+		}
+		art.Sizes = append(art.Sizes, image)
+	}
+
+	return art, nil
+}
+
+// serveArtworkHandler streams a stored artwork file with long lived cache
+// headers.
+func (app *application) serveArtworkHandler(ctx *gin.Context) {
+	name, err := storage.CleanKey(ctx.Param("key"))
+	if err != nil {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	key := path.Join("artwork", name)
+	etag := `"` + name + `"`
+
+	if ctx.GetHeader("If-None-Match") == etag {
+		ctx.Header("Cache-Control", artworkCacheControl)
+		ctx.Header("ETag", etag)
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	obj, err := app.storage.Open(ctx.Request.Context(), key)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrNotFound):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+	defer obj.Body.Close()
+
+	headers := map[string]string{
+		"Cache-Control":          artworkCacheControl,
+		"ETag":                   etag,
+		"X-Content-Type-Options": "nosniff",
+	}
+
+	ctx.DataFromReader(http.StatusOK, obj.Size, obj.ContentType, obj.Body, headers)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/artwork"
+	"github.com/terajari/ipdb/internal/audiometa"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+// audioFile is an audio file to read metadata from, whether uploaded or on
+// the server's disk.
+type audioFile interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// extractAudioMetadataHandler reads an episode's audio file and stores its
+// duration, bitrate, chapters, title and embedded artwork on the episode. The
+// file is uploaded in the multipart field "file", or, when audio-local-dir is
+// set, named by a JSON "path" relative to that directory.
+func (app *application) extractAudioMetadataHandler(ctx *gin.Context) {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	var (
+		f    audioFile
+		size int64
+	)
+
+	if strings.HasPrefix(ctx.ContentType(), "multipart/") {
+		f, size = app.openUploadedAudio(ctx)
+	} else {
+		f, size = app.openLocalAudio(ctx)
+	}
+	if f == nil {
+		return
+	}
+	defer f.Close()
+
+	episode, err := app.models.Episode.FindById(path.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	meta, err := audiometa.Read(f, size)
+	if err != nil {
+		v := validator.New()
+		switch {
+		case errors.Is(err, audiometa.ErrUnsupportedFormat):
+			v.AddError("file", "must be an MP3 or MP4 audio file")
+		case errors.Is(err, audiometa.ErrMalformed):
+			v.AddError("file", "could not be read as audio")
+		default:
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	if meta.Title != "" {
+		episode.Title = meta.Title
+	}
+	if meta.Duration > 0 {
+		episode.Duration = int64(meta.Duration.Seconds())
+	}
+	episode.Bitrate = meta.Bitrate
+
+	episode.Chapters = []data.Chapter{}
+	for _, c := range meta.Chapters {
+		episode.Chapters = append(episode.Chapters, data.Chapter{
+			Title:   c.Title,
+			StartMs: c.Start.Milliseconds(),
+			EndMs:   c.End.Milliseconds(),
+		})
+	}
+
+	// Embedded artwork is often small or odd; an image that will not process
+	// is dropped rather than failing the whole file.
+	if meta.Artwork != nil {
+		limits := artwork.Limits{MinDimension: 1, MaxDimension: app.config.artwork.maxDimension}
+
+		result, err := artwork.Process(meta.Artwork.Data, limits)
+		if err != nil {
+			app.logger.Error(err.Error(), "episode_id", episode.Id)
+		} else if episode.Artwork, err = app.storeArtwork(ctx, result); err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+	}
+
+	v := validator.New()
+	if data.ValidateEpisode(v, episode); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	if err := app.models.Episode.UpdateAudioMetadata(episode); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	audio := gin.H{
+		"format":      meta.Format,
+		"artist":      meta.Artist,
+		"album":       meta.Album,
+		"sample_rate": meta.SampleRate,
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": episode, "audio": audio})
+}
+
+// openUploadedAudio opens the multipart field "file". On failure it writes the
+// response and returns nil.
+func (app *application) openUploadedAudio(ctx *gin.Context) (audioFile, int64) {
+	maxBytes := app.config.audio.maxBytes
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxBytes+64<<10)
+
+	v := validator.New()
+
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesError):
+			v.AddError("file", fmt.Sprintf("must not be larger than %d bytes", maxBytes))
+			app.failedValidationResponse(ctx, v.Errors)
+		case errors.Is(err, http.ErrMissingFile):
+			v.AddError("file", "must be provided")
+			app.failedValidationResponse(ctx, v.Errors)
+		default:
+			app.badRequestResponse(ctx, err)
+		}
+		return nil, 0
+	}
+
+	if v.Check(fh.Size <= maxBytes, "file", fmt.Sprintf("must not be larger than %d bytes", maxBytes)); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return nil, 0
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return nil, 0
+	}
+
+	return f, fh.Size
+}
+
+// openLocalAudio opens the file named by the JSON field "path" under
+// audio-local-dir, refusing paths that lead outside it. On failure it writes
+// the response and returns nil.
+func (app *application) openLocalAudio(ctx *gin.Context) (audioFile, int64) {
+	var input struct {
+		Path string `json:"path"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return nil, 0
+	}
+
+	v := validator.New()
+
+	v.Check(app.config.audio.localDir != "", "path", "reading local files is not enabled on this server")
+	v.Check(input.Path != "", "path", "must be provided")
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return nil, 0
+	}
+
+	name, ok := resolveLocalAudio(app.config.audio.localDir, input.Path)
+	if !ok {
+		v.AddError("path", "must name a file in the audio directory")
+		app.failedValidationResponse(ctx, v.Errors)
+		return nil, 0
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		v.AddError("path", "must name a file in the audio directory")
+		app.failedValidationResponse(ctx, v.Errors)
+		return nil, 0
+	}
+
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		f.Close()
+		v.AddError("path", "must name a file in the audio directory")
+		app.failedValidationResponse(ctx, v.Errors)
+		return nil, 0
+	}
+
+	return f, info.Size()
+}
+
+// resolveLocalAudio joins name onto dir and follows symlinks, reporting
+// whether the file it finds lies inside dir.
+func resolveLocalAudio(dir, name string) (string, bool) {
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", false
+	}
+
+	full, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(name)))
+	if err != nil {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(root, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", false
+	}
+
+	return full, true
+}
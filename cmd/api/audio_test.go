@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveLocalAudio(t *testing.T) {
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret.mp3")
+
+	dir := t.TempDir()
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{secret, filepath.Join(root, "ep.mp3"), filepath.Join(root, "sub", "ep.mp3")} {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte("audio"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := map[string]string{
+		"latest.mp3":       filepath.Join(root, "sub", "ep.mp3"),
+		"secret.mp3":       secret,
+		"outside":          outside,
+		"sub/up":           "..",
+		"sub/relative.mp3": "../../" + filepath.Base(outside) + "/secret.mp3",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("symlinks are not supported here: %v", err)
+		}
+	}
+
+	escape, err := filepath.Rel(root, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{name: "ep.mp3", want: filepath.Join(root, "ep.mp3"), ok: true},
+		{name: "sub/ep.mp3", want: filepath.Join(root, "sub", "ep.mp3"), ok: true},
+		{name: "sub/../ep.mp3", want: filepath.Join(root, "ep.mp3"), ok: true},
+		{name: "/ep.mp3", want: filepath.Join(root, "ep.mp3"), ok: true},
+		{name: "latest.mp3", want: filepath.Join(root, "sub", "ep.mp3"), ok: true},
+		{name: "sub/up/ep.mp3", want: filepath.Join(root, "ep.mp3"), ok: true},
+		{name: "missing.mp3"},
+		{name: ".."},
+		{name: "../" + filepath.Base(root) + "/ep.mp3", want: filepath.Join(root, "ep.mp3"), ok: true},
+		{name: escape},
+		{name: "sub/../../" + filepath.Base(outside) + "/secret.mp3"},
+		{name: secret},
+		{name: "secret.mp3"},
+		{name: "outside/secret.mp3"},
+		{name: "sub/relative.mp3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := resolveLocalAudio(dir, tt.name)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("resolveLocalAudio(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+
+	if _, ok := resolveLocalAudio(filepath.Join(root, "missing"), "ep.mp3"); ok {
+		t.Error("a missing audio directory resolved a file")
+	}
+}
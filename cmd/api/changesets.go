@@ -0,0 +1,291 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+var changeSetSortSafelist = []string{"created_at", "reviewed_at", "-created_at", "-reviewed_at"}
+
+// createSuggestionHandler lets a contributor propose creating, updating or
+// deleting a podcast. The proposal is checked like a direct edit would be and
+// queued for an editor.
+func (app *application) createSuggestionHandler(ctx *gin.Context) {
+	var input struct {
+		Action    string        `json:"action"`
+		PodcastId *int64        `json:"podcast_id"`
+		Podcast   *podcastInput `json:"podcast"`
+		Comment   string        `json:"comment"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	cs := &data.ChangeSet{
+		UserId:    app.contextGetUser(ctx).Id,
+		Action:    input.Action,
+		PodcastId: input.PodcastId,
+		Comment:   strings.TrimSpace(input.Comment),
+	}
+
+	v := validator.New()
+
+	data.ValidateChangeSet(v, cs)
+	v.Check(input.Action == data.ChangeDelete || input.Podcast != nil, "podcast", "must be provided to create or update a podcast")
+	v.Check(input.Action != data.ChangeDelete || input.Podcast == nil, "podcast", "must not be provided to delete a podcast")
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	if cs.Action == data.ChangeCreate {
+		cs.PodcastId = nil
+		cs.Podcast = input.Podcast.newPodcast()
+
+		if err := app.checkPodcast(v, cs.Podcast, *input.Podcast, input.Podcast.Platforms); err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+	} else {
+		podcast, err := app.models.Podcast.FindById(*cs.PodcastId)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				v.AddError("podcast_id", "must be an existing podcast")
+				app.failedValidationResponse(ctx, v.Errors)
+			default:
+				app.serverErrorResponse(ctx, err)
+			}
+			return
+		}
+
+		cs.BaseUpdatedAt = &podcast.UpdatedAt
+
+		if cs.Action == data.ChangeUpdate {
+			extra := input.Podcast.applyTo(podcast)
+			if err := app.checkPodcast(v, podcast, *input.Podcast, extra); err != nil {
+				app.serverErrorResponse(ctx, err)
+				return
+			}
+			cs.Podcast = podcast
+		}
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	if cs.Action == data.ChangeCreate && !app.checkDuplicates(ctx, cs.Podcast) {
+		return
+	}
+
+	if err := app.models.Change.Insert(cs); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/v1/suggestions/%d", cs.Id))
+
+	ctx.JSON(http.StatusAccepted, gin.H{"status": http.StatusAccepted, "data": cs})
+}
+
+// findChangeSet loads the change set named in the path. On failure it writes
+// the response and returns nil.
+func (app *application) findChangeSet(ctx *gin.Context) *data.ChangeSet {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return nil
+	}
+
+	cs, err := app.models.Change.FindById(path.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return nil
+	}
+
+	return cs
+}
+
+// getSuggestionHandler shows a change set to its author or to an editor.
+func (app *application) getSuggestionHandler(ctx *gin.Context) {
+	cs := app.findChangeSet(ctx)
+	if cs == nil {
+		return
+	}
+
+	if user := app.contextGetUser(ctx); cs.UserId != user.Id && !user.IsEditor() {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": cs})
+}
+
+// readChangeSetQuery binds and validates the filters of a change set listing.
+// On failure it writes the response and returns false.
+func (app *application) readChangeSetQuery(ctx *gin.Context, defaults data.ChangeSetFilters, sort string) (data.ChangeSetFilters, data.Filters, bool) {
+	var input struct {
+		data.ChangeSetFilters
+		data.Filters
+	}
+
+	input.ChangeSetFilters = defaults
+	input.Filters = *data.DefaultsFilters(data.Filters{Sort: sort, SortSafelist: changeSetSortSafelist})
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return input.ChangeSetFilters, input.Filters, false
+	}
+
+	v := validator.New()
+
+	data.ValidateFilters(v, input.Filters)
+	data.ValidateChangeSetFilters(v, input.ChangeSetFilters)
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return input.ChangeSetFilters, input.Filters, false
+	}
+
+	return input.ChangeSetFilters, input.Filters, true
+}
+
+func (app *application) listMySuggestionsHandler(ctx *gin.Context) {
+	csFilters, filters, ok := app.readChangeSetQuery(ctx, data.ChangeSetFilters{}, "-created_at")
+	if !ok {
+		return
+	}
+
+	csFilters.UserId = app.contextGetUser(ctx).Id
+
+	changeSets, metadata, err := app.models.Change.GetAll(csFilters, filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": changeSets, "metadata": metadata})
+}
+
+// moderationQueueHandler lists change sets for editors, the oldest pending
+// ones first unless asked otherwise.
+func (app *application) moderationQueueHandler(ctx *gin.Context) {
+	csFilters, filters, ok := app.readChangeSetQuery(ctx, data.ChangeSetFilters{Status: data.ChangePending}, "created_at")
+	if !ok {
+		return
+	}
+
+	changeSets, metadata, err := app.models.Change.GetAll(csFilters, filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": changeSets, "metadata": metadata})
+}
+
+// readReview reads the change set being reviewed and the editor's comment. On
+// failure it writes the response and returns nil.
+func (app *application) readReview(ctx *gin.Context) (*data.ChangeSet, string) {
+	var input struct {
+		Comment string `json:"comment"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return nil, ""
+	}
+
+	comment := strings.TrimSpace(input.Comment)
+
+	v := validator.New()
+
+	if v.Check(len(comment) <= 2000, "comment", "must not be more than 2000 bytes long"); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return nil, ""
+	}
+
+	cs := app.findChangeSet(ctx)
+	if cs == nil {
+		return nil, ""
+	}
+
+	if cs.Status != data.ChangePending {
+		app.conflictResponse(ctx, data.ErrChangeSetReviewed)
+		return nil, ""
+	}
+
+	return cs, comment
+}
+
+func (app *application) writeReviewError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		app.notFoundResponse(ctx)
+	case errors.Is(err, data.ErrEditConflict), errors.Is(err, data.ErrChangeSetReviewed):
+		app.conflictResponse(ctx, err)
+	default:
+		app.serverErrorResponse(ctx, err)
+	}
+}
+
+// approveSuggestionHandler applies a pending change set to the catalog.
+func (app *application) approveSuggestionHandler(ctx *gin.Context) {
+	cs, comment := app.readReview(ctx)
+	if cs == nil {
+		return
+	}
+
+	if cs.Podcast != nil {
+		if err := app.normalizeTags(cs.Podcast); err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+
+		if err := app.linkHostProgram(cs.Podcast); err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+	}
+
+	if err := app.models.Change.Approve(cs, app.contextGetUser(ctx).Id, comment); err != nil {
+		app.writeReviewError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": cs})
+}
+
+func (app *application) rejectSuggestionHandler(ctx *gin.Context) {
+	cs, comment := app.readReview(ctx)
+	if cs == nil {
+		return
+	}
+
+	if err := app.models.Change.Reject(cs, app.contextGetUser(ctx).Id, comment); err != nil {
+		app.writeReviewError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": cs})
+}
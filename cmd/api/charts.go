@@ -0,0 +1,96 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/language"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+// flushViews stores the podcast views counted in memory since the last flush.
+func (app *application) flushViews() {
+	counts := app.views.Drain()
+	if len(counts) == 0 {
+		return
+	}
+
+	if err := app.models.Chart.AddViews(counts); err != nil {
+		app.logger.Error(err.Error())
+		app.views.Merge(counts)
+	}
+}
+
+// readChartQuery reads the filters and paging of a chart. On failure it writes
+// the response and returns false.
+func (app *application) readChartQuery(ctx *gin.Context) (data.ChartFilters, data.Filters, bool) {
+	var chartFilters data.ChartFilters
+
+	if err := ctx.ShouldBindQuery(&chartFilters); err != nil {
+		app.badRequestResponse(ctx, err)
+		return chartFilters, data.Filters{}, false
+	}
+
+	filters, ok := app.readRankingFilters(ctx)
+	if !ok {
+		return chartFilters, filters, false
+	}
+
+	v := validator.New()
+
+	if data.ValidateChartFilters(v, chartFilters); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return chartFilters, filters, false
+	}
+
+	if chartFilters.Language != "" {
+		chartFilters.Language, _ = language.Normalize(chartFilters.Language)
+	}
+
+	if chartFilters.Tag != "" {
+		tags, err := app.models.Tag.Canonicalize([]string{chartFilters.Tag})
+		if err != nil {
+			app.serverErrorResponse(ctx, err)
+			return chartFilters, filters, false
+		}
+		if len(tags) == 0 {
+			v.AddError("tag", "must contain a letter or digit")
+			app.failedValidationResponse(ctx, v.Errors)
+			return chartFilters, filters, false
+		}
+		chartFilters.Tag = tags[0]
+	}
+
+	return chartFilters, filters, true
+}
+
+func (app *application) trendingChartHandler(ctx *gin.Context) {
+	chartFilters, filters, ok := app.readChartQuery(ctx)
+	if !ok {
+		return
+	}
+
+	entries, metadata, err := app.models.Chart.Trending(chartFilters, filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": entries, "metadata": metadata})
+}
+
+func (app *application) topChartHandler(ctx *gin.Context) {
+	chartFilters, filters, ok := app.readChartQuery(ctx)
+	if !ok {
+		return
+	}
+
+	entries, metadata, err := app.models.Chart.Top(chartFilters, filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": entries, "metadata": metadata})
+}
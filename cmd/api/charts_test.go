@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/views"
+)
+
+// flakyChart stores views, failing every other call to AddViews.
+type flakyChart struct {
+	data.IChart
+	calls  int
+	stored map[int64]int64
+}
+
+func (c *flakyChart) AddViews(counts map[int64]int64) error {
+	c.calls++
+	if c.calls%2 == 1 {
+		return errors.New("database unavailable")
+	}
+	for id, n := range counts {
+		c.stored[id] += n
+	}
+	return nil
+}
+
+func TestFlushViews(t *testing.T) {
+	chart := &flakyChart{stored: map[int64]int64{}}
+
+	app := &application{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		models: data.Models{Chart: chart},
+		views:  views.NewCounter(),
+	}
+
+	const workers, perWorker = 4, 1000
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(id int64) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				app.views.Add(id)
+			}
+		}(int64(w + 1))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	for running := true; running; {
+		select {
+		case <-done:
+			running = false
+		default:
+		}
+		app.flushViews()
+	}
+
+	// Every other store fails, so of two more flushes one stores whatever
+	// a failed flush merged back.
+	app.flushViews()
+	app.flushViews()
+
+	want := map[int64]int64{1: perWorker, 2: perWorker, 3: perWorker, 4: perWorker}
+	if !reflect.DeepEqual(chart.stored, want) {
+		t.Errorf("stored %v, want %v", chart.stored, want)
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+)
+
+const userContextKey = "user"
+
+func (app *application) contextSetUser(ctx *gin.Context, user *data.User) {
+	ctx.Set(userContextKey, user)
+}
+
+func (app *application) contextGetUser(ctx *gin.Context) *data.User {
+	user, ok := ctx.MustGet(userContextKey).(*data.User)
+	if !ok {
+		panic("missing user value in request context")
+	}
+	return user
+}
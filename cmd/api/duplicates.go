@@ -0,0 +1,191 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+func (app *application) listDuplicatesHandler(ctx *gin.Context) {
+	var input struct {
+		data.Filters
+	}
+
+	input.Filters.Sort = "similarity"
+	input.Filters.SortSafelist = []string{"similarity"}
+	input.Filters = *data.DefaultsFilters(input.Filters)
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	pairs, metadata, err := app.models.Podcast.GetDuplicatePairs(input.Filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": pairs, "metadata": metadata})
+}
+
+// mergeListCap is the most tags or guest speakers a podcast may have, which the
+// merged lists are cut to when the editor does not give the final ones.
+const mergeListCap = 10
+
+// mergePodcastHandler folds the podcast at :id into the surviving podcast named
+// in the body by id, public id or slug. Requests for the old id are redirected
+// to the survivor afterwards. The survivor gets the tags and guest speakers in
+// the body, or both podcasts' when they are left out.
+func (app *application) mergePodcastHandler(ctx *gin.Context) {
+	podcastId := app.readPodcastId(ctx)
+	if podcastId == 0 {
+		return
+	}
+
+	var input struct {
+		Into          podcastKey `json:"into" binding:"required"`
+		Tags          []string   `json:"tags"`
+		GuestSpeakers []string   `json:"guest_speakers"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	into, err := app.resolvePodcastKey(strings.TrimSpace(string(input.Into)))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			v.AddError("into", "podcast does not exist")
+			app.failedValidationResponse(ctx, v.Errors)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	v.Check(into != podcastId, "into", "must be a different podcast")
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	source, err := app.models.Podcast.FindById(podcastId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	target, err := app.models.Podcast.FindById(into)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			v.AddError("into", "podcast does not exist")
+			app.failedValidationResponse(ctx, v.Errors)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	if input.Tags != nil {
+		target.Tags = input.Tags
+	} else {
+		target.Tags = capList(union(target.Tags, source.Tags), mergeListCap)
+	}
+	if input.GuestSpeakers != nil {
+		target.GuestSpeakers = input.GuestSpeakers
+	} else {
+		target.GuestSpeakers = capList(union(target.GuestSpeakers, source.GuestSpeakers), mergeListCap)
+	}
+
+	if data.ValidatePodcast(v, target); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err = app.models.Podcast.Merge(source, target)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	merged, err := app.models.Podcast.FindById(target.Id)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/v1/podcasts/%d", merged.Id))
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": merged})
+}
+
+// redirectMergedPodcast answers a lookup of a podcast id that no longer exists,
+// pointing the client at the podcast it was merged into when there is one.
+func (app *application) redirectMergedPodcast(ctx *gin.Context, id int64) {
+	newId, err := app.models.Podcast.FindRedirect(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.Redirect(http.StatusMovedPermanently, fmt.Sprintf("/v1/podcasts/%d", newId))
+}
+
+// capList returns the first n of list, keeping the target's own entries, which
+// union puts first.
+func capList(list []string, n int) []string {
+	if len(list) > n {
+		return list[:n]
+	}
+	return list
+}
+
+func union(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	out := make([]string, 0, len(a)+len(b))
+
+	for _, s := range append(append([]string{}, a...), b...) {
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+
+	return out
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+func (app *application) createEpisodeHandler(ctx *gin.Context) {
+	podcastId := app.readPodcastId(ctx)
+	if podcastId == 0 {
+		return
+	}
+
+	var input struct {
+		Guid        string     `json:"guid"`
+		Title       string     `json:"title"`
+		Description string     `json:"description"`
+		Url         string     `json:"url"`
+		AudioUrl    string     `json:"audio_url"`
+		Duration    int64      `json:"duration"`
+		PublishedAt *time.Time `json:"published_at"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	_, err := app.models.Podcast.FindById(podcastId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	episode := data.Episode{
+		PodcastId:   podcastId,
+		Guid:        firstNonEmpty(input.Guid, input.AudioUrl, input.Url),
+		Title:       input.Title,
+		Description: input.Description,
+		Url:         input.Url,
+		AudioUrl:    input.AudioUrl,
+		Duration:    input.Duration,
+		PublishedAt: input.PublishedAt,
+	}
+
+	v := validator.New()
+
+	if data.ValidateEpisode(v, &episode); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	if err := app.models.Episode.Insert(&episode); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/v1/episodes/%d", episode.Id))
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": http.StatusCreated, "data": episode})
+}
+
+func (app *application) getEpisodeHandler(ctx *gin.Context) {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	episode, err := app.models.Episode.FindById(path.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	if !app.allowedInSafeMode(ctx, episode.PodcastId) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": episode})
+}
+
+func (app *application) listEpisodesHandler(ctx *gin.Context) {
+	podcastId := app.readPodcastId(ctx)
+	if podcastId == 0 {
+		return
+	}
+
+	var input struct {
+		data.Filters
+	}
+
+	input.Filters.Sort = "published_at"
+	input.Filters.SortSafelist = []string{"published_at"}
+	input.Filters = *data.DefaultsFilters(input.Filters)
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	if !app.allowedInSafeMode(ctx, podcastId) {
+		return
+	}
+
+	episodes, metadata, err := app.models.Episode.GetAllForPodcast(podcastId, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": episodes, "metadata": metadata})
+}
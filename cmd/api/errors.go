@@ -13,6 +13,12 @@ var (
 	ErrServer            = "IPDB-004 - Server error"
 	ErrRateLimitExceeded = "IPDB-005 - Rate limit exceeded"
 	ErrInvalidCredential = "IPDB-006 - Invalid Credential"
+	ErrDuplicate         = "IPDB-007 - Possible duplicate resource"
+	ErrConflict          = "IPDB-008 - Conflict"
+	ErrInvalidToken      = "IPDB-009 - Invalid or missing authentication token"
+	ErrAuthRequired      = "IPDB-010 - You must be authenticated to access this resource"
+	ErrInactiveAccount   = "IPDB-011 - Your user account must be activated to access this resource"
+	ErrNotPermitted      = "IPDB-012 - Your user account doesn't have the necessary permissions to access this resource"
 )
 
 func (app *application) badRequestResponse(ctx *gin.Context, err error) {
@@ -59,3 +65,48 @@ func (app *application) invalidCredentialResponse(ctx *gin.Context) {
 		"message": ErrInvalidCredential,
 	})
 }
+
+func (app *application) duplicateResponse(ctx *gin.Context, candidates any) {
+	ctx.JSON(http.StatusConflict, gin.H{
+		"status":     http.StatusConflict,
+		"message":    ErrDuplicate,
+		"candidates": candidates,
+	})
+}
+
+func (app *application) conflictResponse(ctx *gin.Context, err error) {
+	ctx.JSON(http.StatusConflict, gin.H{
+		"status":  http.StatusConflict,
+		"message": ErrConflict,
+		"error":   err.Error(),
+	})
+}
+
+func (app *application) invalidAuthenticationTokenResponse(ctx *gin.Context) {
+	ctx.Header("WWW-Authenticate", "Bearer")
+	ctx.JSON(http.StatusUnauthorized, gin.H{
+		"status":  http.StatusUnauthorized,
+		"message": ErrInvalidToken,
+	})
+}
+
+func (app *application) authenticationRequiredResponse(ctx *gin.Context) {
+	ctx.JSON(http.StatusUnauthorized, gin.H{
+		"status":  http.StatusUnauthorized,
+		"message": ErrAuthRequired,
+	})
+}
+
+func (app *application) inactiveAccountResponse(ctx *gin.Context) {
+	ctx.JSON(http.StatusForbidden, gin.H{
+		"status":  http.StatusForbidden,
+		"message": ErrInactiveAccount,
+	})
+}
+
+func (app *application) notPermittedResponse(ctx *gin.Context) {
+	ctx.JSON(http.StatusForbidden, gin.H{
+		"status":  http.StatusForbidden,
+		"message": ErrNotPermitted,
+	})
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/feed"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+const feedPlatform = "RSS"
+
+func (app *application) createFeedHandler(ctx *gin.Context) {
+	var input struct {
+		Url string `json:"url" binding:"required,url"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	f, podcast, err := app.ingestFeed(ctx.Request.Context(), input.Url, v)
+	if err != nil {
+		var fe feedError
+		switch {
+		case errors.Is(err, data.ErrDuplicateFeed):
+			v.AddError("url", "this feed has already been added")
+			app.failedValidationResponse(ctx, v.Errors)
+		case errors.As(err, &fe):
+			v.AddError("url", fe.Error())
+			app.failedValidationResponse(ctx, v.Errors)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": http.StatusCreated, "data": gin.H{"feed": f, "podcast": podcast}})
+}
+
+func (app *application) refreshFeedHandler(ctx *gin.Context) {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	f, err := app.models.Feed.FindById(path.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	// Force a full fetch rather than a conditional one.
+	f.ETag, f.LastModified = "", ""
+
+	if err := app.refreshFeed(ctx.Request.Context(), f); err != nil {
+		var fe feedError
+		switch {
+		case errors.As(err, &fe):
+			v := validator.New()
+			v.AddError("feed", fe.Error())
+			app.failedValidationResponse(ctx, v.Errors)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": f})
+}
+
+// ingestFeed fetches url, creates a podcast from its channel and stores its items
+// as episodes. Validation problems are recorded on v and leave nothing stored.
+func (app *application) ingestFeed(ctx context.Context, url string, v *validator.Validator) (*data.Feed, *data.Podcast, error) {
+	if _, err := app.models.Feed.FindByUrl(url); err == nil {
+		return nil, nil, data.ErrDuplicateFeed
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, app.config.feed.fetchTimeout)
+	defer cancel()
+
+	res, err := app.fetcher.Fetch(ctx, url, "", "")
+	if err != nil {
+		return nil, nil, feedError{err}
+	}
+
+	podcast := podcastFromFeed(res.Feed, url)
+
+	if data.ValidatePodcast(v, podcast); !v.Valid() {
+		return nil, nil, nil
+	}
+
+	if err := app.resolvePlatforms(v, podcast, nil); err != nil {
+		return nil, nil, err
+	}
+
+	if !v.Valid() {
+		return nil, nil, nil
+	}
+
+	if err := app.normalizeTags(podcast); err != nil {
+		return nil, nil, err
+	}
+
+	if err := app.linkHostProgram(podcast); err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	f := &data.Feed{
+		Url:           url,
+		ETag:          res.ETag,
+		LastModified:  res.LastModified,
+		LastFetchedAt: &now,
+	}
+
+	if err := app.models.Feed.Insert(f, podcast); err != nil {
+		return nil, nil, err
+	}
+
+	app.storeEpisodes(podcast.Id, res.Feed)
+
+	return f, podcast, nil
+}
+
+// refreshFeed re-fetches f and applies any changes to its podcast and episodes.
+// The fetch outcome is always written back so the scheduler moves on.
+func (app *application) refreshFeed(ctx context.Context, f *data.Feed) error {
+	ctx, cancel := context.WithTimeout(ctx, app.config.feed.fetchTimeout)
+	defer cancel()
+
+	err := app.applyFeed(ctx, f)
+
+	now := time.Now()
+	f.LastFetchedAt = &now
+	f.LastError = ""
+	if err != nil {
+		f.LastError = err.Error()
+	}
+
+	if updateErr := app.models.Feed.UpdateFetchState(f); updateErr != nil {
+		return updateErr
+	}
+
+	return err
+}
+
+func (app *application) applyFeed(ctx context.Context, f *data.Feed) error {
+	res, err := app.fetcher.Fetch(ctx, f.Url, f.ETag, f.LastModified)
+	if err != nil {
+		return feedError{err}
+	}
+
+	f.ETag, f.LastModified = res.ETag, res.LastModified
+
+	if res.NotModified {
+		return nil
+	}
+
+	podcast, err := app.models.Podcast.FindById(f.PodcastId)
+	if err != nil {
+		return err
+	}
+
+	if applyFeedToPodcast(podcast, podcastFromFeed(res.Feed, f.Url)) {
+		// Changes made by a refresh are not anyone's edit.
+		podcast.UpdatedBy = nil
+
+		v := validator.New()
+		if data.ValidatePodcast(v, podcast); !v.Valid() {
+			return feedError{validationError(v)}
+		}
+
+		if err := app.normalizeTags(podcast); err != nil {
+			return err
+		}
+
+		if err := app.linkHostProgram(podcast); err != nil {
+			return err
+		}
+
+		if err := app.models.Podcast.UpdatePodcast(podcast); err != nil {
+			return err
+		}
+	}
+
+	app.storeEpisodes(podcast.Id, res.Feed)
+
+	return nil
+}
+
+// applyFeedToPodcast copies what a refreshed feed says onto podcast and
+// reports whether anything changed. The feed owns the description and can
+// always mark a show explicit; what editors curate, such as the title, host,
+// languages and tags, is only filled in from the feed while it is empty.
+func applyFeedToPodcast(podcast, fresh *data.Podcast) bool {
+	changed := false
+
+	if podcast.Title == "" && fresh.Title != "" {
+		podcast.Title, changed = fresh.Title, true
+	}
+	if fresh.Description != "" && podcast.Description != fresh.Description {
+		podcast.Description, changed = fresh.Description, true
+	}
+	// Editors may mark a show explicit that its feed does not, but a feed
+	// saying it is explicit always wins.
+	if fresh.Explicit && !podcast.Explicit {
+		podcast.Explicit, changed = true, true
+		if podcast.Audience == data.AudienceKids {
+			podcast.Audience = data.AudienceGeneral
+		}
+	}
+	if podcast.HostId == 0 && podcast.Host == "" && fresh.Host != "" {
+		podcast.Host, changed = fresh.Host, true
+	}
+	if len(podcast.Languages) == 0 && len(fresh.Languages) > 0 {
+		podcast.Languages, changed = fresh.Languages, true
+	}
+	if len(podcast.Tags) == 0 && len(fresh.Tags) > 0 {
+		podcast.Tags, changed = fresh.Tags, true
+	}
+
+	return changed
+}
+
+func (app *application) storeEpisodes(podcastId int64, f *feed.Feed) {
+	for _, episode := range episodesFromFeed(podcastId, f) {
+		v := validator.New()
+		if data.ValidateEpisode(v, episode); !v.Valid() {
+			continue
+		}
+		if err := app.models.Episode.Upsert(episode); err != nil {
+			app.logger.Error(err.Error(), "podcast_id", podcastId, "guid", episode.Guid)
+		}
+	}
+}
+
+func (app *application) refreshFeeds() {
+	feeds, err := app.models.Feed.GetDue(app.config.feed.refreshInterval, app.config.feed.batchSize)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+
+	for _, f := range feeds {
+		if err := app.refreshFeed(context.Background(), f); err != nil {
+			app.logger.Error(err.Error(), "feed_id", f.Id, "url", f.Url)
+		}
+	}
+}
+
+func podcastFromFeed(f *feed.Feed, feedUrl string) *data.Podcast {
+	host := firstNonEmpty(f.Author, f.Owner, f.Title)
+
+	podcast := &data.Podcast{
+		Title:       f.Title,
+		Description: truncate(strings.TrimSpace(f.Description), data.MaxDescriptionLength),
+		Platform:    feedPlatform,
+		Url:         firstNonEmpty(f.Link, feedUrl),
+		Host:        host,
+		Program:     firstNonEmpty(f.Owner, f.Title),
+		Languages:   data.NormalizeLanguages(strings.Fields(f.Language)),
+		Year:        int64(time.Now().Year()),
+		Explicit:    f.Explicit,
+		Advisories:  []string{},
+		Audience:    data.AudienceGeneral,
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range f.Categories {
+		tag := strings.ToLower(strings.TrimSpace(c))
+		if tag == "" || seen[tag] || len(podcast.Tags) == 10 {
+			continue
+		}
+		seen[tag] = true
+		podcast.Tags = append(podcast.Tags, tag)
+	}
+	if len(podcast.Tags) == 0 {
+		podcast.Tags = []string{"uncategorized"}
+	}
+
+	seen = map[string]bool{host: true}
+	for _, item := range f.Items {
+		if !item.Published.IsZero() && int64(item.Published.Year()) < podcast.Year {
+			podcast.Year = int64(item.Published.Year())
+		}
+		if item.Author == "" || seen[item.Author] || len(podcast.GuestSpeakers) == 10 {
+			continue
+		}
+		seen[item.Author] = true
+		podcast.GuestSpeakers = append(podcast.GuestSpeakers, item.Author)
+	}
+	if len(podcast.GuestSpeakers) == 0 {
+		podcast.GuestSpeakers = []string{host}
+	}
+
+	if url, err := data.CanonicalURL(podcast.Url); err == nil {
+		podcast.Url = url
+	}
+
+	// The catalog does not accept shows older than 2003.
+	if podcast.Year < 2003 {
+		podcast.Year = 2003
+	}
+
+	return podcast
+}
+
+func episodesFromFeed(podcastId int64, f *feed.Feed) []*data.Episode {
+	episodes := make([]*data.Episode, 0, len(f.Items))
+
+	for _, item := range f.Items {
+		episode := &data.Episode{
+			PodcastId:   podcastId,
+			Guid:        item.Guid,
+			Title:       item.Title,
+			Description: item.Description,
+			Url:         item.Link,
+			AudioUrl:    item.AudioUrl,
+			Duration:    int64(item.Duration.Seconds()),
+		}
+		if !item.Published.IsZero() {
+			published := item.Published
+			episode.PublishedAt = &published
+		}
+		episodes = append(episodes, episode)
+	}
+
+	return episodes
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// truncate cuts s to at most n bytes without splitting a character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// feedError is a feed that could not be fetched, parsed or turned into a valid
+// podcast: a problem with the feed rather than with the server.
+type feedError struct {
+	err error
+}
+
+func (e feedError) Error() string { return e.err.Error() }
+
+func (e feedError) Unwrap() error { return e.err }
+
+func validationError(v *validator.Validator) error {
+	fields := make([]string, 0, len(v.Errors))
+	for field, msg := range v.Errors {
+		fields = append(fields, field+" "+msg)
+	}
+	sort.Strings(fields)
+	return errors.New(strings.Join(fields, "; "))
+}
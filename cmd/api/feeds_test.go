@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/terajari/ipdb/internal/data"
+)
+
+func TestApplyFeedToPodcast(t *testing.T) {
+	curated := func() *data.Podcast {
+		return &data.Podcast{
+			Title:       "Curated Title",
+			Description: "Old description.",
+			HostId:      7,
+			Host:        "Curated Host",
+			Languages:   []string{"id", "en"},
+			Tags:        []string{"comedy", "culture"},
+			Audience:    data.AudienceKids,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		fresh   data.Podcast
+		want    func(p *data.Podcast)
+		changed bool
+	}{
+		{
+			name:  "nothing new",
+			fresh: data.Podcast{Title: "Feed Title", Description: "Old description.", Host: "Feed Author", Languages: []string{"fr"}, Tags: []string{"uncategorized"}},
+			want:  func(p *data.Podcast) {},
+		},
+		{
+			name:    "description from the feed",
+			fresh:   data.Podcast{Title: "Feed Title", Description: "New description.", Tags: []string{"news"}},
+			want:    func(p *data.Podcast) { p.Description = "New description." },
+			changed: true,
+		},
+		{
+			name:  "empty feed description",
+			fresh: data.Podcast{Title: "Feed Title"},
+			want:  func(p *data.Podcast) {},
+		},
+		{
+			name:  "explicit feed",
+			fresh: data.Podcast{Description: "Old description.", Explicit: true},
+			want: func(p *data.Podcast) {
+				p.Explicit = true
+				p.Audience = data.AudienceGeneral
+			},
+			changed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := curated()
+			want := curated()
+			tt.want(want)
+
+			fresh := tt.fresh
+			if changed := applyFeedToPodcast(got, &fresh); changed != tt.changed {
+				t.Errorf("changed = %v, want %v", changed, tt.changed)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got  %+v\nwant %+v", got, want)
+			}
+		})
+	}
+
+	t.Run("fills empty fields", func(t *testing.T) {
+		got := &data.Podcast{}
+		fresh := &data.Podcast{Title: "Feed Title", Host: "Feed Author", Languages: []string{"id"}, Tags: []string{"news"}}
+
+		if !applyFeedToPodcast(got, fresh) {
+			t.Error("changed = false, want true")
+		}
+		want := &data.Podcast{Title: "Feed Title", Host: "Feed Author", Languages: []string{"id"}, Tags: []string{"news"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got  %+v\nwant %+v", got, want)
+		}
+	})
+}
@@ -1,10 +1,17 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
 
 func (app *application) background(fn func()) {
 	app.wg.Add(1)
 	go func() {
+		defer app.wg.Done()
 		defer func() {
 			if err := recover(); err != nil {
 				app.logger.Error(fmt.Sprintf("%v", err))
@@ -13,3 +20,38 @@ func (app *application) background(fn func()) {
 		fn()
 	}()
 }
+
+// schedule runs fn through background every interval until the server shuts
+// down. A tick that comes while the previous run is still going is skipped.
+func (app *application) schedule(interval time.Duration, fn func()) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var running atomic.Bool
+
+		for {
+			select {
+			case <-ticker.C:
+				if !running.CompareAndSwap(false, true) {
+					continue
+				}
+				app.background(func() {
+					defer running.Store(false)
+					fn()
+				})
+			case <-app.quit:
+				return
+			}
+		}
+	}()
+}
+
+// baseURL returns the scheme and host the client used to reach the API.
+func baseURL(ctx *gin.Context) string {
+	scheme := "http"
+	if ctx.Request.TLS != nil || ctx.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + ctx.Request.Host
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+var historySortSafelist = []string{"listened_at", "updated_at", "-listened_at", "-updated_at"}
+
+func (app *application) listHistoryHandler(ctx *gin.Context) {
+	var input struct {
+		data.HistoryFilters
+		data.Filters
+	}
+
+	input.Filters = *data.DefaultsFilters(data.Filters{Sort: "-listened_at", SortSafelist: historySortSafelist})
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	entries, metadata, err := app.models.History.GetAllForUser(app.contextGetUser(ctx).Id, input.HistoryFilters, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": entries, "metadata": metadata})
+}
+
+// syncHistoryHandler takes the progress a client recorded, possibly while
+// offline, and reports for each entry whether it was applied, was older than
+// what is stored, or named an unknown podcast or episode.
+func (app *application) syncHistoryHandler(ctx *gin.Context) {
+	var input struct {
+		Entries []struct {
+			PodcastId  int64     `json:"podcast_id"`
+			EpisodeId  *int64    `json:"episode_id"`
+			Position   int       `json:"position"`
+			Completed  bool      `json:"completed"`
+			ListenedAt time.Time `json:"listened_at"`
+		} `json:"entries"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	v.Check(len(input.Entries) > 0, "entries", "must contain at least one entry")
+	v.Check(len(input.Entries) <= data.MaxHistoryBatch, "entries", fmt.Sprintf("must not contain more than %d entries", data.MaxHistoryBatch))
+
+	entries := make([]*data.HistoryEntry, len(input.Entries))
+
+	for i, in := range input.Entries {
+		entries[i] = &data.HistoryEntry{
+			PodcastId:  in.PodcastId,
+			EpisodeId:  in.EpisodeId,
+			Position:   in.Position,
+			Completed:  in.Completed,
+			ListenedAt: in.ListenedAt,
+		}
+
+		ev := validator.New()
+		data.ValidateHistoryEntry(ev, entries[i])
+		for key, message := range ev.Errors {
+			v.AddError(fmt.Sprintf("entries[%d].%s", i, key), message)
+		}
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	outcomes, err := app.models.History.Sync(app.contextGetUser(ctx).Id, entries)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	results := make([]gin.H, len(entries))
+	for i, entry := range entries {
+		results[i] = gin.H{"podcast_id": entry.PodcastId, "episode_id": entry.EpisodeId, "result": outcomes[i]}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": results})
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+var hostSortSafelist = []string{"name", "podcasts", "created_at", "-name", "-podcasts", "-created_at"}
+
+func (app *application) listHostsHandler(ctx *gin.Context) {
+	var input struct {
+		Name string `form:"name"`
+		data.Filters
+	}
+
+	input.Filters = *data.DefaultsFilters(data.Filters{Sort: "name", SortSafelist: hostSortSafelist})
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	hosts, metadata, err := app.models.Host.GetAll(input.Name, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": hosts, "metadata": metadata})
+}
+
+func (app *application) createHostHandler(ctx *gin.Context) {
+	var input struct {
+		Name string `json:"name"`
+		Kind string `json:"kind"`
+		Url  string `json:"url"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	host := &data.Host{
+		Name: strings.TrimSpace(input.Name),
+		Kind: firstNonEmpty(input.Kind, data.HostKindPerson),
+		Url:  input.Url,
+	}
+
+	v := validator.New()
+
+	if data.ValidateHost(v, host); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err := app.models.Host.Insert(host)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateHost):
+			v.AddError("name", "a host with this name already exists")
+			app.failedValidationResponse(ctx, v.Errors)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/v1/hosts/%d", host.Id))
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": http.StatusCreated, "data": host})
+}
+
+// findHost loads the host named in the path. On failure it writes the response
+// and returns nil.
+func (app *application) findHost(ctx *gin.Context) *data.Host {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return nil
+	}
+
+	host, err := app.models.Host.FindById(path.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return nil
+	}
+
+	return host
+}
+
+func (app *application) getHostHandler(ctx *gin.Context) {
+	host := app.findHost(ctx)
+	if host == nil {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": host})
+}
+
+func (app *application) updateHostHandler(ctx *gin.Context) {
+	var input struct {
+		Name *string `json:"name"`
+		Kind *string `json:"kind"`
+		Url  *string `json:"url"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	host := app.findHost(ctx)
+	if host == nil {
+		return
+	}
+
+	if input.Name != nil {
+		host.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Kind != nil {
+		host.Kind = *input.Kind
+	}
+	if input.Url != nil {
+		host.Url = *input.Url
+	}
+
+	v := validator.New()
+
+	if data.ValidateHost(v, host); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err := app.models.Host.Update(host)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateHost):
+			v.AddError("name", "a host with this name already exists")
+			app.failedValidationResponse(ctx, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": host})
+}
+
+func (app *application) deleteHostHandler(ctx *gin.Context) {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	err := app.models.Host.DeleteById(path.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		case errors.Is(err, data.ErrHostInUse):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": "host successfully deleted"})
+}
+
+func (app *application) listHostPodcastsHandler(ctx *gin.Context) {
+	host := app.findHost(ctx)
+	if host == nil {
+		return
+	}
+
+	podcastFilters, filters, ok := app.readPodcastQuery(ctx, data.Filters{SortSafelist: podcastSortSafelist})
+	if !ok {
+		return
+	}
+
+	podcastFilters.HostId = host.Id
+
+	podcasts, metadata, err := app.models.Podcast.GetAll(podcastFilters, filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": podcasts, "metadata": metadata})
+}
+
+// lookupHostProgram fills in the names of the host and program the client
+// picked by id. Podcasts that name their host or program instead are linked by
+// linkHostProgram when they are saved.
+func (app *application) lookupHostProgram(v *validator.Validator, podcast *data.Podcast, hostId, programId int64) error {
+	if hostId != 0 {
+		host, err := app.models.Host.FindById(hostId)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			v.AddError("host_id", "must be an existing host")
+		case err != nil:
+			return err
+		default:
+			podcast.HostId, podcast.Host = host.Id, host.Name
+		}
+	}
+
+	if programId != 0 {
+		program, err := app.models.Program.FindById(programId)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			v.AddError("program_id", "must be an existing program")
+		case err != nil:
+			return err
+		default:
+			podcast.ProgramId, podcast.Program = program.Id, program.Name
+		}
+	}
+
+	return nil
+}
+
+// linkHostProgram links the podcast to the host and program it names, creating
+// them the first time they are seen.
+func (app *application) linkHostProgram(podcast *data.Podcast) error {
+	if podcast.HostId == 0 {
+		host, err := app.models.Host.FindOrCreate(podcast.Host)
+		if err != nil {
+			return err
+		}
+		podcast.HostId, podcast.Host = host.Id, host.Name
+	}
+
+	if podcast.ProgramId == 0 {
+		program, err := app.models.Program.FindOrCreate(podcast.Program)
+		if err != nil {
+			return err
+		}
+		podcast.ProgramId, podcast.Program = program.Id, program.Name
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (app *application) listLanguagesHandler(ctx *gin.Context) {
+	languages, err := app.models.Podcast.CountLanguages()
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": languages})
+}
+
+// normalizeLanguages maps the languages of podcasts stored before they were
+// validated onto ISO 639 codes.
+func (app *application) normalizeLanguages() {
+	n, err := app.models.Podcast.NormalizeStoredLanguages()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+
+	if n > 0 {
+		app.logger.Info("normalized podcast languages", "podcasts", n)
+	}
+}
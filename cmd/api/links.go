@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+
+	"github.com/terajari/ipdb/internal/data"
+)
+
+// checkLinks checks a batch of podcast urls that are due and records whether
+// each one still resolves.
+func (app *application) checkLinks() {
+	links, err := app.models.Podcast.GetForLinkCheck(app.config.linkcheck.recheckAfter, app.config.linkcheck.batchSize)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+
+	if len(links) == 0 {
+		return
+	}
+
+	urls := make([]string, len(links))
+	for i, link := range links {
+		urls[i] = link.Url
+	}
+
+	results := app.checker.CheckAll(context.Background(), urls)
+
+	broken := 0
+	for i, res := range results {
+		status := data.LinkStatusOk
+		if res.Broken() {
+			status = data.LinkStatusBroken
+			broken++
+		}
+
+		if err := app.models.Podcast.UpdateLinkStatus(links[i].PodcastId, status, res.StatusCode); err != nil {
+			app.logger.Error(err.Error(), "podcast_id", links[i].PodcastId)
+		}
+	}
+
+	app.logger.Info("checked podcast links", "checked", len(results), "broken", broken)
+}
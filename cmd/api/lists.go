@@ -0,0 +1,409 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+var errFavoritesList = errors.New("the favorites list cannot be renamed or deleted")
+
+func (app *application) listMyListsHandler(ctx *gin.Context) {
+	user := app.contextGetUser(ctx)
+
+	// Make sure favorites shows up even before anything was added to it.
+	if _, err := app.models.List.Favorites(user.Id); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	lists, err := app.models.List.GetAllForUser(user.Id)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": lists})
+}
+
+func (app *application) createListHandler(ctx *gin.Context) {
+	var input struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Public      bool   `json:"public"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	list := &data.List{
+		UserId:      app.contextGetUser(ctx).Id,
+		Kind:        data.ListKindCustom,
+		Name:        strings.TrimSpace(input.Name),
+		Description: strings.TrimSpace(input.Description),
+		Public:      input.Public,
+	}
+
+	v := validator.New()
+
+	if data.ValidateList(v, list); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	if err := app.models.List.Insert(list); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/v1/users/me/lists/%d", list.Id))
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": http.StatusCreated, "data": list})
+}
+
+// findMyList loads the authenticated user's list named in the path. On failure
+// it writes the response and returns nil.
+func (app *application) findMyList(ctx *gin.Context) *data.List {
+	var path struct {
+		Id int64 `uri:"list_id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return nil
+	}
+
+	list, err := app.models.List.FindForUser(path.Id, app.contextGetUser(ctx).Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return nil
+	}
+
+	return list
+}
+
+// findMyFavorites loads the authenticated user's favorites list. On failure it
+// writes the response and returns nil.
+func (app *application) findMyFavorites(ctx *gin.Context) *data.List {
+	list, err := app.models.List.Favorites(app.contextGetUser(ctx).Id)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return nil
+	}
+	return list
+}
+
+// writeListWithItems responds with list and the podcasts in it.
+func (app *application) writeListWithItems(ctx *gin.Context, list *data.List) {
+	items, err := app.models.List.GetItems(list.Id, app.safeMode(ctx))
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	list.Items = items
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": list})
+}
+
+func (app *application) getMyListHandler(ctx *gin.Context) {
+	if list := app.findMyList(ctx); list != nil {
+		app.writeListWithItems(ctx, list)
+	}
+}
+
+func (app *application) getMyFavoritesHandler(ctx *gin.Context) {
+	if list := app.findMyFavorites(ctx); list != nil {
+		app.writeListWithItems(ctx, list)
+	}
+}
+
+// getSharedListHandler shows a public list to anyone holding its share id.
+func (app *application) getSharedListHandler(ctx *gin.Context) {
+	list, err := app.models.List.FindByShareId(ctx.Param("share_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	app.writeListWithItems(ctx, list)
+}
+
+func (app *application) updateListHandler(ctx *gin.Context) {
+	var input struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Public      *bool   `json:"public"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	list := app.findMyList(ctx)
+	if list == nil {
+		return
+	}
+
+	if input.Name != nil {
+		if list.Kind == data.ListKindFavorites {
+			app.conflictResponse(ctx, errFavoritesList)
+			return
+		}
+		list.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		list.Description = strings.TrimSpace(*input.Description)
+	}
+	if input.Public != nil {
+		list.Public = *input.Public
+	}
+
+	v := validator.New()
+
+	if data.ValidateList(v, list); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err := app.models.List.Update(list)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": list})
+}
+
+func (app *application) deleteListHandler(ctx *gin.Context) {
+	list := app.findMyList(ctx)
+	if list == nil {
+		return
+	}
+
+	if list.Kind == data.ListKindFavorites {
+		app.conflictResponse(ctx, errFavoritesList)
+		return
+	}
+
+	err := app.models.List.Delete(list.Id, list.UserId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": "list successfully deleted"})
+}
+
+// addListItem adds the podcast to list, answering 422 when the podcast does
+// not exist. On failure it writes the response and returns false.
+func (app *application) addListItem(ctx *gin.Context, list *data.List, podcastId int64, position int, note string) bool {
+	v := validator.New()
+
+	if data.ValidateListItem(v, position, note); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return false
+	}
+
+	if _, err := app.models.Podcast.FindById(podcastId); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			v.AddError("podcast_id", "must be an existing podcast")
+			app.failedValidationResponse(ctx, v.Errors)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return false
+	}
+
+	err := app.models.List.AddItem(list.Id, podcastId, position, note)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateListItem), errors.Is(err, data.ErrListFull):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return false
+	}
+
+	return true
+}
+
+func (app *application) addMyListItemHandler(ctx *gin.Context) {
+	var input struct {
+		PodcastId int64  `json:"podcast_id" binding:"required,gt=0"`
+		Position  int    `json:"position"`
+		Note      string `json:"note"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	list := app.findMyList(ctx)
+	if list == nil {
+		return
+	}
+
+	if !app.addListItem(ctx, list, input.PodcastId, input.Position, strings.TrimSpace(input.Note)) {
+		return
+	}
+
+	app.writeListWithItems(ctx, list)
+}
+
+func (app *application) updateMyListItemHandler(ctx *gin.Context) {
+	var path struct {
+		PodcastId int64 `uri:"podcast_id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	var input struct {
+		Position int    `json:"position"`
+		Note     string `json:"note"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	list := app.findMyList(ctx)
+	if list == nil {
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateListItem(v, input.Position, input.Note); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err := app.models.List.MoveItem(list.Id, path.PodcastId, input.Position, strings.TrimSpace(input.Note))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	app.writeListWithItems(ctx, list)
+}
+
+func (app *application) removeMyListItemHandler(ctx *gin.Context) {
+	list := app.findMyList(ctx)
+	if list == nil {
+		return
+	}
+
+	app.removeListItem(ctx, list)
+}
+
+func (app *application) removeListItem(ctx *gin.Context, list *data.List) {
+	var path struct {
+		PodcastId int64 `uri:"podcast_id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	err := app.models.List.RemoveItem(list.Id, path.PodcastId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": "podcast successfully removed from list"})
+}
+
+// addFavoriteHandler favorites a podcast. Favoriting it again is not an error.
+func (app *application) addFavoriteHandler(ctx *gin.Context) {
+	var path struct {
+		PodcastId int64 `uri:"podcast_id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	if _, err := app.models.Podcast.FindById(path.PodcastId); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	list := app.findMyFavorites(ctx)
+	if list == nil {
+		return
+	}
+
+	err := app.models.List.AddItem(list.Id, path.PodcastId, 0, "")
+	if err != nil && !errors.Is(err, data.ErrDuplicateListItem) {
+		switch {
+		case errors.Is(err, data.ErrListFull):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": "podcast successfully added to favorites"})
+}
+
+func (app *application) removeFavoriteHandler(ctx *gin.Context) {
+	if list := app.findMyFavorites(ctx); list != nil {
+		app.removeListItem(ctx, list)
+	}
+}
@@ -4,14 +4,22 @@ import (
 	"context"
 	"database/sql"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/lib/pq"
 	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/feed"
+	"github.com/terajari/ipdb/internal/graph"
+	"github.com/terajari/ipdb/internal/linkcheck"
 	"github.com/terajari/ipdb/internal/mailer"
+	"github.com/terajari/ipdb/internal/storage"
+	"github.com/terajari/ipdb/internal/views"
 )
 
 const version = "1.0"
@@ -37,14 +45,66 @@ type config struct {
 		password string
 		sender   string
 	}
+	feed struct {
+		refreshInterval time.Duration
+		fetchTimeout    time.Duration
+		batchSize       int
+	}
+	linkcheck struct {
+		interval     time.Duration
+		recheckAfter time.Duration
+		timeout      time.Duration
+		concurrency  int
+		batchSize    int
+	}
+	similarity struct {
+		interval   time.Duration
+		neighbours int
+	}
+	views struct {
+		flushInterval time.Duration
+	}
+	people struct {
+		interval time.Duration
+	}
+	reports struct {
+		hideThreshold int
+	}
+	storage struct {
+		backend     string
+		dir         string
+		s3Endpoint  string
+		s3Region    string
+		s3Bucket    string
+		s3AccessKey string
+		s3SecretKey string
+	}
+	artwork struct {
+		maxBytes     int64
+		minDimension int
+		maxDimension int
+	}
+	audio struct {
+		maxBytes int64
+		localDir string
+	}
+	editors []string
 }
 
 type application struct {
-	config  config
-	logger  *slog.Logger
-	models  data.Models
-	mailler mailer.Mailer
-	wg      sync.WaitGroup
+	config   config
+	logger   *slog.Logger
+	models   data.Models
+	mailler  mailer.Mailer
+	fetcher  *feed.Fetcher
+	checker  *linkcheck.Checker
+	views    *views.Counter
+	storage  storage.Storage
+	people   atomic.Pointer[graph.Graph]
+	peopleMu sync.Mutex
+	imports  *opmlImports
+	wg       sync.WaitGroup
+	quit     chan struct{}
 }
 
 func main() {
@@ -68,6 +128,46 @@ func main() {
 	flag.StringVar(&cfg.smtp.password, "smtp-password", "3fd239a5c2d90f", "SMTP password")
 	flag.StringVar(&cfg.smtp.sender, "smtp-sender", "IPDB <noreply@ipdb.com>", "SMTP sender")
 
+	flag.DurationVar(&cfg.feed.refreshInterval, "feed-refresh-interval", time.Hour, "Interval between feed refreshes")
+	flag.DurationVar(&cfg.feed.fetchTimeout, "feed-fetch-timeout", 30*time.Second, "Timeout for fetching a single feed")
+	flag.IntVar(&cfg.feed.batchSize, "feed-batch-size", 50, "Maximum number of feeds refreshed per run")
+
+	flag.DurationVar(&cfg.linkcheck.interval, "linkcheck-interval", 15*time.Minute, "Interval between link checker runs")
+	flag.DurationVar(&cfg.linkcheck.recheckAfter, "linkcheck-recheck-after", 24*time.Hour, "Minimum age of a link check before it is repeated")
+	flag.DurationVar(&cfg.linkcheck.timeout, "linkcheck-timeout", 10*time.Second, "Timeout for checking a single link")
+	flag.IntVar(&cfg.linkcheck.concurrency, "linkcheck-concurrency", 8, "Maximum number of links checked at once")
+	flag.IntVar(&cfg.linkcheck.batchSize, "linkcheck-batch-size", 200, "Maximum number of links checked per run")
+
+	flag.DurationVar(&cfg.similarity.interval, "similarity-interval", 6*time.Hour, "Interval between recomputations of similar podcasts")
+	flag.IntVar(&cfg.similarity.neighbours, "similarity-neighbours", 20, "Number of similar podcasts kept for each podcast")
+	flag.DurationVar(&cfg.views.flushInterval, "views-flush-interval", time.Minute, "Interval between writes of counted podcast views")
+	flag.DurationVar(&cfg.people.interval, "people-graph-interval", 15*time.Minute, "Interval between rebuilds of the guest co-appearance graph")
+	flag.IntVar(&cfg.reports.hideThreshold, "reports-hide-threshold", 3, "Number of users reporting a podcast before it is hidden (0 to never hide)")
+
+	flag.StringVar(&cfg.storage.backend, "storage-backend", "local", "File storage backend (local|s3)")
+	flag.StringVar(&cfg.storage.dir, "storage-dir", "./uploads", "Directory for the local storage backend")
+	flag.StringVar(&cfg.storage.s3Endpoint, "s3-endpoint", "http://localhost:9000", "S3 compatible endpoint")
+	flag.StringVar(&cfg.storage.s3Region, "s3-region", "us-east-1", "S3 region")
+	flag.StringVar(&cfg.storage.s3Bucket, "s3-bucket", "ipdb", "S3 bucket")
+	flag.StringVar(&cfg.storage.s3AccessKey, "s3-access-key", "", "S3 access key")
+	flag.StringVar(&cfg.storage.s3SecretKey, "s3-secret-key", "", "S3 secret key")
+
+	flag.Int64Var(&cfg.artwork.maxBytes, "artwork-max-bytes", 10<<20, "Maximum size of an artwork upload in bytes")
+	flag.IntVar(&cfg.artwork.minDimension, "artwork-min-dimension", 300, "Minimum width and height of artwork in pixels")
+	flag.IntVar(&cfg.artwork.maxDimension, "artwork-max-dimension", 4096, "Maximum width and height of artwork in pixels")
+
+	flag.Int64Var(&cfg.audio.maxBytes, "audio-max-bytes", 500<<20, "Maximum size of an uploaded audio file in bytes")
+	flag.StringVar(&cfg.audio.localDir, "audio-local-dir", "", "Directory of audio files editors may read metadata from by path (empty to disable)")
+
+	flag.Func("editors", "Comma-separated emails of users given the editor role at startup", func(s string) error {
+		for _, email := range strings.Split(s, ",") {
+			if email = strings.TrimSpace(email); email != "" {
+				cfg.editors = append(cfg.editors, email)
+			}
+		}
+		return nil
+	})
+
 	flag.Parse()
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
@@ -83,19 +183,55 @@ func main() {
 
 	models := data.NewModels(db)
 
+	store, err := openStorage(cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
 	app := &application{
 		config:  cfg,
 		logger:  logger,
 		models:  models,
 		mailler: mailer.New(cfg.smtp.host, cfg.smtp.port, cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
+		fetcher: feed.NewFetcher(cfg.feed.fetchTimeout, "IPDB/"+version),
+		checker: linkcheck.New(cfg.linkcheck.timeout, cfg.linkcheck.concurrency, "IPDB/"+version),
+		views:   views.NewCounter(),
+		storage: store,
+		imports: newOPMLImports(),
+		quit:    make(chan struct{}),
 	}
 
+	app.grantEditors(cfg.editors)
+	app.background(app.normalizeLanguages)
+
+	app.schedule(cfg.feed.refreshInterval, app.refreshFeeds)
+	app.schedule(cfg.linkcheck.interval, app.checkLinks)
+
+	// Similarities are only as fresh as the last run, so do not leave the table
+	// empty until the first tick after a deploy.
+	app.background(app.computeSimilarities)
+	app.schedule(cfg.similarity.interval, app.computeSimilarities)
+	app.schedule(cfg.views.flushInterval, app.flushViews)
+	app.schedule(cfg.people.interval, app.buildPeopleGraph)
+
 	if err = app.serve(); err != nil {
 		logger.Error(err.Error())
 		os.Exit(1)
 	}
 }
 
+func openStorage(cfg config) (storage.Storage, error) {
+	switch cfg.storage.backend {
+	case "local":
+		return storage.NewLocal(cfg.storage.dir)
+	case "s3":
+		return storage.NewS3(cfg.storage.s3Endpoint, cfg.storage.s3Region, cfg.storage.s3Bucket, cfg.storage.s3AccessKey, cfg.storage.s3SecretKey, 30*time.Second)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.storage.backend)
+	}
+}
+
 func openDb(cfg config) (*sql.DB, error) {
 	db, err := sql.Open("postgres", cfg.db.dsn)
 	if err != nil {
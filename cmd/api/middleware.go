@@ -2,12 +2,17 @@ package main
 
 import (
 	"container/heap"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
 	"golang.org/x/time/rate"
 )
 
@@ -118,3 +123,92 @@ func (app *application) rateLimit() gin.HandlerFunc {
 		}
 	}
 }
+
+// authenticate stores the user owning the request's bearer token in the
+// context, or data.AnonymousUser when there is no Authorization header.
+func (app *application) authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Authorization")
+
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			app.contextSetUser(c, data.AnonymousUser)
+			c.Next()
+			return
+		}
+
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			app.invalidAuthenticationTokenResponse(c)
+			c.Abort()
+			return
+		}
+
+		v := validator.New()
+		if data.ValidatePlaintext(v, token); !v.Valid() {
+			app.invalidAuthenticationTokenResponse(c)
+			c.Abort()
+			return
+		}
+
+		user, err := app.models.User.GetForToken(data.ScopeAuthentication, token)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				app.invalidAuthenticationTokenResponse(c)
+			default:
+				app.serverErrorResponse(c, err)
+			}
+			c.Abort()
+			return
+		}
+
+		app.contextSetUser(c, user)
+		c.Next()
+	}
+}
+
+func (app *application) requireActivatedUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := app.contextGetUser(c)
+
+		if user.IsAnonymous() {
+			app.authenticationRequiredResponse(c)
+			c.Abort()
+			return
+		}
+
+		if !user.Activated {
+			app.inactiveAccountResponse(c)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireEditor lets through activated users with the editor role.
+func (app *application) requireEditor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := app.contextGetUser(c)
+
+		if user.IsAnonymous() {
+			app.authenticationRequiredResponse(c)
+			c.Abort()
+			return
+		}
+
+		if !user.Activated {
+			app.inactiveAccountResponse(c)
+			c.Abort()
+			return
+		}
+
+		if !user.IsEditor() {
+			app.notPermittedResponse(c)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
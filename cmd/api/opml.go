@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/opml"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+const (
+	maxOPMLSize       = 2 << 20
+	maxOPMLOutlines   = 500
+	opmlImportWorkers = 4
+	opmlExportBatch   = 500
+	// opmlImportRetention is how long a finished import can still be looked up.
+	opmlImportRetention = 24 * time.Hour
+)
+
+type opmlImportResult struct {
+	Text      string            `json:"text"`
+	XMLURL    string            `json:"xml_url,omitempty"`
+	HTMLURL   string            `json:"html_url,omitempty"`
+	Status    string            `json:"status"`
+	PodcastId int64             `json:"podcast_id,omitempty"`
+	Error     string            `json:"error,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// opmlImport is an import running in the background, which the client polls
+// until it has finished.
+type opmlImport struct {
+	Id         string             `json:"id"`
+	Status     string             `json:"status"`
+	Total      int                `json:"total"`
+	Processed  int                `json:"processed"`
+	Summary    map[string]int     `json:"summary"`
+	Results    []opmlImportResult `json:"results,omitempty"`
+	StartedAt  time.Time          `json:"started_at"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+	userId     int64
+}
+
+// opmlImports keeps the imports started since the server started, forgetting
+// finished ones after opmlImportRetention.
+type opmlImports struct {
+	mu   sync.Mutex
+	jobs map[string]*opmlImport
+}
+
+func newOPMLImports() *opmlImports {
+	return &opmlImports{jobs: make(map[string]*opmlImport)}
+}
+
+func (imports *opmlImports) add(job *opmlImport) {
+	imports.mu.Lock()
+	defer imports.mu.Unlock()
+
+	for id, j := range imports.jobs {
+		if j.FinishedAt != nil && time.Since(*j.FinishedAt) > opmlImportRetention {
+			delete(imports.jobs, id)
+		}
+	}
+
+	imports.jobs[job.Id] = job
+}
+
+// get returns a copy of the import, safe to read while it is still running.
+func (imports *opmlImports) get(id string) (opmlImport, bool) {
+	imports.mu.Lock()
+	defer imports.mu.Unlock()
+
+	job, ok := imports.jobs[id]
+	if !ok {
+		return opmlImport{}, false
+	}
+
+	snapshot := *job
+	snapshot.Summary = make(map[string]int, len(job.Summary))
+	for status, n := range job.Summary {
+		snapshot.Summary[status] = n
+	}
+	if job.FinishedAt != nil {
+		snapshot.Results = slices.Clone(job.Results)
+	}
+
+	return snapshot, true
+}
+
+// update runs fn on the import while holding the lock.
+func (imports *opmlImports) update(job *opmlImport, fn func(*opmlImport)) {
+	imports.mu.Lock()
+	defer imports.mu.Unlock()
+
+	fn(job)
+}
+
+// importOPMLHandler starts importing the feeds of an OPML document in the
+// background and responds with the import to poll for its results.
+func (app *application) importOPMLHandler(ctx *gin.Context) {
+	var body io.Reader = ctx.Request.Body
+
+	if file, err := ctx.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			app.badRequestResponse(ctx, err)
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	doc, err := opml.Parse(io.LimitReader(body, maxOPMLSize))
+	if err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	outlines := doc.Flatten()
+
+	v := validator.New()
+	v.Check(len(outlines) >= 1, "body", "must contain at least 1 outline with a feed or site url")
+	v.Check(len(outlines) <= maxOPMLOutlines, "body", "must not contain more than 500 outlines")
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	id, err := newJobId()
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	job := &opmlImport{
+		Id:        id,
+		Status:    "running",
+		Total:     len(outlines),
+		Summary:   map[string]int{"created": 0, "matched": 0, "failed": 0},
+		StartedAt: time.Now(),
+		userId:    app.contextGetUser(ctx).Id,
+	}
+
+	app.imports.add(job)
+	snapshot, _ := app.imports.get(job.Id)
+
+	app.background(func() {
+		app.runOPMLImport(job, outlines)
+	})
+
+	ctx.Header("Location", "/v1/podcasts/import/opml/"+job.Id)
+	ctx.JSON(http.StatusAccepted, gin.H{"status": http.StatusAccepted, "data": snapshot})
+}
+
+// runOPMLImport imports the outlines a few at a time, recording each result on
+// job as it comes in. Imports still running at shutdown are cut short.
+func (app *application) runOPMLImport(job *opmlImport, outlines []opml.Outline) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-app.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	results := make([]opmlImportResult, len(outlines))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, opmlImportWorkers)
+
+	for i, o := range outlines {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, o opml.Outline) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			result := app.importOutline(ctx, o)
+			results[i] = result
+
+			app.imports.update(job, func(job *opmlImport) {
+				job.Processed++
+				job.Summary[result.Status]++
+			})
+		}(i, o)
+	}
+
+	wg.Wait()
+
+	app.imports.update(job, func(job *opmlImport) {
+		now := time.Now()
+		job.Status = "finished"
+		job.Results = results
+		job.FinishedAt = &now
+	})
+}
+
+// getOPMLImportHandler shows the progress of an import, and its results once it
+// has finished. Only the editor who started it can see it.
+func (app *application) getOPMLImportHandler(ctx *gin.Context) {
+	job, ok := app.imports.get(ctx.Param("id"))
+	if !ok || job.userId != app.contextGetUser(ctx).Id {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": job})
+}
+
+func newJobId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// importOutline matches an outline against known feeds and podcast urls, and
+// ingests its feed when nothing matches.
+func (app *application) importOutline(ctx context.Context, o opml.Outline) opmlImportResult {
+	result := opmlImportResult{
+		Text:    o.Name(),
+		XMLURL:  o.XMLURL,
+		HTMLURL: firstNonEmpty(o.HTMLURL, o.URL),
+	}
+
+	fail := func(err error) opmlImportResult {
+		result.Status = "failed"
+		result.Error = err.Error()
+		return result
+	}
+
+	if o.XMLURL != "" {
+		f, err := app.models.Feed.FindByUrl(o.XMLURL)
+		switch {
+		case err == nil:
+			result.Status = "matched"
+			result.PodcastId = f.PodcastId
+			return result
+		case !errors.Is(err, sql.ErrNoRows):
+			return fail(err)
+		}
+	}
+
+	for _, url := range []string{result.HTMLURL, o.XMLURL} {
+		if url == "" {
+			continue
+		}
+		podcast, err := app.models.Podcast.FindByUrl(url)
+		switch {
+		case err == nil:
+			result.Status = "matched"
+			result.PodcastId = podcast.Id
+			return result
+		case !errors.Is(err, sql.ErrNoRows):
+			return fail(err)
+		}
+	}
+
+	if o.XMLURL == "" {
+		return fail(errors.New("no matching podcast and no feed url to import from"))
+	}
+
+	v := validator.New()
+
+	_, podcast, err := app.ingestFeed(ctx, o.XMLURL, v)
+	if err != nil {
+		return fail(err)
+	}
+
+	if !v.Valid() {
+		result.Status = "failed"
+		result.Errors = v.Errors
+		return result
+	}
+
+	result.Status = "created"
+	result.PodcastId = podcast.Id
+	return result
+}
+
+// exportOPMLHandler streams every podcast matching the query as an OPML
+// document, looking up feeds a batch of podcasts at a time.
+func (app *application) exportOPMLHandler(ctx *gin.Context) {
+	podcastFilters, filters, ok := app.readPodcastQuery(ctx, data.Filters{SortSafelist: podcastSortSafelist})
+	if !ok {
+		return
+	}
+
+	var enc *opml.Encoder
+	batch := make([]*data.Podcast, 0, opmlExportBatch)
+
+	flush := func() error {
+		if enc == nil {
+			ctx.Header("Content-Disposition", `attachment; filename="ipdb.opml"`)
+			ctx.Header("Content-Type", "text/x-opml; charset=utf-8")
+			ctx.Status(http.StatusOK)
+
+			var err error
+			if enc, err = opml.NewEncoder(ctx.Writer, "IPDB podcasts"); err != nil {
+				return err
+			}
+		}
+
+		ids := make([]int64, len(batch))
+		for i, p := range batch {
+			ids[i] = p.Id
+		}
+
+		feeds, err := app.models.Feed.GetByPodcastIds(ids)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range batch {
+			if err := enc.Encode(podcastOutline(p, feeds[p.Id])); err != nil {
+				return err
+			}
+		}
+
+		batch = batch[:0]
+		return nil
+	}
+
+	err := app.models.Podcast.Stream(podcastFilters, filters, func(p *data.Podcast) error {
+		batch = append(batch, p)
+		if len(batch) == opmlExportBatch {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err == nil {
+		err = enc.Close()
+	}
+
+	if err != nil {
+		// Once the document has started the status can no longer change, so
+		// the client is left with a truncated document.
+		if enc == nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+		app.logger.Error(err.Error())
+	}
+}
+
+// podcastOutline describes p by its feed when it has one, and by its url
+// otherwise.
+func podcastOutline(p *data.Podcast, f *data.Feed) opml.Outline {
+	outline := opml.Outline{
+		Text:     p.Title,
+		Title:    p.Title,
+		HTMLURL:  p.Url,
+		Language: firstNonEmpty(p.Languages...),
+	}
+	if f != nil {
+		outline.Type = "rss"
+		outline.XMLURL = f.Url
+	} else {
+		outline.Type = "link"
+		outline.URL = p.Url
+		outline.HTMLURL = ""
+	}
+	return outline
+}
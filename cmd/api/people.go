@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/graph"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+// maxNetworkNodes caps how many people a co-appearance network returns, so a
+// well connected guest at depth 3 does not pull in the whole graph.
+const maxNetworkNodes = 500
+
+var errPeopleGraphUnavailable = errors.New("co-appearance graph could not be built")
+
+// buildPeopleGraph rebuilds the co-appearance graph the people endpoints read
+// from.
+func (app *application) buildPeopleGraph() {
+	appearances, err := app.models.Podcast.GetAppearances()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+
+	g := graph.New()
+	for _, a := range appearances {
+		g.AddPodcast(a.PodcastId, a.Title, a.People)
+	}
+
+	app.people.Store(g)
+}
+
+// peopleGraph returns the co-appearance graph, building it if no scheduled
+// run has yet. Requests that arrive while it is being built wait for it
+// rather than building it again. On failure it writes the response and
+// returns nil.
+func (app *application) peopleGraph(ctx *gin.Context) *graph.Graph {
+	if g := app.people.Load(); g != nil {
+		return g
+	}
+
+	app.peopleMu.Lock()
+	if app.people.Load() == nil {
+		app.buildPeopleGraph()
+	}
+	app.peopleMu.Unlock()
+
+	g := app.people.Load()
+	if g == nil {
+		app.serverErrorResponse(ctx, errPeopleGraphUnavailable)
+	}
+	return g
+}
+
+func (app *application) peopleNetworkHandler(ctx *gin.Context) {
+	var input struct {
+		Depth int `form:"depth"`
+	}
+
+	input.Depth = 1
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	if v.Check(input.Depth >= 1 && input.Depth <= 3, "depth", "must be between 1 and 3"); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	g := app.peopleGraph(ctx)
+	if g == nil {
+		return
+	}
+
+	name := ctx.Param("name")
+
+	sub, truncated, ok := g.Neighbourhood(name, input.Depth, maxNetworkNodes)
+	if !ok {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	network := sub.JGF(name)
+	network.Graph.Metadata = map[string]any{"depth": input.Depth, "truncated": truncated}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": network})
+}
+
+// peoplePathHandler answers how many hops of shared podcasts separate two
+// people, and through whom.
+func (app *application) peoplePathHandler(ctx *gin.Context) {
+	var input struct {
+		From string `form:"from"`
+		To   string `form:"to"`
+	}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	v.Check(graph.Key(input.From) != "", "from", "must be provided")
+	v.Check(graph.Key(input.To) != "", "to", "must be provided")
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	g := app.peopleGraph(ctx)
+	if g == nil {
+		return
+	}
+
+	path, ok := g.ShortestPath(input.From, input.To)
+	if !ok {
+		app.notFoundResponse(ctx)
+		return
+	}
+
+	type podcast struct {
+		Id    int64  `json:"id"`
+		Title string `json:"title"`
+	}
+
+	type hop struct {
+		Name     string    `json:"name"`
+		Podcasts []podcast `json:"podcasts,omitempty"`
+	}
+
+	hops := make([]hop, len(path))
+	for i, h := range path {
+		hops[i].Name = h.Node.Name
+		for _, id := range h.Podcasts {
+			hops[i].Podcasts = append(hops[i].Podcasts, podcast{Id: id, Title: g.Title(id)})
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": gin.H{"degrees": len(path) - 1, "path": hops}})
+}
+
+// exportPeopleGraphHandler downloads the whole co-appearance graph as JSON
+// Graph Format, or as GraphML with format=graphml.
+func (app *application) exportPeopleGraphHandler(ctx *gin.Context) {
+	var input struct {
+		Format string `form:"format"`
+	}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	if v.Check(validator.PermitedValues(input.Format, "", "json", "graphml"), "format", "must be json or graphml"); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	g := app.peopleGraph(ctx)
+	if g == nil {
+		return
+	}
+
+	if input.Format != "graphml" {
+		ctx.Header("Content-Disposition", `attachment; filename="ipdb-people.json"`)
+		ctx.JSON(http.StatusOK, g.JGF("IPDB co-appearances"))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := g.WriteGraphML(&buf); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="ipdb-people.graphml"`)
+	ctx.Data(http.StatusOK, "application/graphml+xml; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+type platformInput struct {
+	Platform string `json:"platform"`
+	Url      string `json:"url"`
+}
+
+func (app *application) createPlatformHandler(ctx *gin.Context) {
+	var input struct {
+		Name        string   `json:"name"`
+		Slug        string   `json:"slug"`
+		Aliases     []string `json:"aliases"`
+		UrlPatterns []string `json:"url_patterns"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	platform := &data.Platform{
+		Name:        input.Name,
+		Slug:        firstNonEmpty(input.Slug, data.Slugify(input.Name)),
+		Aliases:     input.Aliases,
+		UrlPatterns: input.UrlPatterns,
+	}
+
+	if platform.Aliases == nil {
+		platform.Aliases = []string{}
+	}
+	if platform.UrlPatterns == nil {
+		platform.UrlPatterns = []string{}
+	}
+
+	v := validator.New()
+
+	if data.ValidatePlatform(v, platform); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err := app.models.Platform.Insert(platform)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicatePlatform):
+			v.AddError("name", "a platform with this name or slug already exists")
+			app.failedValidationResponse(ctx, v.Errors)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/v1/platforms/%d", platform.Id))
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": http.StatusCreated, "data": platform})
+}
+
+func (app *application) listPlatformsHandler(ctx *gin.Context) {
+	platforms, err := app.models.Platform.GetAll()
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": platforms})
+}
+
+func (app *application) getPlatformHandler(ctx *gin.Context) {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	platform, err := app.models.Platform.FindById(path.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": platform})
+}
+
+func (app *application) updatePlatformHandler(ctx *gin.Context) {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	var input struct {
+		Name        *string  `json:"name"`
+		Slug        *string  `json:"slug"`
+		Aliases     []string `json:"aliases"`
+		UrlPatterns []string `json:"url_patterns"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	platform, err := app.models.Platform.FindById(path.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	oldName := platform.Name
+
+	if input.Name != nil {
+		platform.Name = *input.Name
+	}
+	if input.Slug != nil {
+		platform.Slug = *input.Slug
+	}
+	if input.Aliases != nil {
+		platform.Aliases = input.Aliases
+	}
+	if input.UrlPatterns != nil {
+		platform.UrlPatterns = input.UrlPatterns
+	}
+
+	v := validator.New()
+
+	if data.ValidatePlatform(v, platform); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err = app.models.Platform.Update(platform, oldName)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicatePlatform):
+			v.AddError("name", "a platform with this name or slug already exists")
+			app.failedValidationResponse(ctx, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": platform})
+}
+
+func (app *application) deletePlatformHandler(ctx *gin.Context) {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	err := app.models.Platform.DeleteById(path.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		case errors.Is(err, data.ErrPlatformInUse):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": "platform successfully deleted"})
+}
+
+// resolvePlatforms maps the podcast's primary platform and any additional ones
+// onto known platforms, checks every url against its platform's patterns and
+// fills podcast.Platforms. Problems with the input are recorded on v.
+func (app *application) resolvePlatforms(v *validator.Validator, podcast *data.Podcast, extra []platformInput) error {
+	if podcast.Platform == "" {
+		return nil
+	}
+
+	primary, err := app.models.Platform.Resolve(podcast.Platform)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			v.AddError("platform", "must be a known platform")
+			return nil
+		default:
+			return err
+		}
+	}
+
+	podcast.Platform = primary.Name
+	v.Check(primary.Matches(podcast.Url), "url", fmt.Sprintf("must be a %s url", primary.Name))
+
+	podcast.Platforms = []data.PodcastPlatform{{PlatformId: primary.Id, Platform: primary.Name, Url: podcast.Url}}
+	seen := map[int64]bool{primary.Id: true}
+
+	v.Check(len(extra) <= 20, "platforms", "must not contain more than 20 platforms")
+
+	for _, in := range extra {
+		platform, err := app.models.Platform.Resolve(in.Platform)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				v.AddError("platforms", fmt.Sprintf("%q is not a known platform", in.Platform))
+				continue
+			default:
+				return err
+			}
+		}
+
+		if seen[platform.Id] {
+			if platform.Id != primary.Id {
+				v.AddError("platforms", "must not contain duplicate platforms")
+			}
+			continue
+		}
+		seen[platform.Id] = true
+
+		url, err := data.CanonicalURL(in.Url)
+		if err != nil {
+			v.AddError("platforms", fmt.Sprintf("url for %s must be an absolute http or https url", platform.Name))
+			continue
+		}
+		v.Check(platform.Matches(url), "platforms", fmt.Sprintf("url for %s must be a %s url", platform.Name, platform.Name))
+
+		podcast.Platforms = append(podcast.Platforms, data.PodcastPlatform{PlatformId: platform.Id, Platform: platform.Name, Url: url})
+	}
+
+	return nil
+}
+
+// extraPlatforms returns the podcast's platforms other than its primary one, in
+// the form accepted by resolvePlatforms.
+func extraPlatforms(podcast *data.Podcast) []platformInput {
+	var extra []platformInput
+	for _, p := range podcast.Platforms {
+		if p.Platform != podcast.Platform {
+			extra = append(extra, platformInput{Platform: p.Platform, Url: p.Url})
+		}
+	}
+	return extra
+}
@@ -2,64 +2,221 @@ package main
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/country"
 	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/language"
 	"github.com/terajari/ipdb/internal/validator"
 )
 
-func (app *application) createPodcastHandler(ctx *gin.Context) {
-	var input struct {
-		Title         string   `json:"title"`
-		Platform      string   `json:"platform"`
-		Url           string   `json:"url"`
-		Host          string   `json:"host"`
-		Program       string   `json:"program"`
-		GuestSpeakers []string `json:"guest_speakers"`
-		Year          int64    `json:"year"`
-		Language      string   `json:"language"`
-		Tags          []string `json:"tags"`
+// podcastInput is the body of a request creating or updating a podcast.
+type podcastInput struct {
+	Title         string          `json:"title"`
+	Description   *string         `json:"description"`
+	Platform      string          `json:"platform"`
+	Url           string          `json:"url"`
+	Host          string          `json:"host"`
+	HostId        int64           `json:"host_id"`
+	Program       string          `json:"program"`
+	ProgramId     int64           `json:"program_id"`
+	GuestSpeakers []string        `json:"guest_speakers"`
+	Year          int64           `json:"year"`
+	Language      string          `json:"language"`
+	Languages     []string        `json:"languages"`
+	Tags          []string        `json:"tags"`
+	Platforms     []platformInput `json:"platforms"`
+	Explicit      *bool           `json:"explicit"`
+	Advisories    []string        `json:"advisories"`
+	Audience      string          `json:"audience"`
+	Country       *string         `json:"country"`
+}
+
+// newPodcast builds the podcast a create request describes.
+func (in podcastInput) newPodcast() *data.Podcast {
+	url := in.Url
+	if canonical, err := data.CanonicalURL(in.Url); err == nil {
+		url = canonical
+	}
+
+	podcast := &data.Podcast{
+		Title:         in.Title,
+		Platform:      in.Platform,
+		Url:           url,
+		Host:          in.Host,
+		Program:       in.Program,
+		GuestSpeakers: in.GuestSpeakers,
+		Year:          in.Year,
+		Languages:     readLanguages(in.Language, in.Languages),
+		Tags:          in.Tags,
+	}
+	if in.Description != nil {
+		podcast.Description = strings.TrimSpace(*in.Description)
+	}
+
+	podcast.Audience = data.AudienceGeneral
+	podcast.Advisories = []string{}
+	in.applyAdvisory(podcast)
+
+	return podcast
+}
+
+// applyAdvisory overwrites the explicit flag, advisories, audience and country
+// the request sets. Countries are normalized to ISO 3166-1 codes when known and
+// left as given otherwise, for validation to reject.
+func (in podcastInput) applyAdvisory(podcast *data.Podcast) {
+	if in.Explicit != nil {
+		podcast.Explicit = *in.Explicit
+	}
+	if in.Advisories != nil {
+		podcast.Advisories = in.Advisories
+	}
+	if in.Audience != "" {
+		podcast.Audience = in.Audience
+	}
+	if in.Country != nil {
+		podcast.Country = strings.TrimSpace(*in.Country)
+		if code, ok := country.Normalize(podcast.Country); ok {
+			podcast.Country = code
+		}
 	}
+}
+
+// applyTo overwrites the fields of podcast an update request sets, and returns
+// the platforms besides the primary one the podcast should be linked to.
+func (in podcastInput) applyTo(podcast *data.Podcast) []platformInput {
+	if in.Title != "" {
+		podcast.Title = in.Title
+	}
+	if in.Description != nil {
+		podcast.Description = strings.TrimSpace(*in.Description)
+	}
+	if in.Platform != "" {
+		podcast.Platform = in.Platform
+	}
+	if in.Url != "" {
+		podcast.Url = in.Url
+		if url, err := data.CanonicalURL(in.Url); err == nil {
+			podcast.Url = url
+		}
+	}
+	if in.Host != "" {
+		podcast.HostId, podcast.Host = 0, in.Host
+	}
+	if in.Program != "" {
+		podcast.ProgramId, podcast.Program = 0, in.Program
+	}
+	if in.GuestSpeakers != nil {
+		podcast.GuestSpeakers = in.GuestSpeakers
+	}
+	if in.Year != 0 {
+		podcast.Year = in.Year
+	}
+	if in.Language != "" || in.Languages != nil {
+		podcast.Languages = readLanguages(in.Language, in.Languages)
+	}
+	if in.Tags != nil {
+		podcast.Tags = in.Tags
+	}
+	in.applyAdvisory(podcast)
+
+	if in.Platforms != nil {
+		return in.Platforms
+	}
+	return extraPlatforms(podcast)
+}
+
+// checkPodcast resolves the host and program the input picked by id, validates
+// the podcast and links it to its platforms. Problems with the input are
+// recorded on v.
+func (app *application) checkPodcast(v *validator.Validator, podcast *data.Podcast, in podcastInput, extra []platformInput) error {
+	if err := app.lookupHostProgram(v, podcast, in.HostId, in.ProgramId); err != nil {
+		return err
+	}
+
+	if data.ValidatePodcast(v, podcast); !v.Valid() {
+		return nil
+	}
+
+	return app.resolvePlatforms(v, podcast, extra)
+}
+
+// checkDuplicates answers with the existing podcasts a new one looks like,
+// unless the client passed force=true. It returns false when it wrote a
+// response.
+func (app *application) checkDuplicates(ctx *gin.Context, podcast *data.Podcast) bool {
+	var query struct {
+		Force bool `form:"force"`
+	}
+
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		app.badRequestResponse(ctx, err)
+		return false
+	}
+
+	if query.Force {
+		return true
+	}
+
+	candidates, err := app.models.Podcast.FindDuplicates(podcast)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return false
+	}
+	if len(candidates) > 0 {
+		app.duplicateResponse(ctx, candidates)
+		return false
+	}
+
+	return true
+}
+
+func (app *application) createPodcastHandler(ctx *gin.Context) {
+	var input podcastInput
 
 	if err := ctx.ShouldBindJSON(&input); err != nil {
 		app.badRequestResponse(ctx, err)
 		return
 	}
 
+	podcast := input.newPodcast()
+
 	v := validator.New()
-	data.ValidatePodcast(v, &data.Podcast{
-		Title:         input.Title,
-		Platform:      input.Platform,
-		Url:           input.Url,
-		Host:          input.Host,
-		Program:       input.Program,
-		GuestSpeakers: input.GuestSpeakers,
-		Year:          input.Year,
-		Language:      input.Language,
-		Tags:          input.Tags,
-	})
+
+	if err := app.checkPodcast(v, podcast, input, input.Platforms); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
 
 	if !v.Valid() {
 		app.failedValidationResponse(ctx, v.Errors)
 		return
 	}
 
-	podcast := data.Podcast{
-		Title:         input.Title,
-		Platform:      input.Platform,
-		Url:           input.Url,
-		Host:          input.Host,
-		Program:       input.Program,
-		GuestSpeakers: input.GuestSpeakers,
-		Year:          input.Year,
-		Language:      input.Language,
-		Tags:          input.Tags,
+	if !app.checkDuplicates(ctx, podcast) {
+		return
+	}
+
+	if err := app.normalizeTags(podcast); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	if err := app.linkHostProgram(podcast); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
 	}
 
-	err := app.models.Podcast.Insert(&podcast)
+	podcast.CreatedBy = &app.contextGetUser(ctx).Id
+
+	err := app.models.Podcast.Insert(podcast)
 	if err != nil {
 		app.serverErrorResponse(ctx, err)
 		return
@@ -70,23 +227,81 @@ func (app *application) createPodcastHandler(ctx *gin.Context) {
 	ctx.JSON(http.StatusCreated, gin.H{"status": http.StatusOK, "data": podcast})
 }
 
-func (app *application) getPodcastsHandler(ctx *gin.Context) {
+// readPodcastRef reads the podcast named by the :id path parameter, which may
+// be its id, its public id or one of its slugs. Ids are returned as given,
+// without checking the podcast exists. On failure it writes the response and
+// returns nil.
+func (app *application) readPodcastRef(ctx *gin.Context) *data.PodcastRef {
+	key := ctx.Param("id")
+
+	if id, err := strconv.ParseInt(key, 10, 64); err == nil {
+		if id <= 0 {
+			app.badRequestResponse(ctx, errors.New("id must be a positive integer"))
+			return nil
+		}
+		return &data.PodcastRef{Id: id}
+	}
+
+	ref, err := app.models.Podcast.Resolve(key)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return nil
+	}
+
+	return ref
+}
 
-	var path struct {
-		Id int64 `uri:"id" binding:"required,gt=0"`
+// resolvePodcastKey returns the id of the podcast key names, as an id, public id
+// or current or former slug. It returns sql.ErrNoRows when key names none.
+func (app *application) resolvePodcastKey(key string) (int64, error) {
+	if id, err := strconv.ParseInt(key, 10, 64); err == nil {
+		if id <= 0 {
+			return 0, sql.ErrNoRows
+		}
+		return id, nil
 	}
 
-	if err := ctx.ShouldBindUri(&path); err != nil {
-		app.logger.Info(err.Error())
-		app.badRequestResponse(ctx, err)
+	ref, err := app.models.Podcast.Resolve(key)
+	if err != nil {
+		return 0, err
+	}
+	return ref.Id, nil
+}
+
+// readPodcastId is readPodcastRef for handlers that only need the id. On
+// failure it writes the response and returns 0.
+func (app *application) readPodcastId(ctx *gin.Context) int64 {
+	ref := app.readPodcastRef(ctx)
+	if ref == nil {
+		return 0
+	}
+	return ref.Id
+}
+
+// getPodcastsHandler returns a podcast by id, public id or slug, translated
+// as Accept-Language asks where it can be. Former slugs are redirected to the
+// current one, and ids of merged podcasts to the podcast they were merged into.
+func (app *application) getPodcastsHandler(ctx *gin.Context) {
+	ref := app.readPodcastRef(ctx)
+	if ref == nil {
 		return
 	}
 
-	podcast, err := app.models.Podcast.FindById(path.Id)
+	if key := ctx.Param("id"); ref.Slug != "" && key != ref.Slug && key != ref.PublicId {
+		ctx.Redirect(http.StatusMovedPermanently, "/v1/podcasts/"+url.PathEscape(ref.Slug))
+		return
+	}
+
+	podcast, err := app.models.Podcast.FindById(ref.Id)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
-			app.notFoundResponse(ctx)
+			app.redirectMergedPodcast(ctx, ref.Id)
 			return
 		default:
 			app.serverErrorResponse(ctx, err)
@@ -94,37 +309,44 @@ func (app *application) getPodcastsHandler(ctx *gin.Context) {
 		}
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": podcast})
-}
+	if podcast.Hidden && !app.contextGetUser(ctx).IsEditor() {
+		app.notFoundResponse(ctx)
+		return
+	}
 
-func (app *application) updatePodcastHandler(ctx *gin.Context) {
-	var path struct {
-		Id int64 `uri:"id" binding:"required,gt=0"`
+	if podcast.HiddenInSafeMode() && app.safeMode(ctx) {
+		app.notFoundResponse(ctx)
+		return
 	}
 
-	if err := ctx.ShouldBindUri(&path); err != nil {
-		app.badRequestResponse(ctx, err)
+	if err := app.localizePodcasts(ctx, podcast); err != nil {
+		app.serverErrorResponse(ctx, err)
 		return
 	}
 
-	var input struct {
-		Title         string   `json:"title"`
-		Platform      string   `json:"platform"`
-		Url           string   `json:"url"`
-		Host          string   `json:"host"`
-		Program       string   `json:"program"`
-		GuestSpeakers []string `json:"guest_speakers"`
-		Year          int64    `json:"year"`
-		Language      string   `json:"language"`
-		Tags          []string `json:"tags"`
+	if podcast.Locale != "" {
+		ctx.Header("Content-Language", podcast.Locale)
 	}
 
+	app.views.Add(podcast.Id)
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": podcast})
+}
+
+func (app *application) updatePodcastHandler(ctx *gin.Context) {
+	podcastId := app.readPodcastId(ctx)
+	if podcastId == 0 {
+		return
+	}
+
+	var input podcastInput
+
 	if err := ctx.ShouldBindJSON(&input); err != nil {
 		app.badRequestResponse(ctx, err)
 		return
 	}
 
-	podcast, err := app.models.Podcast.FindById(path.Id)
+	podcast, err := app.models.Podcast.FindById(podcastId)
 
 	if err != nil {
 		switch {
@@ -137,53 +359,32 @@ func (app *application) updatePodcastHandler(ctx *gin.Context) {
 		}
 	}
 
-	if input.Title != "" {
-		podcast.Title = input.Title
-	}
-	if input.Platform != "" {
-		podcast.Platform = input.Platform
-	}
-	if input.Url != "" {
-		podcast.Url = input.Url
-	}
-	if input.Host != "" {
-		podcast.Host = input.Host
-	}
-	if input.Program != "" {
-		podcast.Program = input.Program
-	}
-	if input.GuestSpeakers != nil {
-		podcast.GuestSpeakers = input.GuestSpeakers
-	}
-	if input.Year != 0 {
-		podcast.Year = input.Year
-	}
-	if input.Language != "" {
-		podcast.Language = input.Language
-	}
-	if input.Tags != nil {
-		podcast.Tags = input.Tags
-	}
+	extra := input.applyTo(podcast)
 
 	v := validator.New()
 
-	data.ValidatePodcast(v, &data.Podcast{
-		Title:         podcast.Title,
-		Platform:      podcast.Platform,
-		Url:           podcast.Url,
-		Host:          podcast.Host,
-		Program:       podcast.Program,
-		GuestSpeakers: podcast.GuestSpeakers,
-		Year:          podcast.Year,
-		Language:      podcast.Language,
-		Tags:          podcast.Tags,
-	})
+	if err := app.checkPodcast(v, podcast, input, extra); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
 
 	if !v.Valid() {
 		app.failedValidationResponse(ctx, v.Errors)
 		return
 	}
 
+	if err := app.normalizeTags(podcast); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	if err := app.linkHostProgram(podcast); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	podcast.UpdatedBy = &app.contextGetUser(ctx).Id
+
 	err = app.models.Podcast.UpdatePodcast(podcast)
 	if err != nil {
 		app.serverErrorResponse(ctx, err)
@@ -194,16 +395,12 @@ func (app *application) updatePodcastHandler(ctx *gin.Context) {
 
 func (app *application) deletePodcastHandler(ctx *gin.Context) {
 
-	var path struct {
-		Id int64 `uri:"id" binding:"required,gt=0"`
-	}
-
-	if err := ctx.ShouldBindUri(&path); err != nil {
-		app.badRequestResponse(ctx, err)
+	podcastId := app.readPodcastId(ctx)
+	if podcastId == 0 {
 		return
 	}
 
-	err := app.models.Podcast.DeleteById(path.Id)
+	err := app.models.Podcast.DeleteById(podcastId)
 	if err != nil {
 		switch {
 		case errors.Is(err, sql.ErrNoRows):
@@ -218,45 +415,310 @@ func (app *application) deletePodcastHandler(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": "podcast successfully deleted"})
 }
 
-func (app *application) listPodcastHandler(ctx *gin.Context) {
+var podcastSortSafelist = []string{
+	"title",
+	"platform",
+	"host",
+	"program",
+	"guest_speakers",
+	"year",
+	"languages",
+	"tags",
+	"created_at",
+	"updated_at",
+	"rating",
+	"rating_count",
+	"favorites_count",
+	"-title",
+	"-platform",
+	"-host",
+	"-program",
+	"-guest_speakers",
+	"-year",
+	"-languages",
+	"-tags",
+	"-created_at",
+	"-updated_at",
+	"-rating",
+	"-rating_count",
+	"-favorites_count",
+}
+
+// podcastSortAliases are sort keys kept working for clients written before
+// the columns they name were renamed.
+var podcastSortAliases = map[string]string{
+	"language":  "languages",
+	"-language": "-languages",
+}
+
+// readPodcastQuery binds and validates the podcast list query string on top of
+// the given defaults. On failure it writes the response and returns false.
+func (app *application) readPodcastQuery(ctx *gin.Context, defaults data.Filters) (data.PodcastFilters, data.Filters, bool) {
 	var input struct {
-		Platform string   `form:"platform"`
-		Tags     []string `form:"tags"`
+		data.PodcastFilters
 		data.Filters
 	}
 
-	input.Filters.SortSafelist = []string{
-		"title",
-		"platform",
-		"host",
-		"program",
-		"guest_speakers",
-		"year",
-		"language",
-		"tags",
+	input.Filters = *data.DefaultsFilters(defaults)
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return data.PodcastFilters{}, data.Filters{}, false
+	}
+
+	if sort, ok := podcastSortAliases[input.Sort]; ok {
+		input.Sort = sort
 	}
 
-	input.Filters = *data.DefaultsFilters(input.Filters)
+	v := validator.New()
+
+	data.ValidateFilters(v, input.Filters)
+	data.ValidatePodcastFilters(v, input.PodcastFilters)
 
-	if err := ctx.ShouldBindQuery(&input); err != nil {
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return data.PodcastFilters{}, data.Filters{}, false
+	}
+
+	if input.Platform != "" {
+		platform, err := app.models.Platform.Resolve(input.Platform)
+		if err != nil {
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				v.AddError("platform", "must be a known platform")
+				app.failedValidationResponse(ctx, v.Errors)
+			default:
+				app.serverErrorResponse(ctx, err)
+			}
+			return data.PodcastFilters{}, data.Filters{}, false
+		}
+		input.Platform = platform.Slug
+	}
+
+	if input.Language != "" {
+		input.Language, _ = language.Normalize(input.Language)
+	}
+
+	if input.Country != "" {
+		input.Country, _ = country.Normalize(input.Country)
+	}
+
+	input.Filters.SafeMode = app.safeMode(ctx)
+
+	if len(input.Tags) > 0 {
+		tags, err := app.models.Tag.Canonicalize(input.Tags)
+		if err != nil {
+			app.serverErrorResponse(ctx, err)
+			return data.PodcastFilters{}, data.Filters{}, false
+		}
+		input.Tags = tags
+	}
+
+	return input.PodcastFilters, input.Filters, true
+}
+
+func (app *application) listPodcastHandler(ctx *gin.Context) {
+	if ids, ok := ctx.GetQuery("ids"); ok {
+		app.getPodcastBatchFromQuery(ctx, ids)
+		return
+	}
+
+	podcastFilters, filters, ok := app.readPodcastQuery(ctx, data.Filters{SortSafelist: podcastSortSafelist})
+	if !ok {
+		return
+	}
+
+	podcasts, metadata, err := app.models.Podcast.GetAll(podcastFilters, filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	localized := make([]*data.Podcast, len(*podcasts))
+	for i := range *podcasts {
+		localized[i] = &(*podcasts)[i]
+	}
+
+	if err := app.localizePodcasts(ctx, localized...); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": podcasts, "metadata": metadata})
+}
+
+// getPodcastBatchFromQuery answers GET /podcasts?ids=1,2,3.
+func (app *application) getPodcastBatchFromQuery(ctx *gin.Context, list string) {
+	var keys []string
+
+	for _, field := range strings.Split(list, ",") {
+		keys = append(keys, strings.TrimSpace(field))
+	}
+
+	app.writePodcastBatch(ctx, keys)
+}
+
+// podcastKey is a podcast id, public id or slug in a request body. Ids may be
+// sent as JSON numbers, as they were before podcasts had other keys.
+type podcastKey string
+
+func (k *podcastKey) UnmarshalJSON(b []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err == nil {
+		*k = podcastKey(n)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return errors.New("ids must contain only numbers and strings")
+	}
+	*k = podcastKey(s)
+	return nil
+}
+
+// batchGetPodcastsHandler returns the podcasts with the ids in the request
+// body, for clients that would rather not put them in the URL.
+func (app *application) batchGetPodcastsHandler(ctx *gin.Context) {
+	var input struct {
+		Ids []podcastKey `json:"ids"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
 		app.badRequestResponse(ctx, err)
 		return
 	}
 
+	keys := make([]string, len(input.Ids))
+	for i, key := range input.Ids {
+		keys[i] = strings.TrimSpace(string(key))
+	}
+
+	app.writePodcastBatch(ctx, keys)
+}
+
+// writePodcastBatch looks up many podcasts in one query and responds with them
+// in the order of keys. Keys are resolved as the single GET resolves them: ids,
+// including those of merged podcasts, public ids and current or former slugs.
+// Repeated keys, and keys naming the same podcast, are answered once. Keys of
+// podcasts that do not exist, or that the client could not get one at a time,
+// are reported as missing rather than failing the whole request.
+func (app *application) writePodcastBatch(ctx *gin.Context, keys []string) {
 	v := validator.New()
 
-	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+	var (
+		unique  []string
+		ids     []int64
+		others  []string
+		numeric = make(map[string]int64)
+		seen    = make(map[string]bool)
+	)
+
+	for _, key := range keys {
+		if id, err := strconv.ParseInt(key, 10, 64); err == nil {
+			v.Check(id > 0, "ids", "must contain only positive ids")
+			key = strconv.FormatInt(id, 10)
+			if !seen[key] {
+				numeric[key] = id
+				ids = append(ids, id)
+			}
+		} else {
+			v.Check(key != "", "ids", "must not contain empty ids")
+			if !seen[key] {
+				others = append(others, key)
+			}
+		}
+
+		if !seen[key] {
+			seen[key] = true
+			unique = append(unique, key)
+		}
+	}
+
+	v.Check(len(unique) >= 1, "ids", "must contain at least 1 id")
+	v.Check(len(unique) <= data.MaxBatchSize, "ids", fmt.Sprintf("must not contain more than %d ids", data.MaxBatchSize))
+
+	if !v.Valid() {
 		app.failedValidationResponse(ctx, v.Errors)
 		return
 	}
 
-	podcasts, metadata, err := app.models.Podcast.GetAll(input.Platform, input.Tags, input.Filters)
+	redirects, err := app.models.Podcast.FindRedirects(ids)
 	if err != nil {
 		app.serverErrorResponse(ctx, err)
 		return
 	}
 
-	app.logger.Info(input.Platform)
+	resolved, err := app.models.Podcast.ResolveAll(others)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
 
-	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": podcasts, "metadata": metadata})
+	for key, id := range numeric {
+		if newId, ok := redirects[id]; ok {
+			id = newId
+		}
+		resolved[key] = id
+	}
+
+	targets := make([]int64, 0, len(unique))
+	for _, key := range unique {
+		if id, ok := resolved[key]; ok {
+			targets = append(targets, id)
+		}
+	}
+
+	found, err := app.models.Podcast.FindByIds(targets)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	editor := app.contextGetUser(ctx).IsEditor()
+	safe := app.safeMode(ctx)
+
+	byId := make(map[int64]*data.Podcast, len(found))
+	for _, podcast := range found {
+		if podcast.Hidden && !editor || podcast.HiddenInSafeMode() && safe {
+			continue
+		}
+		byId[podcast.Id] = podcast
+	}
+
+	podcasts := make([]*data.Podcast, 0, len(byId))
+	missing := []any{}
+	answered := make(map[int64]bool, len(byId))
+	for _, key := range unique {
+		podcast, ok := byId[resolved[key]]
+		switch {
+		case !ok:
+			// Ids stay numbers in the response, as clients sent them before
+			// podcasts had other keys.
+			if id, ok := numeric[key]; ok {
+				missing = append(missing, id)
+			} else {
+				missing = append(missing, key)
+			}
+		case !answered[podcast.Id]:
+			answered[podcast.Id] = true
+			podcasts = append(podcasts, podcast)
+		}
+	}
+
+	if err := app.localizePodcasts(ctx, podcasts...); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": podcasts, "missing": missing})
+}
+
+// readLanguages combines the single language and the language list a client may
+// send into normalized ISO 639 codes.
+func readLanguages(single string, list []string) []string {
+	if list == nil && single != "" {
+		list = []string{single}
+	}
+	return data.NormalizeLanguages(list)
 }
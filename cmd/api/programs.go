@@ -0,0 +1,220 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+var programSortSafelist = []string{"name", "podcasts", "created_at", "-name", "-podcasts", "-created_at"}
+
+func (app *application) listProgramsHandler(ctx *gin.Context) {
+	var input struct {
+		Name string `form:"name"`
+		data.Filters
+	}
+
+	input.Filters = *data.DefaultsFilters(data.Filters{Sort: "name", SortSafelist: programSortSafelist})
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	programs, metadata, err := app.models.Program.GetAll(input.Name, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": programs, "metadata": metadata})
+}
+
+func (app *application) createProgramHandler(ctx *gin.Context) {
+	var input struct {
+		Name        string `json:"name"`
+		Url         string `json:"url"`
+		Description string `json:"description"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	program := &data.Program{
+		Name:        strings.TrimSpace(input.Name),
+		Url:         input.Url,
+		Description: strings.TrimSpace(input.Description),
+	}
+
+	v := validator.New()
+
+	if data.ValidateProgram(v, program); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err := app.models.Program.Insert(program)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateProgram):
+			v.AddError("name", "a program with this name already exists")
+			app.failedValidationResponse(ctx, v.Errors)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/v1/programs/%d", program.Id))
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": http.StatusCreated, "data": program})
+}
+
+// findProgram loads the program named in the path. On failure it writes the response
+// and returns nil.
+func (app *application) findProgram(ctx *gin.Context) *data.Program {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return nil
+	}
+
+	program, err := app.models.Program.FindById(path.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return nil
+	}
+
+	return program
+}
+
+func (app *application) getProgramHandler(ctx *gin.Context) {
+	program := app.findProgram(ctx)
+	if program == nil {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": program})
+}
+
+func (app *application) updateProgramHandler(ctx *gin.Context) {
+	var input struct {
+		Name        *string `json:"name"`
+		Url         *string `json:"url"`
+		Description *string `json:"description"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	program := app.findProgram(ctx)
+	if program == nil {
+		return
+	}
+
+	if input.Name != nil {
+		program.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Url != nil {
+		program.Url = *input.Url
+	}
+	if input.Description != nil {
+		program.Description = strings.TrimSpace(*input.Description)
+	}
+
+	v := validator.New()
+
+	if data.ValidateProgram(v, program); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err := app.models.Program.Update(program)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateProgram):
+			v.AddError("name", "a program with this name already exists")
+			app.failedValidationResponse(ctx, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": program})
+}
+
+func (app *application) deleteProgramHandler(ctx *gin.Context) {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	err := app.models.Program.DeleteById(path.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		case errors.Is(err, data.ErrProgramInUse):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": "program successfully deleted"})
+}
+
+func (app *application) listProgramPodcastsHandler(ctx *gin.Context) {
+	program := app.findProgram(ctx)
+	if program == nil {
+		return
+	}
+
+	podcastFilters, filters, ok := app.readPodcastQuery(ctx, data.Filters{SortSafelist: podcastSortSafelist})
+	if !ok {
+		return
+	}
+
+	podcastFilters.ProgramId = program.Id
+
+	podcasts, metadata, err := app.models.Podcast.GetAll(podcastFilters, filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": podcasts, "metadata": metadata})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+var reportSortSafelist = []string{"created_at", "resolved_at", "-created_at", "-resolved_at"}
+
+// createReportHandler flags a podcast for the moderators. Once enough users
+// have open reports against it the podcast is hidden from the catalog until
+// an editor resolves them.
+func (app *application) createReportHandler(ctx *gin.Context) {
+	var input struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	podcastId := app.readExistingPodcast(ctx)
+	if podcastId == 0 {
+		return
+	}
+
+	user := app.contextGetUser(ctx)
+
+	report := &data.Report{
+		PodcastId: podcastId,
+		UserId:    user.Id,
+		UserName:  user.Name,
+		Reason:    input.Reason,
+		Details:   strings.TrimSpace(input.Details),
+	}
+
+	v := validator.New()
+
+	if data.ValidateReport(v, report); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	if _, err := app.models.Report.Insert(report, app.config.reports.hideThreshold); err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateReport):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": http.StatusCreated, "data": report})
+}
+
+// listReportsHandler lists reports for editors, the oldest open ones first
+// unless asked otherwise.
+func (app *application) listReportsHandler(ctx *gin.Context) {
+	var input struct {
+		data.ReportFilters
+		data.Filters
+	}
+
+	input.ReportFilters.Status = data.ReportOpen
+	input.Filters = *data.DefaultsFilters(data.Filters{Sort: "created_at", SortSafelist: reportSortSafelist})
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	data.ValidateFilters(v, input.Filters)
+	data.ValidateReportFilters(v, input.ReportFilters)
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	reports, metadata, err := app.models.Report.GetAll(input.ReportFilters, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": reports, "metadata": metadata})
+}
+
+// resolveReportHandler closes the open reports against a podcast. Upholding
+// them keeps the podcast hidden; dismissing them puts it back in the catalog.
+func (app *application) resolveReportHandler(ctx *gin.Context) {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	var input struct {
+		Outcome string `json:"outcome"`
+		Comment string `json:"comment"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	comment := strings.TrimSpace(input.Comment)
+
+	v := validator.New()
+
+	v.Check(validator.PermitedValues(input.Outcome, data.ReportUpheld, data.ReportDismissed), "outcome", "must be upheld or dismissed")
+	v.Check(len(comment) <= 2000, "comment", "must not be more than 2000 bytes long")
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	report, err := app.models.Report.FindById(path.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	err = app.models.Report.Resolve(report, input.Outcome, app.contextGetUser(ctx).Id, comment)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		case errors.Is(err, data.ErrReportResolved):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": report})
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+var reviewSortSafelist = []string{"created_at", "updated_at", "score", "-created_at", "-updated_at", "-score"}
+
+// readExistingPodcast reads the podcast from the path and checks it exists. On failure it writes the response and returns 0.
+func (app *application) readExistingPodcast(ctx *gin.Context) int64 {
+	podcastId := app.readPodcastId(ctx)
+	if podcastId == 0 {
+		return 0
+	}
+
+	if _, err := app.models.Podcast.FindById(podcastId); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return 0
+	}
+
+	return podcastId
+}
+
+func (app *application) listReviewsHandler(ctx *gin.Context) {
+	podcastId := app.readExistingPodcast(ctx)
+	if podcastId == 0 {
+		return
+	}
+
+	filters := *data.DefaultsFilters(data.Filters{Sort: "-created_at", SortSafelist: reviewSortSafelist})
+
+	if err := ctx.ShouldBindQuery(&filters); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	reviews, metadata, err := app.models.Review.GetAllForPodcast(podcastId, filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": reviews, "metadata": metadata})
+}
+
+func (app *application) createReviewHandler(ctx *gin.Context) {
+	var input struct {
+		Score int    `json:"score"`
+		Body  string `json:"body"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	podcastId := app.readExistingPodcast(ctx)
+	if podcastId == 0 {
+		return
+	}
+
+	user := app.contextGetUser(ctx)
+
+	review := &data.Review{
+		UserId:    user.Id,
+		UserName:  user.Name,
+		PodcastId: podcastId,
+		Score:     input.Score,
+		Body:      strings.TrimSpace(input.Body),
+	}
+
+	v := validator.New()
+
+	if data.ValidateReview(v, review); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err := app.models.Review.Insert(review)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateReview):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": http.StatusCreated, "data": review})
+}
+
+// updateReviewHandler edits the authenticated user's review of the podcast.
+func (app *application) updateReviewHandler(ctx *gin.Context) {
+	var input struct {
+		Score *int    `json:"score"`
+		Body  *string `json:"body"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	podcastId := app.readExistingPodcast(ctx)
+	if podcastId == 0 {
+		return
+	}
+
+	review, err := app.models.Review.Find(app.contextGetUser(ctx).Id, podcastId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	if input.Score != nil {
+		review.Score = *input.Score
+	}
+	if input.Body != nil {
+		review.Body = strings.TrimSpace(*input.Body)
+	}
+
+	v := validator.New()
+
+	if data.ValidateReview(v, review); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err = app.models.Review.Update(review)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrEditConflict):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": review})
+}
+
+// deleteReviewHandler removes the authenticated user's review of the podcast.
+func (app *application) deleteReviewHandler(ctx *gin.Context) {
+	podcastId := app.readExistingPodcast(ctx)
+	if podcastId == 0 {
+		return
+	}
+
+	err := app.models.Review.Delete(app.contextGetUser(ctx).Id, podcastId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": "review successfully deleted"})
+}
@@ -8,17 +8,120 @@ func (app *application) routes() *gin.Engine {
 	router := gin.Default()
 
 	rg := router.Group("/v1")
-	rg.Use(app.recoverPanic(), app.rateLimit())
+	rg.Use(app.recoverPanic(), app.rateLimit(), app.authenticate())
 
 	rg.GET("/podcasts", app.listPodcastHandler)
 	rg.GET("/healthcheck", app.healthcheckHandler)
-	rg.POST("/podcasts", app.createPodcastHandler)
+	rg.POST("/podcasts", app.requireEditor(), app.createPodcastHandler)
 	rg.GET("/podcasts/:id", app.getPodcastsHandler)
-	rg.PUT("/podcasts/:id", app.updatePodcastHandler)
-	rg.DELETE("/podcasts/:id", app.deletePodcastHandler)
+	rg.PUT("/podcasts/:id", app.requireEditor(), app.updatePodcastHandler)
+	rg.DELETE("/podcasts/:id", app.requireEditor(), app.deletePodcastHandler)
+	rg.GET("/podcasts/duplicates", app.listDuplicatesHandler)
+	rg.POST("/podcasts/batch-get", app.batchGetPodcastsHandler)
+	rg.POST("/podcasts/:id/merge", app.requireEditor(), app.mergePodcastHandler)
+	rg.POST("/podcasts/import/opml", app.requireEditor(), app.importOPMLHandler)
+	rg.GET("/podcasts/import/opml/:id", app.requireEditor(), app.getOPMLImportHandler)
+	rg.GET("/podcasts/export/opml", app.exportOPMLHandler)
+	rg.GET("/podcasts/:id/episodes", app.listEpisodesHandler)
+	rg.POST("/podcasts/:id/episodes", app.requireEditor(), app.createEpisodeHandler)
+	rg.GET("/podcasts/:id/reviews", app.listReviewsHandler)
+	rg.POST("/podcasts/:id/reviews", app.requireActivatedUser(), app.createReviewHandler)
+	rg.PUT("/podcasts/:id/reviews", app.requireActivatedUser(), app.updateReviewHandler)
+	rg.DELETE("/podcasts/:id/reviews", app.requireActivatedUser(), app.deleteReviewHandler)
+	rg.GET("/podcasts/:id/similar", app.listSimilarHandler)
+	rg.PUT("/podcasts/:id/artwork", app.requireEditor(), app.uploadArtworkHandler)
+	rg.POST("/podcasts/:id/reports", app.requireActivatedUser(), app.createReportHandler)
+	rg.GET("/podcasts/:id/translations", app.listTranslationsHandler)
+	rg.PUT("/podcasts/:id/translations/:language", app.requireEditor(), app.putTranslationHandler)
+	rg.DELETE("/podcasts/:id/translations/:language", app.requireEditor(), app.deleteTranslationHandler)
+
+	rg.GET("/artwork/*key", app.serveArtworkHandler)
+
+	rg.GET("/episodes/:id", app.getEpisodeHandler)
+	rg.POST("/episodes/:id/audio-metadata", app.requireEditor(), app.extractAudioMetadataHandler)
+	rg.GET("/episodes/:id/transcript", app.getTranscriptHandler)
+	rg.PUT("/episodes/:id/transcript", app.requireEditor(), app.putTranscriptHandler)
+	rg.DELETE("/episodes/:id/transcript", app.requireEditor(), app.deleteTranscriptHandler)
+
+	rg.GET("/search/transcripts", app.searchTranscriptsHandler)
+
+	rg.GET("/feeds/podcasts.rss", app.podcastsRSSHandler)
+	rg.GET("/feeds/podcasts.atom", app.podcastsAtomHandler)
+	rg.POST("/feeds", app.requireEditor(), app.createFeedHandler)
+	rg.POST("/feeds/:id/refresh", app.requireEditor(), app.refreshFeedHandler)
+
+	rg.GET("/platforms", app.listPlatformsHandler)
+	rg.POST("/platforms", app.requireEditor(), app.createPlatformHandler)
+	rg.GET("/platforms/:id", app.getPlatformHandler)
+	rg.PUT("/platforms/:id", app.requireEditor(), app.updatePlatformHandler)
+	rg.DELETE("/platforms/:id", app.requireEditor(), app.deletePlatformHandler)
+
+	rg.GET("/tags", app.listTagsHandler)
+	rg.POST("/tags", app.requireEditor(), app.createTagHandler)
+	rg.GET("/tags/:slug", app.getTagHandler)
+	rg.PUT("/tags/:slug", app.requireEditor(), app.updateTagHandler)
+	rg.DELETE("/tags/:slug", app.requireEditor(), app.deleteTagHandler)
+	rg.GET("/tags/:slug/podcasts", app.listTagPodcastsHandler)
+
+	rg.GET("/languages", app.listLanguagesHandler)
+
+	rg.GET("/charts/trending", app.trendingChartHandler)
+	rg.GET("/charts/top", app.topChartHandler)
+
+	rg.GET("/stats", app.statsHandler)
+
+	rg.POST("/suggestions", app.requireActivatedUser(), app.createSuggestionHandler)
+	rg.GET("/suggestions/:id", app.requireActivatedUser(), app.getSuggestionHandler)
+
+	moderation := rg.Group("/moderation", app.requireEditor())
+	moderation.GET("/queue", app.moderationQueueHandler)
+	moderation.GET("/queue/:id", app.getSuggestionHandler)
+	moderation.POST("/queue/:id/approve", app.approveSuggestionHandler)
+	moderation.POST("/queue/:id/reject", app.rejectSuggestionHandler)
+	moderation.GET("/reports", app.listReportsHandler)
+	moderation.POST("/reports/:id/resolve", app.resolveReportHandler)
+
+	rg.GET("/people/graph", app.exportPeopleGraphHandler)
+	rg.GET("/people/path", app.peoplePathHandler)
+	rg.GET("/people/:name/network", app.peopleNetworkHandler)
+
+	rg.GET("/hosts", app.listHostsHandler)
+	rg.POST("/hosts", app.requireEditor(), app.createHostHandler)
+	rg.GET("/hosts/:id", app.getHostHandler)
+	rg.PUT("/hosts/:id", app.requireEditor(), app.updateHostHandler)
+	rg.DELETE("/hosts/:id", app.requireEditor(), app.deleteHostHandler)
+	rg.GET("/hosts/:id/podcasts", app.listHostPodcastsHandler)
+
+	rg.GET("/programs", app.listProgramsHandler)
+	rg.POST("/programs", app.requireEditor(), app.createProgramHandler)
+	rg.GET("/programs/:id", app.getProgramHandler)
+	rg.PUT("/programs/:id", app.requireEditor(), app.updateProgramHandler)
+	rg.DELETE("/programs/:id", app.requireEditor(), app.deleteProgramHandler)
+	rg.GET("/programs/:id/podcasts", app.listProgramPodcastsHandler)
 
 	rg.POST("/users", app.createUserHandler)
 	rg.PUT("/users/activated", app.activateUserHandler)
+	rg.PUT("/users/:id/role", app.requireEditor(), app.updateUserRoleHandler)
+
+	me := rg.Group("/users/me", app.requireActivatedUser())
+	me.GET("/lists", app.listMyListsHandler)
+	me.POST("/lists", app.createListHandler)
+	me.GET("/lists/:list_id", app.getMyListHandler)
+	me.PUT("/lists/:list_id", app.updateListHandler)
+	me.DELETE("/lists/:list_id", app.deleteListHandler)
+	me.POST("/lists/:list_id/items", app.addMyListItemHandler)
+	me.PUT("/lists/:list_id/items/:podcast_id", app.updateMyListItemHandler)
+	me.DELETE("/lists/:list_id/items/:podcast_id", app.removeMyListItemHandler)
+	me.GET("/favorites", app.getMyFavoritesHandler)
+	me.PUT("/favorites/:podcast_id", app.addFavoriteHandler)
+	me.DELETE("/favorites/:podcast_id", app.removeFavoriteHandler)
+	me.GET("/history", app.listHistoryHandler)
+	me.POST("/history", app.syncHistoryHandler)
+	me.GET("/recommendations", app.listRecommendationsHandler)
+	me.GET("/suggestions", app.listMySuggestionsHandler)
+	me.PUT("/settings", app.updateMySettingsHandler)
+
+	rg.GET("/lists/:share_id", app.getSharedListHandler)
 
 	rg.POST("/tokens/authentication", app.authTokenHandler)
 
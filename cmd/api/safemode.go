@@ -0,0 +1,91 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// safeMode reports whether explicit podcasts and those aimed at adults should
+// be left out of the response: the user turned safe mode on for their
+// account, or the request asks for it with "Prefer: safe" (RFC 8674) or
+// safe=true. A request cannot turn off safe mode the user turned on.
+func (app *application) safeMode(ctx *gin.Context) bool {
+	ctx.Writer.Header().Add("Vary", "Prefer")
+
+	safe := app.contextGetUser(ctx).SafeMode || prefersSafe(ctx.Request.Header.Values("Prefer")) || ctx.Query("safe") == "true"
+	if safe {
+		ctx.Header("Preference-Applied", "safe")
+	}
+
+	return safe
+}
+
+// prefersSafe reports whether Prefer headers include the safe preference.
+func prefersSafe(headers []string) bool {
+	for _, header := range headers {
+		for _, pref := range strings.Split(header, ",") {
+			name, _, _ := strings.Cut(pref, ";")
+			name, _, _ = strings.Cut(name, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "safe") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allowedInSafeMode answers as if the podcast did not exist when safe mode
+// hides it and the request is in safe mode. It returns false when it wrote a
+// response.
+func (app *application) allowedInSafeMode(ctx *gin.Context, podcastId int64) bool {
+	if !app.safeMode(ctx) {
+		return true
+	}
+
+	podcast, err := app.models.Podcast.FindById(podcastId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return false
+	}
+
+	if podcast.HiddenInSafeMode() {
+		app.notFoundResponse(ctx)
+		return false
+	}
+
+	return true
+}
+
+// updateMySettingsHandler changes the authenticated user's account settings,
+// for now only safe mode.
+func (app *application) updateMySettingsHandler(ctx *gin.Context) {
+	var input struct {
+		SafeMode *bool `json:"safe_mode"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	user := app.contextGetUser(ctx)
+
+	if input.SafeMode != nil {
+		if err := app.models.User.SetSafeMode(user.Id, *input.SafeMode); err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+		user.SafeMode = *input.SafeMode
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": gin.H{"safe_mode": user.SafeMode}})
+}
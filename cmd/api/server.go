@@ -36,14 +36,17 @@ func (app *application) serve() error {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		err := srv.Shutdown(ctx)
-		if err != nil {
+		if err := srv.Shutdown(ctx); err != nil {
 			shutdownErr <- err
+			return
 		}
 
-		shutdownErr <- srv.Shutdown(ctx)
-
+		close(app.quit)
 		app.wg.Wait()
+
+		// Keep the views counted since the last flush.
+		app.flushViews()
+
 		shutdownErr <- nil
 	}()
 
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+// computeSimilarities refreshes the table similar podcasts and
+// recommendations are read from.
+func (app *application) computeSimilarities() {
+	start := time.Now()
+
+	pairs, err := app.models.Similar.Recompute(app.config.similarity.neighbours)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+
+	app.logger.Info("computed similar podcasts", "pairs", pairs, "duration", time.Since(start).String())
+}
+
+// readRankingFilters reads the paging of a list ranked by score. On failure it
+// writes the response and returns false.
+func (app *application) readRankingFilters(ctx *gin.Context) (data.Filters, bool) {
+	filters := *data.DefaultsFilters(data.Filters{Sort: "-score", SortSafelist: []string{"-score"}})
+
+	if err := ctx.ShouldBindQuery(&filters); err != nil {
+		app.badRequestResponse(ctx, err)
+		return filters, false
+	}
+
+	v := validator.New()
+
+	if data.ValidateFilters(v, filters); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return filters, false
+	}
+
+	filters.SafeMode = app.safeMode(ctx)
+
+	return filters, true
+}
+
+func (app *application) listSimilarHandler(ctx *gin.Context) {
+	podcastId := app.readExistingPodcast(ctx)
+	if podcastId == 0 {
+		return
+	}
+
+	filters, ok := app.readRankingFilters(ctx)
+	if !ok {
+		return
+	}
+
+	similar, metadata, err := app.models.Similar.GetSimilar(podcastId, filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": similar, "metadata": metadata})
+}
+
+func (app *application) listRecommendationsHandler(ctx *gin.Context) {
+	filters, ok := app.readRankingFilters(ctx)
+	if !ok {
+		return
+	}
+
+	recommended, metadata, err := app.models.Similar.Recommend(app.contextGetUser(ctx).Id, filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": recommended, "metadata": metadata})
+}
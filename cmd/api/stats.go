@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+)
+
+// statsHandler aggregates the podcasts matching the same filters as
+// listPodcastHandler. Paging and sorting parameters are accepted but have no
+// effect.
+func (app *application) statsHandler(ctx *gin.Context) {
+	podcastFilters, _, ok := app.readPodcastQuery(ctx, data.Filters{SortSafelist: podcastSortSafelist})
+	if !ok {
+		return
+	}
+
+	stats, err := app.models.Stats.Get(podcastFilters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": stats})
+}
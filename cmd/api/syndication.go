@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/feed"
+)
+
+func (app *application) podcastsRSSHandler(ctx *gin.Context) {
+	app.servePodcastFeed(ctx, "application/rss+xml; charset=utf-8", feed.WriteRSS)
+}
+
+func (app *application) podcastsAtomHandler(ctx *gin.Context) {
+	app.servePodcastFeed(ctx, "application/atom+xml; charset=utf-8", feed.WriteAtom)
+}
+
+// servePodcastFeed publishes the most recently added or updated podcasts that
+// match the usual list filters, safe mode included, answering 304 when
+// nothing in the catalog changed since the client's If-Modified-Since. Caches
+// may keep the feed but must check back each time, and only the user's own
+// cache may keep a feed that depends on their settings.
+func (app *application) servePodcastFeed(ctx *gin.Context, contentType string, write func(io.Writer, *feed.Channel) error) {
+	podcastFilters, filters, ok := app.readPodcastQuery(ctx, data.Filters{
+		PageSize:     50,
+		Sort:         "-updated_at",
+		SortSafelist: []string{"-updated_at", "-created_at"},
+	})
+	if !ok {
+		return
+	}
+
+	changedAt, err := app.models.Podcast.CatalogChangedAt()
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+	lastModified := changedAt.UTC().Truncate(time.Second)
+
+	if app.contextGetUser(ctx).IsAnonymous() {
+		ctx.Header("Cache-Control", "public, no-cache")
+	} else {
+		ctx.Header("Cache-Control", "private, no-cache")
+	}
+	ctx.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+
+	since, err := http.ParseTime(ctx.GetHeader("If-Modified-Since"))
+	if err == nil && !lastModified.After(since) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	podcasts, _, err := app.models.Podcast.GetAll(podcastFilters, filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	base := baseURL(ctx)
+
+	ch := &feed.Channel{
+		Title:       "IPDB - recently added and updated podcasts",
+		Link:        base + "/v1/podcasts",
+		SelfLink:    base + ctx.Request.URL.RequestURI(),
+		Description: "Podcasts recently added to or updated in the Internet Podcasts Database",
+		Updated:     lastModified,
+	}
+
+	for _, p := range *podcasts {
+		ch.Entries = append(ch.Entries, feed.Entry{
+			Id:         fmt.Sprintf("%s/v1/podcasts/%d", base, p.Id),
+			Title:      p.Title,
+			Link:       p.Url,
+			Summary:    fmt.Sprintf("%s, hosted by %s on %s (%d)", p.Program, p.Host, p.Platform, p.Year),
+			Author:     p.Host,
+			Categories: p.Tags,
+			Published:  p.CreatedAt,
+			Updated:    p.UpdatedAt,
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := write(&buf, ch); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.Data(http.StatusOK, contentType, buf.Bytes())
+}
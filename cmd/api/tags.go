@@ -0,0 +1,335 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+var tagSortSafelist = []string{
+	"slug",
+	"name",
+	"usage_count",
+	"total_count",
+	"created_at",
+	"-slug",
+	"-name",
+	"-usage_count",
+	"-total_count",
+	"-created_at",
+}
+
+type tagPath struct {
+	Slug string `uri:"slug" binding:"required"`
+}
+
+func (app *application) listTagsHandler(ctx *gin.Context) {
+	var input struct {
+		data.TagFilters
+		data.Filters
+	}
+
+	input.Filters = *data.DefaultsFilters(data.Filters{Sort: "-total_count", SortSafelist: tagSortSafelist})
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	if data.ValidateFilters(v, input.Filters); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	tags, metadata, err := app.models.Tag.GetAll(input.TagFilters, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": tags, "metadata": metadata})
+}
+
+func (app *application) createTagHandler(ctx *gin.Context) {
+	var input struct {
+		Name     string   `json:"name"`
+		Slug     string   `json:"slug"`
+		Parent   string   `json:"parent"`
+		Synonyms []string `json:"synonyms"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	tag := &data.Tag{
+		Name:     strings.TrimSpace(input.Name),
+		Slug:     firstNonEmpty(input.Slug, data.Slugify(input.Name)),
+		Synonyms: input.Synonyms,
+	}
+
+	if tag.Synonyms == nil {
+		tag.Synonyms = []string{}
+	}
+
+	v := validator.New()
+
+	if err := app.resolveTagParent(v, tag, input.Parent); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	if data.ValidateTag(v, tag); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err := app.models.Tag.Insert(tag)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateTag):
+			v.AddError("slug", "a tag or synonym with this slug already exists")
+			app.failedValidationResponse(ctx, v.Errors)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	tag, err = app.models.Tag.FindBySlug(tag.Slug)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.Header("Location", fmt.Sprintf("/v1/tags/%s", tag.Slug))
+
+	ctx.JSON(http.StatusCreated, gin.H{"status": http.StatusCreated, "data": tag})
+}
+
+// findTag loads the tag named in the path. A synonym is redirected to its tag.
+// On failure it writes the response and returns nil.
+func (app *application) findTag(ctx *gin.Context) *data.Tag {
+	var path tagPath
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return nil
+	}
+
+	tag, err := app.models.Tag.FindBySlug(path.Slug)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return nil
+	}
+
+	if tag.Slug != path.Slug && ctx.Request.Method == http.MethodGet {
+		location := strings.Replace(ctx.Request.URL.Path, "/tags/"+path.Slug, "/tags/"+tag.Slug, 1)
+		if ctx.Request.URL.RawQuery != "" {
+			location += "?" + ctx.Request.URL.RawQuery
+		}
+		ctx.Redirect(http.StatusMovedPermanently, location)
+		return nil
+	}
+
+	return tag
+}
+
+func (app *application) getTagHandler(ctx *gin.Context) {
+	tag := app.findTag(ctx)
+	if tag == nil {
+		return
+	}
+
+	ancestors, err := app.models.Tag.GetAncestors(tag.Id)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	children, err := app.models.Tag.GetChildren(tag.Id)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": gin.H{
+		"tag":       tag,
+		"ancestors": ancestors,
+		"children":  children,
+	}})
+}
+
+// listTagPodcastsHandler lists the podcasts tagged with the tag or any tag
+// below it, accepting the same query parameters as the podcast list.
+func (app *application) listTagPodcastsHandler(ctx *gin.Context) {
+	tag := app.findTag(ctx)
+	if tag == nil {
+		return
+	}
+
+	podcastFilters, filters, ok := app.readPodcastQuery(ctx, data.Filters{SortSafelist: podcastSortSafelist})
+	if !ok {
+		return
+	}
+
+	podcastFilters.Tags = append(podcastFilters.Tags, tag.Slug)
+
+	podcasts, metadata, err := app.models.Podcast.GetAll(podcastFilters, filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": podcasts, "metadata": metadata})
+}
+
+func (app *application) updateTagHandler(ctx *gin.Context) {
+	var input struct {
+		Name     *string  `json:"name"`
+		Slug     *string  `json:"slug"`
+		Parent   *string  `json:"parent"`
+		Synonyms []string `json:"synonyms"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	tag := app.findTag(ctx)
+	if tag == nil {
+		return
+	}
+
+	oldSlug := tag.Slug
+
+	if input.Name != nil {
+		tag.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Synonyms != nil {
+		tag.Synonyms = input.Synonyms
+	}
+	if input.Slug != nil {
+		tag.Slug = *input.Slug
+
+		// Renaming a tag to one of its synonyms promotes the synonym.
+		synonyms := []string{}
+		for _, synonym := range tag.Synonyms {
+			if data.Slugify(synonym) != tag.Slug {
+				synonyms = append(synonyms, synonym)
+			}
+		}
+		tag.Synonyms = synonyms
+	}
+
+	v := validator.New()
+
+	if input.Parent != nil {
+		if err := app.resolveTagParent(v, tag, *input.Parent); err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+	}
+
+	if data.ValidateTag(v, tag); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	err := app.models.Tag.Update(tag, oldSlug)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateTag):
+			v.AddError("slug", "a tag or synonym with this slug already exists")
+			app.failedValidationResponse(ctx, v.Errors)
+		case errors.Is(err, data.ErrTagCycle):
+			v.AddError("parent", "must not be the tag itself or one of its descendants")
+			app.failedValidationResponse(ctx, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	tag, err = app.models.Tag.FindBySlug(tag.Slug)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": tag})
+}
+
+func (app *application) deleteTagHandler(ctx *gin.Context) {
+	tag := app.findTag(ctx)
+	if tag == nil {
+		return
+	}
+
+	err := app.models.Tag.DeleteById(tag.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		case errors.Is(err, data.ErrTagInUse):
+			app.conflictResponse(ctx, err)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": "tag successfully deleted"})
+}
+
+// resolveTagParent sets the tag's parent to the canonical slug of parent, or
+// clears it when parent is empty.
+func (app *application) resolveTagParent(v *validator.Validator, tag *data.Tag, parent string) error {
+	if strings.TrimSpace(parent) == "" {
+		tag.Parent = ""
+		return nil
+	}
+
+	p, err := app.models.Tag.FindBySlug(parent)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			v.AddError("parent", "must be an existing tag")
+			return nil
+		default:
+			return err
+		}
+	}
+
+	tag.Parent = p.Slug
+	return nil
+}
+
+// normalizeTags rewrites the podcast's tags to their canonical slugs, creating
+// tags seen for the first time.
+func (app *application) normalizeTags(podcast *data.Podcast) error {
+	tags, err := app.models.Tag.Normalize(podcast.Tags)
+	if err != nil {
+		return err
+	}
+
+	podcast.Tags = tags
+	return nil
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/language"
+	"github.com/terajari/ipdb/internal/transcript"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+const maxTranscriptSize = 5 << 20
+
+var transcriptContentTypes = map[string]string{
+	transcript.FormatVTT:  "text/vtt; charset=utf-8",
+	transcript.FormatSRT:  "application/x-subrip; charset=utf-8",
+	transcript.FormatText: "text/plain; charset=utf-8",
+}
+
+// readTranscriptEpisode reads the episode id from the path and checks the
+// episode exists. On failure it writes the response and returns 0.
+func (app *application) readTranscriptEpisode(ctx *gin.Context) int64 {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return 0
+	}
+
+	if _, err := app.models.Episode.FindById(path.Id); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return 0
+	}
+
+	return path.Id
+}
+
+// putTranscriptHandler attaches a transcript to an episode, replacing any it
+// had. The transcript is sent as the request body or as the multipart field
+// "file", in WebVTT, SRT or plain text; the format is detected unless given.
+func (app *application) putTranscriptHandler(ctx *gin.Context) {
+	var input struct {
+		Format   string `form:"format"`
+		Language string `form:"language"`
+	}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	episodeId := app.readTranscriptEpisode(ctx)
+	if episodeId == 0 {
+		return
+	}
+
+	tooLarge := fmt.Sprintf("must not be larger than %d bytes", maxTranscriptSize)
+
+	// Leave room for the multipart headers around the file.
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxTranscriptSize+64<<10)
+
+	v := validator.New()
+
+	var body io.Reader = ctx.Request.Body
+
+	if strings.HasPrefix(ctx.ContentType(), "multipart/") {
+		fh, err := ctx.FormFile("file")
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+			switch {
+			case errors.As(err, &maxBytesError):
+				v.AddError("transcript", tooLarge)
+				app.failedValidationResponse(ctx, v.Errors)
+			case errors.Is(err, http.ErrMissingFile):
+				v.AddError("file", "must be provided")
+				app.failedValidationResponse(ctx, v.Errors)
+			default:
+				app.badRequestResponse(ctx, err)
+			}
+			return
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(body, maxTranscriptSize+1))
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesError):
+			v.AddError("transcript", tooLarge)
+			app.failedValidationResponse(ctx, v.Errors)
+		default:
+			app.badRequestResponse(ctx, err)
+		}
+		return
+	}
+
+	v.Check(len(raw) <= maxTranscriptSize, "transcript", tooLarge)
+	v.Check(utf8.Valid(raw), "transcript", "must be UTF-8 text")
+	v.Check(validator.PermitedValues(input.Format, "", transcript.FormatText, transcript.FormatVTT, transcript.FormatSRT), "format", "must be one of text, vtt or srt")
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	if input.Format == "" {
+		input.Format = transcript.DetectFormat(raw)
+	}
+
+	segments, err := transcript.Parse(input.Format, raw)
+	if err != nil {
+		var syntaxError *transcript.SyntaxError
+		switch {
+		case errors.As(err, &syntaxError):
+			v.AddError("transcript", fmt.Sprintf("line %d: %s", syntaxError.Line, syntaxError.Msg))
+		case errors.Is(err, transcript.ErrEmpty):
+			v.AddError("transcript", "must contain some text")
+		default:
+			app.serverErrorResponse(ctx, err)
+			return
+		}
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	t := &data.Transcript{
+		EpisodeId: episodeId,
+		Format:    input.Format,
+		Language:  strings.TrimSpace(input.Language),
+		Segments:  make([]data.TranscriptSegment, len(segments)),
+	}
+
+	for i, s := range segments {
+		t.Segments[i] = data.TranscriptSegment{Position: i, Speaker: s.Speaker, Text: s.Text}
+		if input.Format != transcript.FormatText {
+			start, end := s.Start.Milliseconds(), s.End.Milliseconds()
+			t.Segments[i].StartMs, t.Segments[i].EndMs = &start, &end
+		}
+	}
+
+	if data.ValidateTranscript(v, t); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	t.Language, _ = language.Normalize(t.Language)
+
+	if err := app.models.Transcript.Save(t); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": t})
+}
+
+// getTranscriptHandler returns an episode's transcript as JSON, or as a file
+// in WebVTT, SRT or plain text when asked with format.
+func (app *application) getTranscriptHandler(ctx *gin.Context) {
+	var input struct {
+		Format string `form:"format"`
+	}
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	if v.Check(validator.PermitedValues(input.Format, "", "json", transcript.FormatText, transcript.FormatVTT, transcript.FormatSRT), "format", "must be one of json, text, vtt or srt"); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	episodeId := app.readTranscriptEpisode(ctx)
+	if episodeId == 0 {
+		return
+	}
+
+	t, err := app.models.Transcript.FindByEpisode(episodeId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	if input.Format == "" || input.Format == "json" {
+		ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": t})
+		return
+	}
+
+	segments := make([]transcript.Segment, len(t.Segments))
+	for i, s := range t.Segments {
+		segments[i] = transcript.Segment{Speaker: s.Speaker, Text: s.Text}
+		if s.StartMs != nil && s.EndMs != nil {
+			segments[i].Start = time.Duration(*s.StartMs) * time.Millisecond
+			segments[i].End = time.Duration(*s.EndMs) * time.Millisecond
+		}
+	}
+
+	out, err := transcript.Write(input.Format, segments)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="episode-%d.%s"`, episodeId, strings.Replace(input.Format, "text", "txt", 1)))
+	ctx.Data(http.StatusOK, transcriptContentTypes[input.Format], out)
+}
+
+func (app *application) deleteTranscriptHandler(ctx *gin.Context) {
+	episodeId := app.readTranscriptEpisode(ctx)
+	if episodeId == 0 {
+		return
+	}
+
+	if err := app.models.Transcript.Delete(episodeId); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": "transcript successfully deleted"})
+}
+
+// searchTranscriptsHandler finds what was said in episodes, returning the
+// matching segments with the episode and the time they were said.
+func (app *application) searchTranscriptsHandler(ctx *gin.Context) {
+	var input struct {
+		data.TranscriptSearch
+		data.Filters
+	}
+
+	input.Filters = *data.DefaultsFilters(data.Filters{Sort: "-rank", SortSafelist: []string{"-rank"}})
+
+	if err := ctx.ShouldBindQuery(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	input.Query = strings.TrimSpace(input.Query)
+
+	v := validator.New()
+
+	data.ValidateFilters(v, input.Filters)
+	data.ValidateTranscriptSearch(v, input.TranscriptSearch)
+
+	if input.Language != "" {
+		var ok bool
+		input.Language, ok = language.Normalize(input.Language)
+		v.Check(ok, "language", "must be an ISO 639 language code, BCP 47 tag or language name")
+	}
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	input.Filters.SafeMode = app.safeMode(ctx)
+
+	hits, metadata, err := app.models.Transcript.Search(input.TranscriptSearch, input.Filters)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": hits, "metadata": metadata})
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/terajari/ipdb/internal/data"
+	"github.com/terajari/ipdb/internal/language"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+// localizePodcasts shows podcasts in the languages the client asked for with
+// Accept-Language, where they have been translated.
+func (app *application) localizePodcasts(ctx *gin.Context, podcasts ...*data.Podcast) error {
+	ctx.Writer.Header().Add("Vary", "Accept-Language")
+
+	languages := language.ParseAcceptLanguage(ctx.GetHeader("Accept-Language"))
+
+	return app.models.Translation.Localize(podcasts, languages)
+}
+
+// readTranslationPath reads the podcast and the language of a translation
+// from the path. On failure it writes the response and returns nil.
+func (app *application) readTranslationPath(ctx *gin.Context) (*data.Podcast, string) {
+	podcastId := app.readPodcastId(ctx)
+	if podcastId == 0 {
+		return nil, ""
+	}
+
+	code, ok := language.Normalize(ctx.Param("language"))
+	if !ok {
+		v := validator.New()
+		v.AddError("language", "must be an ISO 639 language code, BCP 47 tag or language name")
+		app.failedValidationResponse(ctx, v.Errors)
+		return nil, ""
+	}
+
+	podcast, err := app.models.Podcast.FindById(podcastId)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return nil, ""
+	}
+
+	return podcast, code
+}
+
+func (app *application) listTranslationsHandler(ctx *gin.Context) {
+	podcastId := app.readExistingPodcast(ctx)
+	if podcastId == 0 {
+		return
+	}
+
+	translations, err := app.models.Translation.GetAll(podcastId)
+	if err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": translations})
+}
+
+// putTranslationHandler sets a podcast's title and description in another
+// language, replacing any translation it had in that language.
+func (app *application) putTranslationHandler(ctx *gin.Context) {
+	var input struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	podcast, code := app.readTranslationPath(ctx)
+	if podcast == nil {
+		return
+	}
+
+	t := &data.Translation{
+		PodcastId:   podcast.Id,
+		Language:    code,
+		Title:       strings.TrimSpace(input.Title),
+		Description: strings.TrimSpace(input.Description),
+	}
+
+	v := validator.New()
+
+	v.Check(!slices.Contains(podcast.Languages, code), "language", "must not be one of the podcast's own languages")
+
+	if data.ValidateTranslation(v, t); !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	if err := app.models.Translation.Upsert(t); err != nil {
+		app.serverErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": t})
+}
+
+func (app *application) deleteTranslationHandler(ctx *gin.Context) {
+	podcast, code := app.readTranslationPath(ctx)
+	if podcast == nil {
+		return
+	}
+
+	if err := app.models.Translation.Delete(podcast.Id, code); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "message": "translation successfully deleted"})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"database/sql"
 	"errors"
 	"net/http"
 	"time"
@@ -121,3 +122,70 @@ func (app *application) activateUserHandler(ctx *gin.Context) {
 		"user": user,
 	})
 }
+
+// updateUserRoleHandler lets an editor make another user an editor, or take
+// the role away again.
+func (app *application) updateUserRoleHandler(ctx *gin.Context) {
+	var path struct {
+		Id int64 `uri:"id" binding:"required,gt=0"`
+	}
+
+	if err := ctx.ShouldBindUri(&path); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	var input struct {
+		Role string `json:"role"`
+	}
+
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		app.badRequestResponse(ctx, err)
+		return
+	}
+
+	v := validator.New()
+
+	v.Check(validator.PermitedValues(input.Role, data.RoleContributor, data.RoleEditor), "role", "must be contributor or editor")
+	v.Check(path.Id != app.contextGetUser(ctx).Id, "role", "must not be changed for yourself")
+
+	if !v.Valid() {
+		app.failedValidationResponse(ctx, v.Errors)
+		return
+	}
+
+	if err := app.models.User.SetRole(path.Id, input.Role); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			app.notFoundResponse(ctx)
+		default:
+			app.serverErrorResponse(ctx, err)
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"status": http.StatusOK, "data": gin.H{"id": path.Id, "role": input.Role}})
+}
+
+// grantEditors gives the editor role to the users with the given emails, so
+// that a fresh deployment has someone to edit the catalog and promote others.
+func (app *application) grantEditors(emails []string) {
+	for _, email := range emails {
+		user, err := app.models.User.GetByEmail(email)
+		if err != nil {
+			app.logger.Error(err.Error(), "email", email)
+			continue
+		}
+
+		if user.IsEditor() {
+			continue
+		}
+
+		if err := app.models.User.SetRole(user.Id, data.RoleEditor); err != nil {
+			app.logger.Error(err.Error(), "email", email)
+			continue
+		}
+
+		app.logger.Info("granted editor role", "email", email)
+	}
+}
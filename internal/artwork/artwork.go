@@ -0,0 +1,135 @@
+package artwork
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// Sizes are the widths, in pixels, of the thumbnails made from every upload.
+var Sizes = []int{600, 300, 100}
+
+var (
+	ErrUnsupportedType = errors.New("artwork: not a JPEG or PNG image")
+	ErrTooSmall        = errors.New("artwork: image is smaller than allowed")
+	ErrTooLarge        = errors.New("artwork: image is larger than allowed")
+)
+
+// Limits bound the images accepted as artwork.
+type Limits struct {
+	MinDimension int
+	MaxDimension int
+}
+
+// File is an encoded image ready to be stored.
+type File struct {
+	Name        string
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+// Result is a processed upload: the original file and its thumbnails, named
+// after the hash of the original so that identical uploads share files.
+type Result struct {
+	Hash     string
+	Original File
+	Sizes    []File
+}
+
+// Process checks data is a JPEG or PNG within limits and makes thumbnails of
+// it. The type is sniffed from the content rather than trusted from the
+// client, and the dimensions are read from the header before the image is
+// decoded, so a small file cannot claim a huge bitmap.
+func Process(data []byte, limits Limits) (*Result, error) {
+	mtype := mimetype.Detect(data)
+
+	var ext string
+	switch {
+	case mtype.Is("image/jpeg"):
+		ext = ".jpg"
+	case mtype.Is("image/png"):
+		ext = ".png"
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	if cfg.Width < limits.MinDimension || cfg.Height < limits.MinDimension {
+		return nil, ErrTooSmall
+	}
+
+	if cfg.Width > limits.MaxDimension || cfg.Height > limits.MaxDimension {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	sum := sha256.Sum256(data)
+
+	result := &Result{
+		Hash: hex.EncodeToString(sum[:]),
+		Original: File{
+			Name:        "original" + ext,
+			Width:       cfg.Width,
+			Height:      cfg.Height,
+			ContentType: mtype.String(),
+			Data:        data,
+		},
+	}
+
+	for _, size := range Sizes {
+		if size > cfg.Width {
+			continue
+		}
+
+		thumb := Resize(img, size, cfg.Height*size/cfg.Width)
+
+		file, err := encode(thumb, fmt.Sprint(size), ext)
+		if err != nil {
+			return nil, err
+		}
+		result.Sizes = append(result.Sizes, file)
+	}
+
+	return result, nil
+}
+
+// encode writes thumbnails as JPEG, except for PNG originals which may rely on
+// transparency.
+func encode(img image.Image, name, ext string) (File, error) {
+	var buf bytes.Buffer
+
+	file := File{Name: name + ext, Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+
+	switch ext {
+	case ".png":
+		file.ContentType = "image/png"
+		if err := png.Encode(&buf, img); err != nil {
+			return File{}, err
+		}
+	default:
+		file.ContentType = "image/jpeg"
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return File{}, err
+		}
+	}
+
+	file.Data = buf.Bytes()
+	return file, nil
+}
@@ -0,0 +1,184 @@
+package artwork
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// fixture encodes a w by h gradient in the given format.
+func fixture(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	limits := Limits{MinDimension: 200, MaxDimension: 1000}
+
+	type size struct {
+		name string
+		w, h int
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		contentType string
+		original    string
+		sizes       []size
+		err         error
+	}{
+		{
+			name:        "png",
+			data:        fixture(t, "png", 800, 400),
+			contentType: "image/png",
+			original:    "original.png",
+			sizes:       []size{{"600.png", 600, 300}, {"300.png", 300, 150}, {"100.png", 100, 50}},
+		},
+		{
+			name:        "jpeg narrower than the largest thumbnail",
+			data:        fixture(t, "jpeg", 300, 300),
+			contentType: "image/jpeg",
+			original:    "original.jpg",
+			sizes:       []size{{"300.jpg", 300, 300}, {"100.jpg", 100, 100}},
+		},
+		{
+			name:        "at the limits",
+			data:        fixture(t, "png", 1000, 200),
+			contentType: "image/png",
+			original:    "original.png",
+			sizes:       []size{{"600.png", 600, 120}, {"300.png", 300, 60}, {"100.png", 100, 20}},
+		},
+		{name: "too small", data: fixture(t, "png", 199, 400), err: ErrTooSmall},
+		{name: "too large", data: fixture(t, "jpeg", 400, 1001), err: ErrTooLarge},
+		{name: "gif", data: fixture(t, "gif", 300, 300), err: ErrUnsupportedType},
+		{name: "text", data: []byte("not an image"), err: ErrUnsupportedType},
+		{name: "truncated png", data: fixture(t, "png", 300, 300)[:100], err: ErrUnsupportedType},
+		{name: "empty", data: nil, err: ErrUnsupportedType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Process(tt.data, limits)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			sum := sha256.Sum256(tt.data)
+			if res.Hash != hex.EncodeToString(sum[:]) {
+				t.Errorf("Hash = %s, want the SHA-256 of the upload", res.Hash)
+			}
+			if res.Original.Name != tt.original || res.Original.ContentType != tt.contentType || !bytes.Equal(res.Original.Data, tt.data) {
+				t.Errorf("Original = %s, %s, want %s, %s and the upload unchanged", res.Original.Name, res.Original.ContentType, tt.original, tt.contentType)
+			}
+
+			if len(res.Sizes) != len(tt.sizes) {
+				t.Fatalf("got %d thumbnails, want %d", len(res.Sizes), len(tt.sizes))
+			}
+			for i, want := range tt.sizes {
+				got := res.Sizes[i]
+				if got.Name != want.name || got.Width != want.w || got.Height != want.h || got.ContentType != tt.contentType {
+					t.Errorf("thumbnail %d = %s %dx%d %s, want %s %dx%d %s", i, got.Name, got.Width, got.Height, got.ContentType, want.name, want.w, want.h, tt.contentType)
+				}
+
+				cfg, format, err := image.DecodeConfig(bytes.NewReader(got.Data))
+				if err != nil {
+					t.Fatalf("thumbnail %d does not decode: %v", i, err)
+				}
+				if cfg.Width != want.w || cfg.Height != want.h || "image/"+format != tt.contentType {
+					t.Errorf("thumbnail %d decodes as %s %dx%d", i, format, cfg.Width, cfg.Height)
+				}
+			}
+		})
+	}
+}
+
+func TestResize(t *testing.T) {
+	t.Run("checkerboard averages to grey", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				c := color.RGBA{A: 255}
+				if (x+y)%2 == 0 {
+					c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+				}
+				src.SetRGBA(x, y, c)
+			}
+		}
+
+		dst := Resize(src, 2, 2)
+		if dst.Bounds() != image.Rect(0, 0, 2, 2) {
+			t.Fatalf("bounds = %v", dst.Bounds())
+		}
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 2; x++ {
+				if got, want := dst.RGBAAt(x, y), (color.RGBA{R: 128, G: 128, B: 128, A: 255}); got != want {
+					t.Errorf("pixel %d,%d = %v, want %v", x, y, got, want)
+				}
+			}
+		}
+	})
+
+	t.Run("transparency does not darken", func(t *testing.T) {
+		src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+		src.SetNRGBA(0, 0, color.NRGBA{R: 255, A: 255})
+		src.SetNRGBA(1, 0, color.NRGBA{G: 255, A: 0})
+
+		got := color.NRGBAModel.Convert(Resize(src, 1, 1).At(0, 0)).(color.NRGBA)
+		if want := (color.NRGBA{R: 255, A: 128}); got != want {
+			t.Errorf("pixel = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("offset bounds and fractional scale", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(10, 10, 13, 11))
+		for x := 10; x < 13; x++ {
+			src.SetRGBA(x, 10, color.RGBA{R: 90, G: 90, B: 90, A: 255})
+		}
+
+		dst := Resize(src, 2, 1)
+		for x := 0; x < 2; x++ {
+			if got, want := dst.RGBAAt(x, 0), (color.RGBA{R: 90, G: 90, B: 90, A: 255}); got != want {
+				t.Errorf("pixel %d = %v, want %v", x, got, want)
+			}
+		}
+	})
+
+	t.Run("at least one pixel", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(0, 0, 100, 1))
+		if got := Resize(src, 10, 0).Bounds(); got != image.Rect(0, 0, 10, 1) {
+			t.Errorf("bounds = %v, want 10x1", got)
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package artwork
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+type contribution struct {
+	index  int
+	weight float64
+}
+
+// weights returns, for every pixel of a row of dst pixels, the src pixels it
+// covers and how much of it each one covers.
+func weights(src, dst int) [][]contribution {
+	scale := float64(src) / float64(dst)
+	out := make([][]contribution, dst)
+
+	for i := range out {
+		start, end := float64(i)*scale, float64(i+1)*scale
+		for j := int(math.Floor(start)); j < int(math.Ceil(end)) && j < src; j++ {
+			overlap := math.Min(end, float64(j+1)) - math.Max(start, float64(j))
+			if overlap > 0 {
+				out[i] = append(out[i], contribution{index: j, weight: overlap / scale})
+			}
+		}
+	}
+
+	return out
+}
+
+// Resize scales img to w by h pixels by averaging the area of the source each
+// pixel covers, which keeps detail when shrinking without aliasing. Colours
+// are averaged premultiplied so transparent pixels do not darken edges.
+func Resize(img image.Image, w, h int) *image.RGBA {
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	sw, sh := b.Dx(), b.Dy()
+	xw, yw := weights(sw, w), weights(sh, h)
+
+	// Scale each row horizontally, then the result vertically.
+	tmp := make([]float64, w*sh*4)
+	for y := 0; y < sh; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x, cs := range xw {
+			var acc [4]float64
+			for _, c := range cs {
+				p := row[c.index*4:]
+				for k := range acc {
+					acc[k] += float64(p[k]) * c.weight
+				}
+			}
+			copy(tmp[(y*w+x)*4:], acc[:])
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y, cs := range yw {
+		for x := 0; x < w; x++ {
+			var acc [4]float64
+			for _, c := range cs {
+				p := tmp[(c.index*w+x)*4:]
+				for k := range acc {
+					acc[k] += p[k] * c.weight
+				}
+			}
+			px := dst.Pix[y*dst.Stride+x*4:]
+			for k, v := range acc {
+				px[k] = uint8(math.Min(255, math.Max(0, math.Round(v))))
+			}
+		}
+	}
+
+	return dst
+}
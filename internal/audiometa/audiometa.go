@@ -0,0 +1,85 @@
+package audiometa
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	FormatMP3 = "mp3"
+	FormatMP4 = "mp4"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("audiometa: not an MP3 or MP4 audio file")
+	ErrMalformed         = errors.New("audiometa: malformed file")
+)
+
+// maxTagSize bounds how much of a file is read as tags, so a corrupt size
+// field cannot make us allocate gigabytes.
+const maxTagSize = 32 << 20
+
+// Chapter is a titled part of an episode.
+type Chapter struct {
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// Picture is an image embedded in the file.
+type Picture struct {
+	MIMEType string
+	Data     []byte
+}
+
+// Metadata is what could be read from an audio file. Fields the file does not
+// carry are left zero.
+type Metadata struct {
+	Format     string
+	Title      string
+	Artist     string
+	Album      string
+	Duration   time.Duration
+	Bitrate    int // kbit/s, averaged over the file for variable bitrates
+	SampleRate int
+	Chapters   []Chapter
+	Artwork    *Picture
+}
+
+// Read extracts metadata from an MP3 (ID3v2 tags and MPEG frame headers) or
+// MP4/M4A (iTunes metadata and Nero chapters) file of the given size.
+func Read(r io.ReaderAt, size int64) (*Metadata, error) {
+	head := make([]byte, 12)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	switch {
+	case len(head) >= 8 && bytes.Equal(head[4:8], []byte("ftyp")):
+		return readMP4(r, size)
+	case bytes.HasPrefix(head, []byte("ID3")), len(head) >= 2 && head[0] == 0xff && head[1]&0xe0 == 0xe0:
+		return readMP3(r, size)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// fixChapters fills in missing ends: a chapter without one runs until the
+// next starts, and the last until the end of the file.
+func fixChapters(chapters []Chapter, duration time.Duration) {
+	for i := range chapters {
+		if chapters[i].End > chapters[i].Start {
+			continue
+		}
+		switch {
+		case i+1 < len(chapters) && chapters[i+1].Start > chapters[i].Start:
+			chapters[i].End = chapters[i+1].Start
+		case duration > chapters[i].Start:
+			chapters[i].End = duration
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package audiometa
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+//go:generate go run testdata/gen.go
+
+func readFixture(t *testing.T, name string) (*Metadata, error) {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Read(bytes.NewReader(b), int64(len(b)))
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		file string
+		want *Metadata
+	}{
+		{
+			file: "chapters-v23.mp3",
+			want: &Metadata{
+				Format:     FormatMP3,
+				Title:      "Episode 12",
+				Artist:     "Rina",
+				Album:      "Ngobrol Santai",
+				Duration:   3257812500 * time.Nanosecond,
+				Bitrate:    128,
+				SampleRate: 44100,
+				Chapters: []Chapter{
+					{Title: "Intro", Start: 0, End: time.Second},
+					{Title: "Middle", Start: time.Second, End: 2 * time.Second},
+					{Title: "Outro", Start: 2 * time.Second, End: 3257812500 * time.Nanosecond},
+				},
+				Artwork: &Picture{MIMEType: "image/png", Data: []byte("\x89PNGFRONT")},
+			},
+		},
+		{
+			file: "vbr-v24.mp3",
+			want: &Metadata{
+				Format:     FormatMP3,
+				Title:      "Épisode",
+				Artist:     "Zoë",
+				Duration:   26122448979 * time.Nanosecond,
+				Bitrate:    1,
+				SampleRate: 44100,
+				Chapters: []Chapter{
+					{Title: "First", Start: 0, End: 30 * time.Second},
+					{Title: "Second", Start: 30 * time.Second, End: 0},
+				},
+			},
+		},
+		{
+			// 100 frames last 2.60625s, which a float64 cannot hold exactly,
+			// so the bitrate must be rounded rather than truncated.
+			file: "cbr.mp3",
+			want: &Metadata{
+				Format:     FormatMP3,
+				Duration:   2606250 * time.Microsecond,
+				Bitrate:    128,
+				SampleRate: 44100,
+			},
+		},
+		{
+			file: "v22.mp3",
+			want: &Metadata{
+				Format:   FormatMP3,
+				Title:    "Old Show",
+				Artist:   "Someone",
+				Duration: 5 * time.Second,
+				Artwork:  &Picture{MIMEType: "image/png", Data: []byte("\x89PNGOLD")},
+			},
+		},
+		{
+			file: "chapters.m4a",
+			want: &Metadata{
+				Format:     FormatMP4,
+				Title:      "M4A Episode",
+				Artist:     "Studio",
+				Album:      "The Show",
+				Duration:   time.Minute,
+				Bitrate:    1,
+				SampleRate: 44100,
+				Chapters: []Chapter{
+					{Title: "Opening", Start: 0, End: 20 * time.Second},
+					{Title: "Main", Start: 20 * time.Second, End: time.Minute},
+				},
+				Artwork: &Picture{MIMEType: "image/png", Data: []byte("\x89PNGCOVER")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got, err := readFixture(t, tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadUnsupported(t *testing.T) {
+	if _, err := readFixture(t, "not-audio.txt"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("err = %v, want %v", err, ErrUnsupportedFormat)
+	}
+	if _, err := Read(bytes.NewReader(nil), 0); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("empty file err = %v, want %v", err, ErrUnsupportedFormat)
+	}
+}
+
+func TestReadTruncated(t *testing.T) {
+	for _, name := range []string{"chapters-v23.mp3", "chapters.m4a"} {
+		b, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Cut inside the tag or the moov box, as an interrupted upload would.
+		b = b[:200]
+		if _, err := Read(bytes.NewReader(b), int64(len(b))); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%s cut short: err = %v, want %v", name, err, ErrMalformed)
+		}
+	}
+}
+
+func TestFixChapters(t *testing.T) {
+	tests := []struct {
+		name     string
+		chapters []Chapter
+		duration time.Duration
+		want     []Chapter
+	}{
+		{
+			name:     "ends from next start and duration",
+			chapters: []Chapter{{Start: 0}, {Start: 10 * time.Second}},
+			duration: time.Minute,
+			want:     []Chapter{{Start: 0, End: 10 * time.Second}, {Start: 10 * time.Second, End: time.Minute}},
+		},
+		{
+			name:     "ends kept",
+			chapters: []Chapter{{Start: 0, End: 5 * time.Second}, {Start: 10 * time.Second, End: 20 * time.Second}},
+			duration: time.Minute,
+			want:     []Chapter{{Start: 0, End: 5 * time.Second}, {Start: 10 * time.Second, End: 20 * time.Second}},
+		},
+		{
+			name:     "unknown duration",
+			chapters: []Chapter{{Start: 10 * time.Second}},
+			want:     []Chapter{{Start: 10 * time.Second}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixChapters(tt.chapters, tt.duration)
+			if !reflect.DeepEqual(tt.chapters, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", tt.chapters, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFrameHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   mpegFrame
+		ok     bool
+	}{
+		{
+			name:   "mpeg 1 layer III 128k",
+			header: []byte{0xff, 0xfb, 0x90, 0x00},
+			want:   mpegFrame{version: 0, layer: 3, bitrate: 128, sampleRate: 44100, samples: 1152, length: 417},
+			ok:     true,
+		},
+		{
+			name:   "padded mono",
+			header: []byte{0xff, 0xfb, 0x92, 0xc0},
+			want:   mpegFrame{version: 0, layer: 3, bitrate: 128, sampleRate: 44100, samples: 1152, length: 418, mono: true},
+			ok:     true,
+		},
+		{
+			name:   "mpeg 2 layer III 64k",
+			header: []byte{0xff, 0xf3, 0x80, 0x00},
+			want:   mpegFrame{version: 1, layer: 3, bitrate: 64, sampleRate: 22050, samples: 576, length: 208},
+			ok:     true,
+		},
+		{name: "no sync", header: []byte{0xff, 0x0b, 0x90, 0x00}},
+		{name: "reserved version", header: []byte{0xff, 0xeb, 0x90, 0x00}},
+		{name: "free bitrate", header: []byte{0xff, 0xfb, 0x00, 0x00}},
+		{name: "bad sample rate", header: []byte{0xff, 0xfb, 0x9c, 0x00}},
+		{name: "short", header: []byte{0xff, 0xfb}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseFrameHeader(tt.header)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("got %+v, %v; want %+v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		enc  byte
+		in   []byte
+		want string
+	}{
+		{0, []byte("caf\xe9\x00"), "café"},
+		{1, []byte{0xff, 0xfe, 'h', 0, 'i', 0}, "hi"},
+		{1, []byte{0xfe, 0xff, 0, 'h', 0, 'i'}, "hi"},
+		{2, []byte{0, 'h', 0, 'i', 0, 0}, "hi"},
+		{3, []byte("zoë\x00"), "zoë"},
+	}
+
+	for _, tt := range tests {
+		if got := decodeText(tt.enc, tt.in); got != tt.want {
+			t.Errorf("decodeText(%d, %q) = %q, want %q", tt.enc, tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package audiometa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+type id3Frame struct {
+	id   string
+	data []byte
+}
+
+// id3Tag is a parsed ID3v2 tag.
+type id3Tag struct {
+	major  byte
+	frames []id3Frame
+}
+
+// v2.2 used three letter frame ids; these are the ones we read.
+var id3v22Frames = map[string]string{
+	"TT2": "TIT2",
+	"TP1": "TPE1",
+	"TAL": "TALB",
+	"TLE": "TLEN",
+	"PIC": "APIC",
+}
+
+func syncsafe(b []byte) int64 {
+	return int64(b[0]&0x7f)<<21 | int64(b[1]&0x7f)<<14 | int64(b[2]&0x7f)<<7 | int64(b[3]&0x7f)
+}
+
+// deunsync undoes ID3 unsynchronisation, which inserts a zero byte after
+// every 0xff so that tag data never looks like an MPEG frame sync.
+func deunsync(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xff, 0x00}, []byte{0xff})
+}
+
+// readID3 reads the ID3v2 tag at the start of r, if there is one. end is the
+// offset just past the tag, where the audio starts.
+func readID3(r io.ReaderAt) (tag *id3Tag, end int64, err error) {
+	header := make([]byte, 10)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, 0, nil
+	}
+
+	if !bytes.HasPrefix(header, []byte("ID3")) {
+		return nil, 0, nil
+	}
+
+	major, flags := header[3], header[5]
+	size := syncsafe(header[6:10])
+
+	end = 10 + size
+	if major == 4 && flags&0x10 != 0 {
+		end += 10
+	}
+
+	if major < 2 || major > 4 || size > maxTagSize {
+		return nil, end, nil
+	}
+
+	body := make([]byte, size)
+	if _, err := r.ReadAt(body, 10); err != nil {
+		return nil, 0, ErrMalformed
+	}
+
+	if major < 4 && flags&0x80 != 0 {
+		body = deunsync(body)
+	}
+
+	if flags&0x40 != 0 && major > 2 {
+		if len(body) < 4 {
+			return nil, 0, ErrMalformed
+		}
+		skip := syncsafe(body[:4])
+		if major == 3 {
+			skip = int64(binary.BigEndian.Uint32(body[:4])) + 4
+		}
+		if skip > int64(len(body)) {
+			return nil, 0, ErrMalformed
+		}
+		body = body[skip:]
+	}
+
+	return &id3Tag{major: major, frames: parseID3Frames(body, major)}, end, nil
+}
+
+// parseID3Frames splits b into frames, stopping at padding or at the first
+// frame that does not fit. Compressed and encrypted frames are skipped.
+func parseID3Frames(b []byte, major byte) []id3Frame {
+	var frames []id3Frame
+
+	headerSize := 10
+	if major == 2 {
+		headerSize = 6
+	}
+
+	for len(b) >= headerSize && b[0] != 0 {
+		var (
+			id    string
+			size  int64
+			flags uint16
+		)
+
+		switch major {
+		case 2:
+			id = string(b[:3])
+			size = int64(b[3])<<16 | int64(b[4])<<8 | int64(b[5])
+		case 3:
+			id = string(b[:4])
+			size = int64(binary.BigEndian.Uint32(b[4:8]))
+			flags = binary.BigEndian.Uint16(b[8:10])
+		default:
+			id = string(b[:4])
+			size = syncsafe(b[4:8])
+			flags = binary.BigEndian.Uint16(b[8:10])
+		}
+
+		if size > int64(len(b)-headerSize) {
+			break
+		}
+
+		data := b[headerSize : int64(headerSize)+size]
+		b = b[int64(headerSize)+size:]
+
+		if major == 2 {
+			if id = id3v22Frames[id]; id == "" {
+				continue
+			}
+		}
+
+		switch major {
+		case 3:
+			if flags&0x00c0 != 0 {
+				continue
+			}
+			if flags&0x0020 != 0 && len(data) > 0 {
+				data = data[1:]
+			}
+		case 4:
+			if flags&0x000c != 0 {
+				continue
+			}
+			if flags&0x0040 != 0 && len(data) > 0 {
+				data = data[1:]
+			}
+			if flags&0x0001 != 0 && len(data) >= 4 {
+				data = data[4:]
+			}
+			if flags&0x0002 != 0 {
+				data = deunsync(data)
+			}
+		}
+
+		frames = append(frames, id3Frame{id: id, data: data})
+	}
+
+	return frames
+}
+
+// splitString returns the string at the start of b in the given text
+// encoding, up to its terminator, and the bytes after the terminator.
+func splitString(enc byte, b []byte) (string, []byte) {
+	if enc == 1 || enc == 2 {
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] == 0 && b[i+1] == 0 {
+				return decodeText(enc, b[:i]), b[i+2:]
+			}
+		}
+		return decodeText(enc, b), nil
+	}
+
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		return decodeText(enc, b[:i]), b[i+1:]
+	}
+	return decodeText(enc, b), nil
+}
+
+// decodeText decodes ID3 text: 0 is ISO-8859-1, 1 UTF-16 with a byte order
+// mark, 2 UTF-16 big endian and 3 UTF-8.
+func decodeText(enc byte, b []byte) string {
+	switch enc {
+	case 0:
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+		return strings.TrimRight(string(runes), "\x00")
+	case 1, 2:
+		var order binary.ByteOrder = binary.BigEndian
+		if enc == 1 && len(b) >= 2 {
+			switch {
+			case b[0] == 0xff && b[1] == 0xfe:
+				order, b = binary.LittleEndian, b[2:]
+			case b[0] == 0xfe && b[1] == 0xff:
+				b = b[2:]
+			}
+		}
+		units := make([]uint16, len(b)/2)
+		for i := range units {
+			units[i] = order.Uint16(b[2*i:])
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	default:
+		return strings.TrimRight(string(b), "\x00")
+	}
+}
+
+// textFrame returns the first value of a text frame.
+func textFrame(data []byte) string {
+	if len(data) < 1 {
+		return ""
+	}
+	s, _ := splitString(data[0], data[1:])
+	return strings.TrimSpace(s)
+}
+
+// picture reads an APIC frame, or a v2.2 PIC frame, whose image format is a
+// three letter code rather than a MIME type.
+func picture(data []byte, major byte) (p *Picture, front bool) {
+	if len(data) < 2 {
+		return nil, false
+	}
+
+	enc, rest := data[0], data[1:]
+
+	var mimeType string
+	if major == 2 {
+		if len(rest) < 3 {
+			return nil, false
+		}
+		switch strings.ToUpper(string(rest[:3])) {
+		case "PNG":
+			mimeType = "image/png"
+		default:
+			mimeType = "image/jpeg"
+		}
+		rest = rest[3:]
+	} else {
+		mimeType, rest = splitString(0, rest)
+		switch strings.ToLower(mimeType) {
+		case "", "jpg", "jpeg":
+			mimeType = "image/jpeg"
+		case "png":
+			mimeType = "image/png"
+		}
+	}
+
+	if len(rest) < 1 {
+		return nil, false
+	}
+
+	kind := rest[0]
+	_, img := splitString(enc, rest[1:])
+	if len(img) == 0 {
+		return nil, false
+	}
+
+	return &Picture{MIMEType: mimeType, Data: img}, kind == 3
+}
+
+type id3Chapter struct {
+	Chapter
+	id string
+}
+
+// chapter reads a CHAP frame: an element id, start and end times in
+// milliseconds, byte offsets we ignore, and sub-frames holding the title.
+func chapter(data []byte, major byte) (id3Chapter, bool) {
+	id, rest := splitString(0, data)
+	if len(rest) < 16 {
+		return id3Chapter{}, false
+	}
+
+	c := id3Chapter{id: id}
+	c.Start = time.Duration(binary.BigEndian.Uint32(rest[0:4])) * time.Millisecond
+	if end := binary.BigEndian.Uint32(rest[4:8]); end != 0xffffffff {
+		c.End = time.Duration(end) * time.Millisecond
+	}
+
+	for _, f := range parseID3Frames(rest[16:], major) {
+		if f.id == "TIT2" {
+			c.Title = textFrame(f.data)
+		}
+	}
+
+	return c, true
+}
+
+type id3TOC struct {
+	topLevel bool
+	children []string
+}
+
+// toc reads a CTOC frame: an element id, flags, and the ids of its entries.
+func toc(data []byte) (string, id3TOC, bool) {
+	id, rest := splitString(0, data)
+	if len(rest) < 2 {
+		return "", id3TOC{}, false
+	}
+
+	t := id3TOC{topLevel: rest[0]&0x02 != 0}
+	count := int(rest[1])
+	rest = rest[2:]
+
+	for i := 0; i < count && len(rest) > 0; i++ {
+		var child string
+		child, rest = splitString(0, rest)
+		t.children = append(t.children, child)
+	}
+
+	return id, t, true
+}
+
+// apply copies what the tag holds into m. Chapters are ordered as the top
+// level table of contents lists them, or by start time without one.
+func (tag *id3Tag) apply(m *Metadata) {
+	chapters := map[string]id3Chapter{}
+	tocs := map[string]id3TOC{}
+	var order []string
+
+	for _, f := range tag.frames {
+		switch f.id {
+		case "TIT2":
+			m.Title = textFrame(f.data)
+		case "TPE1":
+			m.Artist = textFrame(f.data)
+		case "TALB":
+			m.Album = textFrame(f.data)
+		case "TLEN":
+			if ms, err := strconv.ParseInt(textFrame(f.data), 10, 64); err == nil && m.Duration == 0 {
+				m.Duration = time.Duration(ms) * time.Millisecond
+			}
+		case "APIC":
+			if p, front := picture(f.data, tag.major); p != nil && (m.Artwork == nil || front) {
+				m.Artwork = p
+			}
+		case "CHAP":
+			if c, ok := chapter(f.data, tag.major); ok {
+				chapters[c.id] = c
+				order = append(order, c.id)
+			}
+		case "CTOC":
+			if id, t, ok := toc(f.data); ok {
+				tocs[id] = t
+			}
+		}
+	}
+
+	var listed []string
+	seen := map[string]bool{}
+
+	var walk func(id string)
+	walk = func(id string) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		if _, ok := chapters[id]; ok {
+			listed = append(listed, id)
+			return
+		}
+		for _, child := range tocs[id].children {
+			walk(child)
+		}
+	}
+
+	for id, t := range tocs {
+		if t.topLevel {
+			walk(id)
+			break
+		}
+	}
+
+	if len(listed) == 0 {
+		listed = order
+		sort.SliceStable(listed, func(i, j int) bool { return chapters[listed[i]].Start < chapters[listed[j]].Start })
+	}
+
+	for _, id := range listed {
+		m.Chapters = append(m.Chapters, chapters[id].Chapter)
+	}
+}
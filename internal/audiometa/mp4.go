@@ -0,0 +1,298 @@
+package audiometa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// atom is an MP4 box: its type and where its payload lies in the file.
+type atom struct {
+	typ   string
+	start int64
+	end   int64
+}
+
+// atoms lists the boxes between off and end.
+func atoms(r io.ReaderAt, off, end int64) ([]atom, error) {
+	var out []atom
+
+	header := make([]byte, 16)
+	for off+8 <= end {
+		if _, err := r.ReadAt(header[:8], off); err != nil {
+			return nil, ErrMalformed
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		typ := string(header[4:8])
+		start := off + 8
+
+		switch size {
+		case 0:
+			size = end - off
+		case 1:
+			if _, err := r.ReadAt(header[8:16], off+8); err != nil {
+				return nil, ErrMalformed
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			start += 8
+		}
+
+		if size < start-off || off+size > end {
+			return nil, ErrMalformed
+		}
+
+		out = append(out, atom{typ: typ, start: start, end: off + size})
+		off += size
+	}
+
+	return out, nil
+}
+
+func children(r io.ReaderAt, a atom) []atom {
+	out, _ := atoms(r, a.start, a.end)
+	return out
+}
+
+func find(list []atom, typ string) (atom, bool) {
+	for _, a := range list {
+		if a.typ == typ {
+			return a, true
+		}
+	}
+	return atom{}, false
+}
+
+// payload reads an atom's payload, refusing ones too large to be metadata.
+func payload(r io.ReaderAt, a atom) ([]byte, error) {
+	if a.end-a.start > maxTagSize {
+		return nil, ErrMalformed
+	}
+	b := make([]byte, a.end-a.start)
+	if _, err := r.ReadAt(b, a.start); err != nil {
+		return nil, ErrMalformed
+	}
+	return b, nil
+}
+
+func readMP4(r io.ReaderAt, size int64) (*Metadata, error) {
+	top, err := atoms(r, 0, size)
+	if err != nil {
+		return nil, err
+	}
+
+	moov, ok := find(top, "moov")
+	if !ok {
+		return nil, ErrMalformed
+	}
+
+	m := &Metadata{Format: FormatMP4}
+
+	var mediaBytes int64
+	for _, a := range top {
+		if a.typ == "mdat" {
+			mediaBytes += a.end - a.start
+		}
+	}
+
+	inMoov := children(r, moov)
+
+	if mvhd, ok := find(inMoov, "mvhd"); ok {
+		b, err := payload(r, mvhd)
+		if err != nil {
+			return nil, err
+		}
+		m.Duration = mediaDuration(b)
+	}
+
+	for _, trak := range inMoov {
+		if trak.typ != "trak" {
+			continue
+		}
+		if rate, ok := soundSampleRate(r, trak); ok {
+			m.SampleRate = rate
+			break
+		}
+	}
+
+	if udta, ok := find(inMoov, "udta"); ok {
+		inUdta := children(r, udta)
+
+		if meta, ok := find(inUdta, "meta"); ok {
+			if err := readIlst(r, meta, m); err != nil {
+				return nil, err
+			}
+		}
+
+		if chpl, ok := find(inUdta, "chpl"); ok {
+			b, err := payload(r, chpl)
+			if err != nil {
+				return nil, err
+			}
+			m.Chapters = neroChapters(b)
+		}
+	}
+
+	if m.Duration > 0 && mediaBytes > 0 {
+		m.Bitrate = int(math.Round(float64(mediaBytes*8) / m.Duration.Seconds() / 1000))
+	}
+
+	fixChapters(m.Chapters, m.Duration)
+
+	return m, nil
+}
+
+// mediaDuration reads the duration from an mvhd or mdhd payload, whose field
+// widths depend on its version.
+func mediaDuration(b []byte) time.Duration {
+	scale, length := timescale(b)
+	if scale == 0 {
+		return 0
+	}
+	return seconds(float64(length) / float64(scale))
+}
+
+func timescale(b []byte) (scale uint32, length uint64) {
+	if len(b) < 1 {
+		return 0, 0
+	}
+	switch b[0] {
+	case 1:
+		if len(b) < 32 {
+			return 0, 0
+		}
+		return binary.BigEndian.Uint32(b[20:24]), binary.BigEndian.Uint64(b[24:32])
+	default:
+		if len(b) < 20 {
+			return 0, 0
+		}
+		return binary.BigEndian.Uint32(b[12:16]), uint64(binary.BigEndian.Uint32(b[16:20]))
+	}
+}
+
+// soundSampleRate returns the timescale of an audio track's media header,
+// which encoders set to the sample rate.
+func soundSampleRate(r io.ReaderAt, trak atom) (int, bool) {
+	mdia, ok := find(children(r, trak), "mdia")
+	if !ok {
+		return 0, false
+	}
+
+	inMdia := children(r, mdia)
+
+	hdlr, ok := find(inMdia, "hdlr")
+	if !ok {
+		return 0, false
+	}
+	h, err := payload(r, hdlr)
+	if err != nil || len(h) < 12 || string(h[8:12]) != "soun" {
+		return 0, false
+	}
+
+	mdhd, ok := find(inMdia, "mdhd")
+	if !ok {
+		return 0, false
+	}
+	b, err := payload(r, mdhd)
+	if err != nil {
+		return 0, false
+	}
+
+	scale, _ := timescale(b)
+	return int(scale), scale > 0
+}
+
+// readIlst reads iTunes style metadata: items under meta/ilst, each holding
+// a data atom with a type, a locale and the value.
+func readIlst(r io.ReaderAt, meta atom, m *Metadata) error {
+	// meta is a full box in iTunes files, with four bytes of version and flags
+	// before its children, but not in QuickTime ones.
+	peek := make([]byte, 8)
+	if _, err := r.ReadAt(peek, meta.start); err == nil && string(peek[4:8]) != "hdlr" {
+		meta.start += 4
+	}
+
+	ilst, ok := find(children(r, meta), "ilst")
+	if !ok {
+		return nil
+	}
+
+	for _, item := range children(r, ilst) {
+		data, ok := find(children(r, item), "data")
+		if !ok {
+			continue
+		}
+
+		b, err := payload(r, data)
+		if err != nil {
+			return err
+		}
+		if len(b) < 8 {
+			continue
+		}
+
+		kind, value := binary.BigEndian.Uint32(b[:4])&0xffffff, b[8:]
+
+		switch item.typ {
+		case "\xa9nam":
+			m.Title = strings.TrimSpace(string(value))
+		case "\xa9ART":
+			m.Artist = strings.TrimSpace(string(value))
+		case "\xa9alb":
+			m.Album = strings.TrimSpace(string(value))
+		case "covr":
+			if m.Artwork != nil || len(value) == 0 {
+				continue
+			}
+			mimeType := "image/jpeg"
+			if kind == 14 || bytes.HasPrefix(value, []byte("\x89PNG")) {
+				mimeType = "image/png"
+			}
+			m.Artwork = &Picture{MIMEType: mimeType, Data: value}
+		}
+	}
+
+	return nil
+}
+
+// neroChapters reads a chpl atom: a version, flags, a count, then each
+// chapter's start in units of 100ns and its length prefixed title.
+func neroChapters(b []byte) []Chapter {
+	if len(b) < 5 {
+		return nil
+	}
+
+	rest := b[4:]
+	if b[0] == 1 {
+		if len(rest) < 4 {
+			return nil
+		}
+		rest = rest[4:]
+	}
+
+	if len(rest) < 1 {
+		return nil
+	}
+	count := int(rest[0])
+	rest = rest[1:]
+
+	var chapters []Chapter
+	for i := 0; i < count && len(rest) >= 9; i++ {
+		start := binary.BigEndian.Uint64(rest[:8])
+		n := int(rest[8])
+		rest = rest[9:]
+		if n > len(rest) {
+			break
+		}
+		chapters = append(chapters, Chapter{
+			Title: strings.TrimSpace(string(rest[:n])),
+			Start: time.Duration(start) * 100 * time.Nanosecond,
+		})
+		rest = rest[n:]
+	}
+
+	return chapters
+}
@@ -0,0 +1,212 @@
+package audiometa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// mpegBitrates holds bitrates in kbit/s by version (1, or 2 and 2.5), layer
+// (I, II, III) and bitrate index.
+var mpegBitrates = [2][3][15]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+}
+
+// mpegSampleRates holds sample rates by version (1, 2, 2.5) and index.
+var mpegSampleRates = [3][3]int{
+	{44100, 48000, 32000},
+	{22050, 24000, 16000},
+	{11025, 12000, 8000},
+}
+
+type mpegFrame struct {
+	version    int // 0 for MPEG 1, 1 for MPEG 2, 2 for MPEG 2.5
+	layer      int // 1, 2 or 3
+	bitrate    int
+	sampleRate int
+	samples    int
+	length     int
+	mono       bool
+}
+
+// parseFrameHeader decodes a four byte MPEG audio frame header.
+func parseFrameHeader(h []byte) (mpegFrame, bool) {
+	if len(h) < 4 || h[0] != 0xff || h[1]&0xe0 != 0xe0 {
+		return mpegFrame{}, false
+	}
+
+	var f mpegFrame
+
+	switch (h[1] >> 3) & 3 {
+	case 3:
+		f.version = 0
+	case 2:
+		f.version = 1
+	case 0:
+		f.version = 2
+	default:
+		return mpegFrame{}, false
+	}
+
+	layer := (h[1] >> 1) & 3
+	if layer == 0 {
+		return mpegFrame{}, false
+	}
+	f.layer = 4 - int(layer)
+
+	bitrateIndex, rateIndex := h[2]>>4, (h[2]>>2)&3
+	if bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mpegFrame{}, false
+	}
+
+	table := 0
+	if f.version > 0 {
+		table = 1
+	}
+	f.bitrate = mpegBitrates[table][f.layer-1][bitrateIndex]
+	f.sampleRate = mpegSampleRates[f.version][rateIndex]
+	f.mono = h[3]>>6 == 3
+
+	padding := int(h[2]>>1) & 1
+
+	switch {
+	case f.layer == 1:
+		f.samples = 384
+		f.length = (12*f.bitrate*1000/f.sampleRate + padding) * 4
+	case f.layer == 3 && f.version > 0:
+		f.samples = 576
+		f.length = 72*f.bitrate*1000/f.sampleRate + padding
+	default:
+		f.samples = 1152
+		f.length = 144*f.bitrate*1000/f.sampleRate + padding
+	}
+
+	return f, true
+}
+
+// findFrame looks for the first frame header at or after off, accepting one
+// only when another header follows it, so stray sync bytes are not taken
+// for audio.
+func findFrame(r io.ReaderAt, off, size int64) (int64, mpegFrame, bool) {
+	const window = 64 << 10
+
+	buf := make([]byte, window)
+	n, _ := r.ReadAt(buf, off)
+	buf = buf[:n]
+
+	for i := 0; i+4 <= len(buf); i++ {
+		f, ok := parseFrameHeader(buf[i:])
+		if !ok {
+			continue
+		}
+
+		next := make([]byte, 4)
+		pos := off + int64(i) + int64(f.length)
+		if pos+4 > size {
+			return off + int64(i), f, true
+		}
+		if _, err := r.ReadAt(next, pos); err == nil {
+			if _, ok := parseFrameHeader(next); ok {
+				return off + int64(i), f, true
+			}
+		}
+	}
+
+	return 0, mpegFrame{}, false
+}
+
+// vbrFrames reads the frame count from a Xing/Info or VBRI header in the
+// first frame, as variable bitrate encoders write.
+func vbrFrames(frame []byte, f mpegFrame) (int64, bool) {
+	sideInfo := 32
+	switch {
+	case f.version == 0 && f.mono:
+		sideInfo = 17
+	case f.version > 0 && !f.mono:
+		sideInfo = 17
+	case f.version > 0 && f.mono:
+		sideInfo = 9
+	}
+
+	if off := 4 + sideInfo; len(frame) >= off+12 {
+		tag := frame[off : off+4]
+		if bytes.Equal(tag, []byte("Xing")) || bytes.Equal(tag, []byte("Info")) {
+			flags := binary.BigEndian.Uint32(frame[off+4:])
+			if flags&1 != 0 {
+				return int64(binary.BigEndian.Uint32(frame[off+8:])), true
+			}
+		}
+	}
+
+	if off := 4 + 32; len(frame) >= off+18 && bytes.Equal(frame[off:off+4], []byte("VBRI")) {
+		return int64(binary.BigEndian.Uint32(frame[off+14:])), true
+	}
+
+	return 0, false
+}
+
+func readMP3(r io.ReaderAt, size int64) (*Metadata, error) {
+	tag, audioStart, err := readID3(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Metadata{Format: FormatMP3}
+
+	audioEnd := size
+	if v1 := make([]byte, 128); size >= 128 {
+		if _, err := r.ReadAt(v1, size-128); err == nil && bytes.HasPrefix(v1, []byte("TAG")) {
+			audioEnd -= 128
+			m.Title = strings.TrimSpace(strings.TrimRight(decodeText(0, v1[3:33]), "\x00"))
+		}
+	}
+
+	if tag != nil {
+		tag.apply(m)
+	}
+
+	start, f, ok := findFrame(r, audioStart, size)
+	if !ok {
+		if tag == nil {
+			return nil, ErrUnsupportedFormat
+		}
+		fixChapters(m.Chapters, m.Duration)
+		return m, nil
+	}
+
+	m.SampleRate = f.sampleRate
+	audioBytes := audioEnd - start
+
+	frame := make([]byte, f.length)
+	n, _ := r.ReadAt(frame, start)
+
+	if frames, ok := vbrFrames(frame[:n], f); ok && frames > 0 {
+		m.Duration = seconds(float64(frames*int64(f.samples)) / float64(f.sampleRate))
+	} else if f.bitrate > 0 {
+		m.Duration = seconds(float64(audioBytes*8) / float64(f.bitrate*1000))
+	}
+
+	if m.Duration > 0 {
+		m.Bitrate = int(math.Round(float64(audioBytes*8) / m.Duration.Seconds() / 1000))
+	}
+
+	fixChapters(m.Chapters, m.Duration)
+
+	return m, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
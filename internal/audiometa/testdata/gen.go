@@ -0,0 +1,180 @@
+//go:build ignore
+
+// gen writes the audio fixtures the audiometa tests read. Run it from the
+// package directory with go generate.
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+	"os"
+	"path/filepath"
+	"unicode/utf16"
+)
+
+func main() {
+	fixtures := map[string][]byte{
+		"chapters-v23.mp3": chaptersV23(),
+		"vbr-v24.mp3":      vbrV24(),
+		"v22.mp3":          v22(),
+		"cbr.mp3":          cbrFrames(100),
+		"chapters.m4a":     chaptersM4A(),
+		"not-audio.txt":    []byte("hello, not audio\n"),
+	}
+
+	for name, data := range fixtures {
+		if err := os.WriteFile(filepath.Join("testdata", name), data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func u32(n uint32) []byte { return binary.BigEndian.AppendUint32(nil, n) }
+
+func syncsafe(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+func cat(parts ...[]byte) []byte { return bytes.Join(parts, nil) }
+
+// id3 wraps frames in an ID3v2 tag of the given major version.
+func id3(major byte, frames ...[]byte) []byte {
+	body := cat(frames...)
+	return cat([]byte{'I', 'D', '3', major, 0, 0}, syncsafe(len(body)), body)
+}
+
+func frame(major byte, id string, data []byte) []byte {
+	switch major {
+	case 2:
+		n := len(data)
+		return cat([]byte(id), []byte{byte(n >> 16), byte(n >> 8), byte(n)}, data)
+	case 3:
+		return cat([]byte(id), u32(uint32(len(data))), []byte{0, 0}, data)
+	default:
+		return cat([]byte(id), syncsafe(len(data)), []byte{0, 0}, data)
+	}
+}
+
+func latin1(major byte, id, s string) []byte { return frame(major, id, cat([]byte{0}, []byte(s))) }
+
+func utf16BOM(s string) []byte {
+	b := []byte{0xff, 0xfe}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+func chap(major byte, id string, startMs, endMs uint32, title string) []byte {
+	data := cat([]byte(id), []byte{0}, u32(startMs), u32(endMs), u32(0xffffffff), u32(0xffffffff), latin1(major, "TIT2", title))
+	return frame(major, "CHAP", data)
+}
+
+func ctoc(major byte, id string, topLevel bool, children ...string) []byte {
+	var flags byte
+	if topLevel {
+		flags = 0x03
+	}
+	data := cat([]byte(id), []byte{0, flags, byte(len(children))})
+	for _, c := range children {
+		data = cat(data, []byte(c), []byte{0})
+	}
+	return frame(major, "CTOC", data)
+}
+
+// cbrFrames is n MPEG 1 Layer III frames at 128 kbit/s and 44.1 kHz, each 417
+// bytes long.
+func cbrFrames(n int) []byte {
+	f := make([]byte, 417)
+	copy(f, []byte{0xff, 0xfb, 0x90, 0x00})
+	return bytes.Repeat(f, n)
+}
+
+func chaptersV23() []byte {
+	const major = 3
+	tag := id3(major,
+		latin1(major, "TIT2", "Episode 12"),
+		latin1(major, "TPE1", "Rina"),
+		latin1(major, "TALB", "Ngobrol Santai"),
+		frame(major, "APIC", cat([]byte{0}, []byte("image/jpeg\x00"), []byte{0}, []byte("back\x00"), []byte("JPEGBACK"))),
+		frame(major, "APIC", cat([]byte{0}, []byte("image/png\x00"), []byte{3}, []byte("cover\x00"), []byte("\x89PNGFRONT"))),
+		// Out of order on purpose: the table of contents decides.
+		chap(major, "c2", 1000, 2000, "Middle"),
+		chap(major, "c1", 0, 1000, "Intro"),
+		chap(major, "c3", 2000, 0xffffffff, "Outro"),
+		ctoc(major, "toc", true, "c1", "c2", "c3"),
+	)
+	return cat(tag, cbrFrames(125))
+}
+
+// vbrV24 has a UTF-16 title, chapters without a table of contents and a Xing
+// header saying the audio is 1000 frames long.
+func vbrV24() []byte {
+	const major = 4
+	tag := id3(major,
+		frame(major, "TIT2", cat([]byte{1}, utf16BOM("Épisode"))),
+		frame(major, "TPE1", cat([]byte{3}, []byte("Zoë"))),
+		chap(major, "b", 30000, 0, "Second"),
+		chap(major, "a", 0, 0, "First"),
+	)
+
+	first := make([]byte, 417)
+	copy(first, []byte{0xff, 0xfb, 0x90, 0x00})
+	copy(first[4+32:], cat([]byte("Xing"), u32(1), u32(1000)))
+
+	return cat(tag, first, cbrFrames(9))
+}
+
+func v22() []byte {
+	const major = 2
+	tag := id3(major,
+		latin1(major, "TT2", "Old Show"),
+		latin1(major, "TP1", "Someone"),
+		latin1(major, "TLE", "5000"),
+		frame(major, "PIC", cat([]byte{0}, []byte("PNG"), []byte{3}, []byte("\x00"), []byte("\x89PNGOLD"))),
+	)
+	return tag
+}
+
+func box(typ string, parts ...[]byte) []byte {
+	body := cat(parts...)
+	return cat(u32(uint32(8+len(body))), []byte(typ), body)
+}
+
+func ilstItem(typ string, kind uint32, value []byte) []byte {
+	return box(typ, box("data", u32(kind), u32(0), value))
+}
+
+// chaptersM4A is a minute long M4A with iTunes metadata, cover art and Nero
+// chapters.
+func chaptersM4A() []byte {
+	mvhd := box("mvhd", []byte{0, 0, 0, 0}, u32(0), u32(0), u32(1000), u32(60000), make([]byte, 80))
+	hdlr := box("hdlr", u32(0), u32(0), []byte("soun"), make([]byte, 13))
+	mdhd := box("mdhd", []byte{0, 0, 0, 0}, u32(0), u32(0), u32(44100), u32(44100*60), make([]byte, 4))
+	trak := box("trak", box("mdia", mdhd, hdlr))
+
+	meta := box("meta", u32(0),
+		box("hdlr", u32(0), u32(0), []byte("mdir"), make([]byte, 13)),
+		box("ilst",
+			ilstItem("\xa9nam", 1, []byte("M4A Episode")),
+			ilstItem("\xa9ART", 1, []byte("Studio")),
+			ilstItem("\xa9alb", 1, []byte("The Show")),
+			ilstItem("covr", 14, []byte("\x89PNGCOVER")),
+		),
+	)
+
+	chpl := []byte{1, 0, 0, 0, 0, 0, 0, 0, 2}
+	for _, c := range []struct {
+		start uint64
+		title string
+	}{{0, "Opening"}, {20 * 10_000_000, "Main"}} {
+		chpl = binary.BigEndian.AppendUint64(chpl, c.start)
+		chpl = append(chpl, byte(len(c.title)))
+		chpl = append(chpl, c.title...)
+	}
+
+	moov := box("moov", mvhd, trak, box("udta", meta, box("chpl", chpl)))
+
+	return cat(box("ftyp", []byte("M4A "), u32(0), []byte("isomM4A ")), moov, box("mdat", make([]byte, 7500)))
+}
@@ -0,0 +1,115 @@
+// Package country validates and normalizes countries and regions of origin
+// against an embedded ISO 3166-1 table.
+package country
+
+import (
+	"bufio"
+	_ "embed"
+	"strings"
+)
+
+//go:embed iso3166.tsv
+var iso3166 string
+
+type Country struct {
+	// Code is the ISO 3166-1 alpha-2 code, in upper case.
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+var (
+	countries []Country
+	byCode    = map[string]int{}
+	byName    = map[string]int{}
+)
+
+// aliases are names and codes commonly used for a country that differ from
+// the ones in the table.
+var aliases = map[string]string{
+	"uk":                       "GB",
+	"great britain":            "GB",
+	"britain":                  "GB",
+	"usa":                      "US",
+	"united states of america": "US",
+	"america":                  "US",
+	"turkey":                   "TR",
+	"vietnam":                  "VN",
+	"czech republic":           "CZ",
+	"swaziland":                "SZ",
+	"macedonia":                "MK",
+	"ivory coast":              "CI",
+	"cape verde":               "CV",
+	"east timor":               "TL",
+	"brunei":                   "BN",
+	"burma":                    "MM",
+	"korea":                    "KR",
+	"republic of korea":        "KR",
+	"holland":                  "NL",
+	"vatican":                  "VA",
+}
+
+func init() {
+	s := bufio.NewScanner(strings.NewReader(iso3166))
+
+	for s.Scan() {
+		line := s.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 2 {
+			continue
+		}
+
+		i := len(countries)
+		countries = append(countries, Country{Code: fields[0], Name: fields[1]})
+
+		byCode[fields[0]] = i
+		byName[strings.ToLower(fields[1])] = i
+	}
+
+	for alias, code := range aliases {
+		byName[alias] = byCode[code]
+	}
+}
+
+// Lookup finds the country named by s, which may be an ISO 3166-1 alpha-2
+// code or an English name. Case is ignored.
+func Lookup(s string) (Country, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Country{}, false
+	}
+
+	if i, ok := byCode[strings.ToUpper(s)]; ok && len(s) == 2 {
+		return countries[i], true
+	}
+
+	if i, ok := byName[strings.ToLower(s)]; ok {
+		return countries[i], true
+	}
+
+	return Country{}, false
+}
+
+// Normalize returns the code of the country named by s.
+func Normalize(s string) (string, bool) {
+	c, ok := Lookup(s)
+	return c.Code, ok
+}
+
+// Valid reports whether code is the normalized code of a known country.
+func Valid(code string) bool {
+	_, ok := byCode[code]
+	return ok
+}
+
+// Name returns the English name of the country with the given code, or code
+// itself if it is not known.
+func Name(code string) string {
+	if i, ok := byCode[code]; ok {
+		return countries[i].Name
+	}
+	return code
+}
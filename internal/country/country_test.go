@@ -0,0 +1,88 @@
+package country
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Country
+		ok   bool
+	}{
+		{in: "ID", want: Country{Code: "ID", Name: "Indonesia"}, ok: true},
+		{in: "id", want: Country{Code: "ID", Name: "Indonesia"}, ok: true},
+		{in: " gb ", want: Country{Code: "GB", Name: "United Kingdom"}, ok: true},
+		{in: "Indonesia", want: Country{Code: "ID", Name: "Indonesia"}, ok: true},
+		{in: "united states", want: Country{Code: "US", Name: "United States"}, ok: true},
+		{in: "Côte d'Ivoire", want: Country{Code: "CI", Name: "Côte d'Ivoire"}, ok: true},
+		{in: "UK", want: Country{Code: "GB", Name: "United Kingdom"}, ok: true},
+		{in: "USA", want: Country{Code: "US", Name: "United States"}, ok: true},
+		{in: "Ivory Coast", want: Country{Code: "CI", Name: "Côte d'Ivoire"}, ok: true},
+		{in: "Turkey", want: Country{Code: "TR", Name: "Türkiye"}, ok: true},
+		{in: "Korea", want: Country{Code: "KR", Name: "South Korea"}, ok: true},
+		{in: "North Korea", want: Country{Code: "KP", Name: "North Korea"}, ok: true},
+		{in: "", ok: false},
+		{in: "XX", ok: false},
+		{in: "IDN", ok: false},
+		{in: "Atlantis", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Lookup(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Lookup(%q) = %+v, %v, want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"id", "ID", true},
+		{"Viet Nam", "VN", true},
+		{"vietnam", "VN", true},
+		{"Czech Republic", "CZ", true},
+		{"holland", "NL", true},
+		{"Narnia", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Normalize(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Normalize(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestValidAndName(t *testing.T) {
+	tests := []struct {
+		code  string
+		valid bool
+		name  string
+	}{
+		{"ID", true, "Indonesia"},
+		{"GB", true, "United Kingdom"},
+		{"id", false, "id"},
+		{"UK", false, "UK"},
+		{"XX", false, "XX"},
+	}
+
+	for _, tt := range tests {
+		if got := Valid(tt.code); got != tt.valid {
+			t.Errorf("Valid(%q) = %v, want %v", tt.code, got, tt.valid)
+		}
+		if got := Name(tt.code); got != tt.name {
+			t.Errorf("Name(%q) = %q, want %q", tt.code, got, tt.name)
+		}
+	}
+}
+
+func TestAliasesNameKnownCountries(t *testing.T) {
+	for alias, code := range aliases {
+		if !Valid(code) {
+			t.Errorf("alias %q names unknown code %q", alias, code)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Appearance lists the people heard on a podcast: its host, when the host is a
+// person rather than an organization, and its guest speakers.
+type Appearance struct {
+	PodcastId int64
+	Title     string
+	People    []string
+}
+
+func (pm PodcastModel) GetAppearances() ([]Appearance, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT p.id, p.title, COALESCE(h.name, ''), p.guest_speakers
+		FROM podcasts p
+		LEFT JOIN hosts h ON h.id = p.host_id AND h.kind = 'person'
+		WHERE NOT p.hidden
+		ORDER BY p.id
+	`
+
+	rows, err := pm.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appearances := []Appearance{}
+
+	for rows.Next() {
+		var a Appearance
+		var host string
+		var guests []string
+		if err := rows.Scan(&a.PodcastId, &a.Title, &host, pq.Array(&guests)); err != nil {
+			return nil, err
+		}
+		if host != "" {
+			a.People = append(a.People, host)
+		}
+		a.People = append(a.People, guests...)
+		appearances = append(appearances, a)
+	}
+
+	return appearances, rows.Err()
+}
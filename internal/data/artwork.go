@@ -0,0 +1,49 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Artwork is a podcast's cover image: the uploaded original and the
+// thumbnails made from it, largest first.
+type Artwork struct {
+	Hash   string         `json:"hash"`
+	Url    string         `json:"url"`
+	Width  int            `json:"width"`
+	Height int            `json:"height"`
+	Sizes  []ArtworkImage `json:"sizes"`
+}
+
+type ArtworkImage struct {
+	Url    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// SetArtwork stores podcast.Artwork, or removes the artwork when it is nil,
+// and sets podcast.UpdatedAt to when it changed.
+func (pm PodcastModel) SetArtwork(podcast *Podcast) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var artwork any
+	if podcast.Artwork != nil {
+		b, err := json.Marshal(podcast.Artwork)
+		if err != nil {
+			return err
+		}
+		artwork = string(b)
+	}
+
+	query := `
+		UPDATE podcasts
+		SET artwork = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING updated_at
+	`
+
+	return pm.Db.QueryRowContext(ctx, query, artwork, podcast.Id).Scan(&podcast.UpdatedAt)
+}
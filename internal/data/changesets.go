@@ -0,0 +1,289 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+const (
+	ChangeCreate = "create"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+const (
+	ChangePending  = "pending"
+	ChangeApproved = "approved"
+	ChangeRejected = "rejected"
+)
+
+var ErrChangeSetReviewed = errors.New("change set has already been reviewed")
+
+// ChangeSet is a change to a podcast suggested by a contributor. Podcast holds
+// the podcast as it would be after a create or update, and BaseUpdatedAt when
+// the podcast an update or delete was written against was last changed, so
+// that approving it cannot silently overwrite a later edit.
+type ChangeSet struct {
+	Id            int64      `json:"id"`
+	UserId        int64      `json:"user_id"`
+	UserName      string     `json:"user_name"`
+	Action        string     `json:"action"`
+	PodcastId     *int64     `json:"podcast_id"`
+	Podcast       *Podcast   `json:"podcast,omitempty"`
+	BaseUpdatedAt *time.Time `json:"base_updated_at,omitempty"`
+	Comment       string     `json:"comment"`
+	Status        string     `json:"status"`
+	ReviewerId    *int64     `json:"reviewer_id"`
+	ReviewComment string     `json:"review_comment"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReviewedAt    *time.Time `json:"reviewed_at"`
+}
+
+type ChangeSetFilters struct {
+	Status    string `form:"status"`
+	Action    string `form:"action"`
+	PodcastId int64  `form:"podcast_id"`
+	UserId    int64
+}
+
+type ChangeSetModel struct {
+	Db *sql.DB
+}
+
+type IChangeSet interface {
+	Insert(*ChangeSet) error
+	FindById(int64) (*ChangeSet, error)
+	GetAll(ChangeSetFilters, Filters) ([]*ChangeSet, Metadata, error)
+	Approve(cs *ChangeSet, reviewerId int64, comment string) error
+	Reject(cs *ChangeSet, reviewerId int64, comment string) error
+}
+
+func NewChangeSetModel(db *sql.DB) IChangeSet {
+	return &ChangeSetModel{Db: db}
+}
+
+func ValidateChangeSet(v *validator.Validator, cs *ChangeSet) {
+	v.Check(validator.PermitedValues(cs.Action, ChangeCreate, ChangeUpdate, ChangeDelete), "action", "must be one of create, update or delete")
+	v.Check(cs.Action == ChangeCreate || (cs.PodcastId != nil && *cs.PodcastId > 0), "podcast_id", "must be provided to update or delete a podcast")
+	v.Check(cs.Action == ChangeCreate || cs.Action == ChangeUpdate || cs.Podcast == nil, "podcast", "must not be provided to delete a podcast")
+	v.Check(len(cs.Comment) <= 2000, "comment", "must not be more than 2000 bytes long")
+}
+
+func ValidateChangeSetFilters(v *validator.Validator, f ChangeSetFilters) {
+	v.Check(f.Status == "" || validator.PermitedValues(f.Status, ChangePending, ChangeApproved, ChangeRejected), "status", "must be one of pending, approved or rejected")
+	v.Check(f.Action == "" || validator.PermitedValues(f.Action, ChangeCreate, ChangeUpdate, ChangeDelete), "action", "must be one of create, update or delete")
+}
+
+const changeSetColumns = `cs.id, cs.user_id, users.name, cs.action, cs.podcast_id, cs.payload, cs.base_updated_at,
+	cs.comment, cs.status, cs.reviewer_id, cs.review_comment, cs.created_at, cs.reviewed_at`
+
+func changeSetFields(cs *ChangeSet) []any {
+	return []any{
+		&cs.Id,
+		&cs.UserId,
+		&cs.UserName,
+		&cs.Action,
+		&cs.PodcastId,
+		jsonColumn{&cs.Podcast},
+		&cs.BaseUpdatedAt,
+		&cs.Comment,
+		&cs.Status,
+		&cs.ReviewerId,
+		&cs.ReviewComment,
+		&cs.CreatedAt,
+		&cs.ReviewedAt,
+	}
+}
+
+func (cm ChangeSetModel) Insert(cs *ChangeSet) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var payload any
+	if cs.Podcast != nil {
+		b, err := json.Marshal(cs.Podcast)
+		if err != nil {
+			return err
+		}
+		payload = string(b)
+	}
+
+	query := `
+		INSERT INTO change_sets (user_id, action, podcast_id, payload, base_updated_at, comment)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, created_at
+	`
+
+	args := []any{cs.UserId, cs.Action, cs.PodcastId, payload, cs.BaseUpdatedAt, cs.Comment}
+
+	return cm.Db.QueryRowContext(ctx, query, args...).Scan(&cs.Id, &cs.Status, &cs.CreatedAt)
+}
+
+func (cm ChangeSetModel) FindById(id int64) (*ChangeSet, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + changeSetColumns + ` FROM change_sets cs JOIN users ON users.id = cs.user_id WHERE cs.id = $1`
+
+	var cs ChangeSet
+	if err := cm.Db.QueryRowContext(ctx, query, id).Scan(changeSetFields(&cs)...); err != nil {
+		return nil, err
+	}
+
+	return &cs, nil
+}
+
+func (cm ChangeSetModel) GetAll(csFilters ChangeSetFilters, filters Filters) ([]*ChangeSet, Metadata, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT count(*) OVER(), ` + changeSetColumns + `
+		FROM change_sets cs JOIN users ON users.id = cs.user_id
+		WHERE ($1 = '' OR cs.status = $1)
+		AND ($2 = '' OR cs.action = $2)
+		AND ($3 = 0 OR cs.podcast_id = $3)
+		AND ($4 = 0 OR cs.user_id = $4)
+		ORDER BY cs.` + filters.sortColumn() + ` ` + filters.sortDirection() + `, cs.id
+		LIMIT $5 OFFSET $6
+	`
+
+	args := []any{csFilters.Status, csFilters.Action, csFilters.PodcastId, csFilters.UserId, filters.Limit(), filters.Offset()}
+
+	rows, err := cm.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	changeSets := []*ChangeSet{}
+
+	for rows.Next() {
+		var cs ChangeSet
+		if err := rows.Scan(append([]any{&totalRecords}, changeSetFields(&cs)...)...); err != nil {
+			return nil, Metadata{}, err
+		}
+		changeSets = append(changeSets, &cs)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return changeSets, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Approve applies the change set to the catalog, crediting its author, and
+// marks it approved, all in one transaction. It returns ErrEditConflict when
+// the podcast changed or disappeared since the change was suggested, and
+// ErrChangeSetReviewed when another editor got to it first.
+func (cm ChangeSetModel) Approve(cs *ChangeSet, reviewerId int64, comment string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := cm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockPendingChangeSet(ctx, tx, cs.Id); err != nil {
+		return err
+	}
+
+	if cs.Action != ChangeCreate {
+		var updatedAt time.Time
+		err := tx.QueryRowContext(ctx, `SELECT updated_at FROM podcasts WHERE id = $1 FOR UPDATE`, *cs.PodcastId).Scan(&updatedAt)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		case err != nil:
+			return err
+		case cs.BaseUpdatedAt != nil && !updatedAt.Equal(*cs.BaseUpdatedAt):
+			return ErrEditConflict
+		}
+	}
+
+	switch cs.Action {
+	case ChangeCreate:
+		cs.Podcast.CreatedBy = &cs.UserId
+		if err := insertPodcast(ctx, tx, cs.Podcast); err != nil {
+			return err
+		}
+		cs.PodcastId = &cs.Podcast.Id
+	case ChangeUpdate:
+		cs.Podcast.Id = *cs.PodcastId
+		cs.Podcast.UpdatedBy = &cs.UserId
+		if err := updatePodcast(ctx, tx, cs.Podcast); err != nil {
+			return err
+		}
+	case ChangeDelete:
+		if _, err := tx.ExecContext(ctx, `DELETE FROM podcasts WHERE id = $1`, *cs.PodcastId); err != nil {
+			return err
+		}
+	}
+
+	if err := reviewChangeSet(ctx, tx, cs, ChangeApproved, reviewerId, comment); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Reject closes the change set without touching the catalog.
+func (cm ChangeSetModel) Reject(cs *ChangeSet, reviewerId int64, comment string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := cm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockPendingChangeSet(ctx, tx, cs.Id); err != nil {
+		return err
+	}
+
+	if err := reviewChangeSet(ctx, tx, cs, ChangeRejected, reviewerId, comment); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func lockPendingChangeSet(ctx context.Context, tx *sql.Tx, id int64) error {
+	var status string
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM change_sets WHERE id = $1 FOR UPDATE`, id).Scan(&status); err != nil {
+		return err
+	}
+	if status != ChangePending {
+		return ErrChangeSetReviewed
+	}
+	return nil
+}
+
+func reviewChangeSet(ctx context.Context, tx *sql.Tx, cs *ChangeSet, status string, reviewerId int64, comment string) error {
+
+	query := `
+		UPDATE change_sets
+		SET status = $1, reviewer_id = $2, review_comment = $3, reviewed_at = NOW(), podcast_id = $4
+		WHERE id = $5
+		RETURNING status, reviewer_id, review_comment, reviewed_at
+	`
+
+	args := []any{status, reviewerId, comment, cs.PodcastId, cs.Id}
+
+	return tx.QueryRowContext(ctx, query, args...).Scan(&cs.Status, &cs.ReviewerId, &cs.ReviewComment, &cs.ReviewedAt)
+}
@@ -0,0 +1,185 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/terajari/ipdb/internal/language"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+// TrendingHalfLife is how many days it takes for a view to count half as much
+// towards a podcast trending, and TrendingWindow how many days of views are
+// looked at in all.
+const (
+	TrendingHalfLife = 3
+	TrendingWindow   = 28
+)
+
+// Periods a top chart can cover, and how many days each one spans.
+var chartPeriods = map[string]int{
+	"day":   1,
+	"week":  7,
+	"month": 30,
+	"year":  365,
+	"all":   0,
+}
+
+type ChartFilters struct {
+	Tag      string `form:"tag"`
+	Language string `form:"language"`
+	Year     int64  `form:"year"`
+	Period   string `form:"period"`
+}
+
+type ChartEntry struct {
+	Rank    int     `json:"rank"`
+	Score   float64 `json:"score"`
+	Views   int64   `json:"views"`
+	Podcast Podcast `json:"podcast"`
+}
+
+type ChartModel struct {
+	Db *sql.DB
+}
+
+type IChart interface {
+	AddViews(map[int64]int64) error
+	Trending(ChartFilters, Filters) ([]*ChartEntry, Metadata, error)
+	Top(ChartFilters, Filters) ([]*ChartEntry, Metadata, error)
+}
+
+func NewChartModel(db *sql.DB) IChart {
+	return &ChartModel{Db: db}
+}
+
+func ValidateChartFilters(v *validator.Validator, f ChartFilters) {
+	_, ok := chartPeriods[f.Period]
+	v.Check(f.Period == "" || ok, "period", "must be one of day, week, month, year or all")
+	v.Check(f.Year == 0 || (f.Year >= 1900 && f.Year <= int64(time.Now().Year())), "year", "must be a valid year")
+	if f.Language != "" {
+		_, ok := language.Lookup(f.Language)
+		v.Check(ok, "language", "must be an ISO 639 language code, BCP 47 tag or language name")
+	}
+}
+
+// AddViews adds counts, keyed by podcast id, to today's totals. Views of
+// podcasts merged since they were viewed go to the podcast they were merged
+// into; podcasts deleted since are skipped.
+func (cm ChartModel) AddViews(counts map[int64]int64) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ids := make([]int64, 0, len(counts))
+	views := make([]int64, 0, len(counts))
+	for id, n := range counts {
+		ids = append(ids, id)
+		views = append(views, n)
+	}
+
+	query := `
+		INSERT INTO podcast_views (podcast_id, day, views)
+		SELECT podcasts.id, (NOW() AT TIME ZONE 'UTC')::date, sum(v.views)
+		FROM unnest($1::bigint[], $2::bigint[]) AS v(id, views)
+		LEFT JOIN podcast_redirects r ON r.old_id = v.id
+		JOIN podcasts ON podcasts.id = COALESCE(r.new_id, v.id)
+		GROUP BY podcasts.id
+		ON CONFLICT (podcast_id, day) DO UPDATE SET views = podcast_views.views + EXCLUDED.views
+	`
+
+	_, err := cm.Db.ExecContext(ctx, query, pq.Array(ids), pq.Array(views))
+	return err
+}
+
+// Trending ranks podcasts by their views over the last TrendingWindow days,
+// with each day's views counting half as much every TrendingHalfLife days.
+func (cm ChartModel) Trending(chartFilters ChartFilters, filters Filters) ([]*ChartEntry, Metadata, error) {
+
+	query := `
+		WITH scores AS (
+			SELECT podcast_id,
+				sum(views * power(0.5, ((NOW() AT TIME ZONE 'UTC')::date - day)::float8 / $5)) AS score,
+				sum(views) AS views
+			FROM podcast_views
+			WHERE day > (NOW() AT TIME ZONE 'UTC')::date - $6::int
+			GROUP BY podcast_id
+		)
+		SELECT count(*) OVER(), s.score, s.views, ` + podcastColumnsFor("p") + `
+		FROM scores s JOIN podcasts p ON p.id = s.podcast_id
+		WHERE ($1 = '' OR p.tags && tag_descendants($1))
+		AND ($2 = '' OR $2 = ANY(p.languages))
+		AND ($3 = 0 OR p.year = $3)
+		AND NOT p.hidden
+		AND ` + safeModeWhere("$4", "p") + `
+		ORDER BY s.score DESC, p.id
+		LIMIT $7 OFFSET $8
+	`
+
+	return cm.getChart(query, chartFilters, filters, TrendingHalfLife, TrendingWindow)
+}
+
+// Top ranks podcasts by their views over the period, the last week unless
+// another is asked for.
+func (cm ChartModel) Top(chartFilters ChartFilters, filters Filters) ([]*ChartEntry, Metadata, error) {
+
+	days, ok := chartPeriods[chartFilters.Period]
+	if !ok {
+		days = chartPeriods["week"]
+	}
+
+	query := `
+		WITH scores AS (
+			SELECT podcast_id, sum(views) AS views
+			FROM podcast_views
+			WHERE $5 = 0 OR day > (NOW() AT TIME ZONE 'UTC')::date - $5::int
+			GROUP BY podcast_id
+		)
+		SELECT count(*) OVER(), s.views, s.views, ` + podcastColumnsFor("p") + `
+		FROM scores s JOIN podcasts p ON p.id = s.podcast_id
+		WHERE ($1 = '' OR p.tags && tag_descendants($1))
+		AND ($2 = '' OR $2 = ANY(p.languages))
+		AND ($3 = 0 OR p.year = $3)
+		AND NOT p.hidden
+		AND ` + safeModeWhere("$4", "p") + `
+		ORDER BY s.views DESC, p.id
+		LIMIT $6 OFFSET $7
+	`
+
+	return cm.getChart(query, chartFilters, filters, days)
+}
+
+func (cm ChartModel) getChart(query string, chartFilters ChartFilters, filters Filters, extra ...any) ([]*ChartEntry, Metadata, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	args := append([]any{chartFilters.Tag, chartFilters.Language, chartFilters.Year, filters.SafeMode}, extra...)
+	args = append(args, filters.Limit(), filters.Offset())
+
+	rows, err := cm.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	entries := []*ChartEntry{}
+
+	for rows.Next() {
+		var entry ChartEntry
+		if err := rows.Scan(append([]any{&totalRecords, &entry.Score, &entry.Views}, podcastFields(&entry.Podcast)...)...); err != nil {
+			return nil, Metadata{}, err
+		}
+		entry.Rank = filters.Offset() + len(entries) + 1
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return entries, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
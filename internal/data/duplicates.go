@@ -0,0 +1,238 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DuplicateTitleSimilarity is the pg_trgm similarity above which two titles are
+// considered to name the same show.
+const DuplicateTitleSimilarity = 0.6
+
+type DuplicateCandidate struct {
+	Podcast    Podcast `json:"podcast"`
+	Similarity float64 `json:"similarity"`
+	SameUrl    bool    `json:"same_url"`
+}
+
+type DuplicatePair struct {
+	Podcast    Podcast `json:"podcast"`
+	Duplicate  Podcast `json:"duplicate"`
+	Similarity float64 `json:"similarity"`
+	SameUrl    bool    `json:"same_url"`
+}
+
+// FindDuplicates returns podcasts other than podcast itself whose normalized url
+// matches or whose title is similar enough, most similar first.
+func (pm PodcastModel) FindDuplicates(podcast *Podcast) ([]DuplicateCandidate, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT ` + podcastColumns + `, similarity(title, $1), podcast_url_key(url) = podcast_url_key($2)
+		FROM podcasts
+		WHERE (podcast_url_key(url) = podcast_url_key($2) OR (title % $1 AND similarity(title, $1) >= $3))
+		AND id <> $4
+		ORDER BY podcast_url_key(url) = podcast_url_key($2) DESC, similarity(title, $1) DESC
+		LIMIT 10
+	`
+
+	rows, err := pm.Db.QueryContext(ctx, query, podcast.Title, podcast.Url, DuplicateTitleSimilarity, podcast.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []DuplicateCandidate{}
+
+	for rows.Next() {
+		var c DuplicateCandidate
+		if err := rows.Scan(append(podcastFields(&c.Podcast), &c.Similarity, &c.SameUrl)...); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, c)
+	}
+
+	return candidates, rows.Err()
+}
+
+func (pm PodcastModel) GetDuplicatePairs(filters Filters) (*[]DuplicatePair, Metadata, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT count(*) OVER(), ` + podcastColumnsFor("a") + `, ` + podcastColumnsFor("b") + `,
+			similarity(a.title, b.title), podcast_url_key(a.url) = podcast_url_key(b.url)
+		FROM podcasts a
+		JOIN podcasts b ON a.id < b.id
+		AND (podcast_url_key(a.url) = podcast_url_key(b.url) OR (a.title % b.title AND similarity(a.title, b.title) >= $1))
+		ORDER BY podcast_url_key(a.url) = podcast_url_key(b.url) DESC, similarity(a.title, b.title) DESC, a.id, b.id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := pm.Db.QueryContext(ctx, query, DuplicateTitleSimilarity, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	pairs := []DuplicatePair{}
+
+	for rows.Next() {
+		var p DuplicatePair
+		dest := []any{&totalRecords}
+		dest = append(dest, podcastFields(&p.Podcast)...)
+		dest = append(dest, podcastFields(&p.Duplicate)...)
+		dest = append(dest, &p.Similarity, &p.SameUrl)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, Metadata{}, err
+		}
+		pairs = append(pairs, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return &pairs, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Merge folds source into target: target takes the tags and guest speakers
+// already merged by the caller and inherits source's episodes, feeds,
+// platforms, translations, slugs, reviews, reports, list items, listening
+// history, views and redirects. Source is deleted with a redirect left behind,
+// all in one transaction.
+func (pm PodcastModel) Merge(source, target *Podcast) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := pm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE podcasts
+		SET tags = $1, guest_speakers = $2, updated_at = NOW()
+		WHERE id = $3
+		RETURNING updated_at
+	`, pq.Array(target.Tags), pq.Array(target.GuestSpeakers), target.Id).Scan(&target.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	stmts := []string{
+		// Point history at the target's copy of episodes both podcasts have,
+		// before the source's copies are deleted, then keep the latest entry
+		// where a user listened to both podcasts.
+		`UPDATE listening_history h SET episode_id = t.id
+			FROM episodes e, episodes t
+			WHERE h.episode_id = e.id AND e.podcast_id = $1 AND t.podcast_id = $2 AND t.guid = e.guid`,
+		`DELETE FROM listening_history h WHERE h.podcast_id = $1
+			AND EXISTS (SELECT 1 FROM listening_history t WHERE t.podcast_id = $2 AND t.user_id = h.user_id
+				AND COALESCE(t.episode_id, 0) = COALESCE(h.episode_id, 0) AND t.listened_at >= h.listened_at)`,
+		`DELETE FROM listening_history t WHERE t.podcast_id = $2
+			AND EXISTS (SELECT 1 FROM listening_history h WHERE h.podcast_id = $1 AND h.user_id = t.user_id
+				AND COALESCE(h.episode_id, 0) = COALESCE(t.episode_id, 0))`,
+		`UPDATE listening_history SET podcast_id = $2 WHERE podcast_id = $1`,
+		`DELETE FROM episodes e WHERE e.podcast_id = $1
+			AND EXISTS (SELECT 1 FROM episodes t WHERE t.podcast_id = $2 AND t.guid = e.guid)`,
+		`UPDATE episodes SET podcast_id = $2 WHERE podcast_id = $1`,
+		`UPDATE feeds SET podcast_id = $2 WHERE podcast_id = $1`,
+		`INSERT INTO podcast_platforms (podcast_id, platform_id, url)
+			SELECT $2, platform_id, url FROM podcast_platforms WHERE podcast_id = $1
+			ON CONFLICT DO NOTHING`,
+		`INSERT INTO podcast_translations (podcast_id, language, title, description)
+			SELECT $2, language, title, description FROM podcast_translations WHERE podcast_id = $1
+			ON CONFLICT DO NOTHING`,
+		`UPDATE podcast_slugs SET podcast_id = $2 WHERE podcast_id = $1`,
+		// A user who reviewed both podcasts keeps their latest review.
+		`DELETE FROM reviews r WHERE r.podcast_id = $1
+			AND EXISTS (SELECT 1 FROM reviews t WHERE t.podcast_id = $2 AND t.user_id = r.user_id AND t.updated_at >= r.updated_at)`,
+		`DELETE FROM reviews t WHERE t.podcast_id = $2
+			AND EXISTS (SELECT 1 FROM reviews r WHERE r.podcast_id = $1 AND r.user_id = t.user_id)`,
+		`UPDATE reviews SET podcast_id = $2 WHERE podcast_id = $1`,
+		// A user may have one open report per podcast, so where they have one
+		// open against both, only the latest is kept.
+		`DELETE FROM reports r WHERE r.podcast_id = $1 AND r.status = 'open'
+			AND EXISTS (SELECT 1 FROM reports t WHERE t.podcast_id = $2 AND t.user_id = r.user_id AND t.status = 'open' AND t.created_at >= r.created_at)`,
+		`DELETE FROM reports t WHERE t.podcast_id = $2 AND t.status = 'open'
+			AND EXISTS (SELECT 1 FROM reports r WHERE r.podcast_id = $1 AND r.user_id = t.user_id AND r.status = 'open')`,
+		`UPDATE reports SET podcast_id = $2 WHERE podcast_id = $1`,
+		`DELETE FROM list_items i WHERE i.podcast_id = $1
+			AND EXISTS (SELECT 1 FROM list_items t WHERE t.podcast_id = $2 AND t.list_id = i.list_id)`,
+		`UPDATE list_items SET podcast_id = $2 WHERE podcast_id = $1`,
+		`INSERT INTO podcast_views (podcast_id, day, views)
+			SELECT $2, day, views FROM podcast_views WHERE podcast_id = $1
+			ON CONFLICT (podcast_id, day) DO UPDATE SET views = podcast_views.views + EXCLUDED.views`,
+		`UPDATE podcast_redirects SET new_id = $2 WHERE new_id = $1`,
+		`INSERT INTO podcast_redirects (old_id, new_id) VALUES ($1, $2)`,
+	}
+
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt, source.Id, target.Id); err != nil {
+			return err
+		}
+	}
+
+	if err := updateRating(ctx, tx, target.Id); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM podcasts WHERE id = $1`, source.Id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+// FindRedirect returns the id of the podcast that id was merged into.
+func (pm PodcastModel) FindRedirect(id int64) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var newId int64
+	err := pm.Db.QueryRowContext(ctx, `SELECT new_id FROM podcast_redirects WHERE old_id = $1`, id).Scan(&newId)
+
+	return newId, err
+}
+
+// FindRedirects is FindRedirect for many ids at once. Ids that were never
+// merged are left out of the map.
+func (pm PodcastModel) FindRedirects(ids []int64) (map[int64]int64, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := pm.Db.QueryContext(ctx, `SELECT old_id, new_id FROM podcast_redirects WHERE old_id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	redirects := make(map[int64]int64)
+	for rows.Next() {
+		var oldId, newId int64
+		if err := rows.Scan(&oldId, &newId); err != nil {
+			return nil, err
+		}
+		redirects[oldId] = newId
+	}
+
+	return redirects, rows.Err()
+}
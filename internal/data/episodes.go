@@ -0,0 +1,234 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+type Episode struct {
+	Id          int64      `json:"id"`
+	PodcastId   int64      `json:"podcast_id"`
+	Guid        string     `json:"guid"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Url         string     `json:"url"`
+	AudioUrl    string     `json:"audio_url"`
+	Duration    int64      `json:"duration"`
+	PublishedAt *time.Time `json:"published_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Bitrate     int        `json:"bitrate"`
+	Chapters    []Chapter  `json:"chapters"`
+	Artwork     *Artwork   `json:"artwork"`
+}
+
+// Chapter is a titled part of an episode, in milliseconds from its start.
+type Chapter struct {
+	Title   string `json:"title"`
+	StartMs int64  `json:"start_ms"`
+	EndMs   int64  `json:"end_ms"`
+}
+
+const episodeColumns = `id, podcast_id, guid, title, description, url, audio_url, duration, published_at, created_at,
+	bitrate, chapters, artwork`
+
+func episodeFields(episode *Episode) []any {
+	return []any{
+		&episode.Id,
+		&episode.PodcastId,
+		&episode.Guid,
+		&episode.Title,
+		&episode.Description,
+		&episode.Url,
+		&episode.AudioUrl,
+		&episode.Duration,
+		&episode.PublishedAt,
+		&episode.CreatedAt,
+		&episode.Bitrate,
+		jsonColumn{&episode.Chapters},
+		jsonColumn{&episode.Artwork},
+	}
+}
+
+type EpisodeModel struct {
+	Db *sql.DB
+}
+
+type IEpisode interface {
+	Insert(*Episode) error
+	Upsert(*Episode) error
+	FindById(int64) (*Episode, error)
+	GetAllForPodcast(int64, Filters) (*[]Episode, Metadata, error)
+	UpdateAudioMetadata(*Episode) error
+}
+
+func NewEpisodeModel(db *sql.DB) IEpisode {
+	return &EpisodeModel{Db: db}
+}
+
+func ValidateEpisode(v *validator.Validator, episode *Episode) {
+	v.Check(episode.Guid != "", "guid", "must be provided")
+	v.Check(len(episode.Guid) <= 500, "guid", "must not be more than 500 bytes long")
+	v.Check(episode.Title != "", "title", "must be provided")
+	v.Check(len(episode.Title) <= 500, "title", "must not be more than 500 bytes long")
+	v.Check(len(episode.Url) <= 500, "url", "must not be more than 500 bytes long")
+	v.Check(len(episode.AudioUrl) <= 500, "audio_url", "must not be more than 500 bytes long")
+	v.Check(episode.Duration >= 0, "duration", "must not be negative")
+}
+
+func (em EpisodeModel) Insert(episode *Episode) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO episodes (podcast_id, guid, title, description, url, audio_url, duration, published_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`
+
+	args := []any{
+		episode.PodcastId,
+		episode.Guid,
+		episode.Title,
+		episode.Description,
+		episode.Url,
+		episode.AudioUrl,
+		episode.Duration,
+		episode.PublishedAt,
+	}
+
+	return em.Db.QueryRowContext(ctx, query, args...).Scan(&episode.Id, &episode.CreatedAt)
+}
+
+// Upsert inserts the episode or, when one with the same guid already exists for
+// the podcast, overwrites its fields with the new values.
+func (em EpisodeModel) Upsert(episode *Episode) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO episodes (podcast_id, guid, title, description, url, audio_url, duration, published_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (podcast_id, guid) DO UPDATE
+		SET title = EXCLUDED.title, description = EXCLUDED.description, url = EXCLUDED.url,
+			audio_url = EXCLUDED.audio_url, duration = EXCLUDED.duration, published_at = EXCLUDED.published_at
+		RETURNING id, created_at
+	`
+
+	args := []any{
+		episode.PodcastId,
+		episode.Guid,
+		episode.Title,
+		episode.Description,
+		episode.Url,
+		episode.AudioUrl,
+		episode.Duration,
+		episode.PublishedAt,
+	}
+
+	return em.Db.QueryRowContext(ctx, query, args...).Scan(&episode.Id, &episode.CreatedAt)
+}
+
+func (em EpisodeModel) FindById(id int64) (*Episode, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + episodeColumns + ` FROM episodes WHERE id = $1`
+
+	var episode Episode
+	if err := em.Db.QueryRowContext(ctx, query, id).Scan(episodeFields(&episode)...); err != nil {
+		return nil, err
+	}
+
+	return &episode, nil
+}
+
+func (em EpisodeModel) GetAllForPodcast(podcastId int64, filters Filters) (*[]Episode, Metadata, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT count(*) OVER(), ` + episodeColumns + `
+		FROM episodes
+		WHERE podcast_id = $1
+		ORDER BY published_at DESC NULLS LAST, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := em.Db.QueryContext(ctx, query, podcastId, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	episodes := []Episode{}
+
+	for rows.Next() {
+		var episode Episode
+		if err := rows.Scan(append([]any{&totalRecords}, episodeFields(&episode)...)...); err != nil {
+			return nil, Metadata{}, err
+		}
+		episodes = append(episodes, episode)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return &episodes, metadata, nil
+}
+
+// UpdateAudioMetadata stores what was read from the episode's audio file.
+func (em EpisodeModel) UpdateAudioMetadata(episode *Episode) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	chapters, err := json.Marshal(episode.Chapters)
+	if err != nil {
+		return err
+	}
+
+	var artwork any
+	if episode.Artwork != nil {
+		b, err := json.Marshal(episode.Artwork)
+		if err != nil {
+			return err
+		}
+		artwork = string(b)
+	}
+
+	query := `
+		UPDATE episodes
+		SET title = $1, duration = $2, bitrate = $3, chapters = $4, artwork = $5
+		WHERE id = $6
+	`
+
+	args := []any{episode.Title, episode.Duration, episode.Bitrate, string(chapters), artwork, episode.Id}
+
+	res, err := em.Db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrDuplicateFeed = errors.New("duplicate feed")
+
+type Feed struct {
+	Id            int64      `json:"id"`
+	PodcastId     int64      `json:"podcast_id"`
+	Url           string     `json:"url"`
+	ETag          string     `json:"-"`
+	LastModified  string     `json:"-"`
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type FeedModel struct {
+	Db *sql.DB
+}
+
+type IFeed interface {
+	Insert(*Feed, *Podcast) error
+	FindById(int64) (*Feed, error)
+	FindByUrl(string) (*Feed, error)
+	GetByPodcastIds([]int64) (map[int64]*Feed, error)
+	UpdateFetchState(*Feed) error
+	GetDue(time.Duration, int) ([]*Feed, error)
+}
+
+func NewFeedModel(db *sql.DB) IFeed {
+	return &FeedModel{Db: db}
+}
+
+// Insert stores podcast and the feed it was created from in one transaction,
+// so a feed that cannot be added leaves no podcast behind.
+func (fm FeedModel) Insert(feed *Feed, podcast *Podcast) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := fm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertPodcast(ctx, tx, podcast); err != nil {
+		return err
+	}
+
+	feed.PodcastId = podcast.Id
+
+	query := `
+		INSERT INTO feeds (podcast_id, url, etag, last_modified, last_fetched_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+
+	args := []any{feed.PodcastId, feed.Url, feed.ETag, feed.LastModified, feed.LastFetchedAt}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&feed.Id, &feed.CreatedAt)
+	if err != nil {
+		switch {
+		case violatesConstraint(err, "feeds_url_key"):
+			return ErrDuplicateFeed
+		default:
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// violatesConstraint reports whether err is Postgres rejecting a row for
+// breaking the named unique constraint.
+func violatesConstraint(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
+func (fm FeedModel) FindById(id int64) (*Feed, error) {
+	return fm.findOne(`WHERE id = $1`, id)
+}
+
+func (fm FeedModel) FindByUrl(url string) (*Feed, error) {
+	return fm.findOne(`WHERE url = $1`, url)
+}
+
+// GetByPodcastIds returns the feed of each podcast that has one, keyed by podcast id.
+func (fm FeedModel) GetByPodcastIds(ids []int64) (map[int64]*Feed, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT DISTINCT ON (podcast_id) id, podcast_id, url, etag, last_modified, last_fetched_at, last_error, created_at
+		FROM feeds
+		WHERE podcast_id = ANY($1)
+		ORDER BY podcast_id, id
+	`
+
+	rows, err := fm.Db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feeds := make(map[int64]*Feed)
+
+	for rows.Next() {
+		var feed Feed
+		if err := rows.Scan(
+			&feed.Id,
+			&feed.PodcastId,
+			&feed.Url,
+			&feed.ETag,
+			&feed.LastModified,
+			&feed.LastFetchedAt,
+			&feed.LastError,
+			&feed.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		feeds[feed.PodcastId] = &feed
+	}
+
+	return feeds, rows.Err()
+}
+
+func (fm FeedModel) findOne(where string, arg any) (*Feed, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, podcast_id, url, etag, last_modified, last_fetched_at, last_error, created_at
+		FROM feeds
+	` + where
+
+	var feed Feed
+	if err := fm.Db.QueryRowContext(ctx, query, arg).Scan(
+		&feed.Id,
+		&feed.PodcastId,
+		&feed.Url,
+		&feed.ETag,
+		&feed.LastModified,
+		&feed.LastFetchedAt,
+		&feed.LastError,
+		&feed.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &feed, nil
+}
+
+func (fm FeedModel) UpdateFetchState(feed *Feed) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE feeds
+		SET etag = $1, last_modified = $2, last_fetched_at = $3, last_error = $4
+		WHERE id = $5
+	`
+
+	args := []any{feed.ETag, feed.LastModified, feed.LastFetchedAt, feed.LastError, feed.Id}
+
+	_, err := fm.Db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// GetDue returns up to limit feeds that have not been fetched within interval,
+// least recently fetched first.
+func (fm FeedModel) GetDue(interval time.Duration, limit int) ([]*Feed, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, podcast_id, url, etag, last_modified, last_fetched_at, last_error, created_at
+		FROM feeds
+		WHERE last_fetched_at IS NULL OR last_fetched_at < $1
+		ORDER BY last_fetched_at NULLS FIRST
+		LIMIT $2
+	`
+
+	rows, err := fm.Db.QueryContext(ctx, query, time.Now().Add(-interval), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feeds := []*Feed{}
+
+	for rows.Next() {
+		var feed Feed
+		if err := rows.Scan(
+			&feed.Id,
+			&feed.PodcastId,
+			&feed.Url,
+			&feed.ETag,
+			&feed.LastModified,
+			&feed.LastFetchedAt,
+			&feed.LastError,
+			&feed.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		feeds = append(feeds, &feed)
+	}
+
+	return feeds, rows.Err()
+}
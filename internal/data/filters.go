@@ -2,6 +2,7 @@ package data
 
 import (
 	"math"
+	"strings"
 
 	"github.com/terajari/ipdb/internal/validator"
 )
@@ -11,6 +12,10 @@ type Filters struct {
 	PageSize     int    `form:"page_size"`
 	Sort         string `form:"sort"`
 	SortSafelist []string
+	// SafeMode leaves explicit podcasts and those aimed at adults out of the
+	// results. It is set by the
+	// handler, never from the query string.
+	SafeMode bool `form:"-"`
 }
 
 type Metadata struct {
@@ -56,6 +61,24 @@ func DefaultsFilters(f Filters) *Filters {
 	return &f
 }
 
+// sortColumn returns the column named by Sort without its direction prefix. Sort
+// has already been checked against SortSafelist, so it is safe to interpolate.
+func (f Filters) sortColumn() string {
+	for _, safeValue := range f.SortSafelist {
+		if f.Sort == safeValue {
+			return strings.TrimPrefix(f.Sort, "-")
+		}
+	}
+	panic("unsafe sort parameter: " + f.Sort)
+}
+
+func (f Filters) sortDirection() string {
+	if strings.HasPrefix(f.Sort, "-") {
+		return "DESC"
+	}
+	return "ASC"
+}
+
 func (f Filters) Limit() int {
 	return f.PageSize
 }
@@ -0,0 +1,194 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+// MaxHistoryBatch caps how many entries a single sync request can carry.
+const MaxHistoryBatch = 500
+
+// Outcomes of syncing a single history entry.
+const (
+	HistoryApplied  = "applied"
+	HistoryStale    = "stale"
+	HistoryNotFound = "not_found"
+)
+
+// HistoryEntry is how far a user got into an episode, or into a podcast when
+// EpisodeId is nil.
+type HistoryEntry struct {
+	PodcastId    int64     `json:"podcast_id"`
+	PodcastTitle string    `json:"podcast_title"`
+	EpisodeId    *int64    `json:"episode_id"`
+	EpisodeTitle string    `json:"episode_title,omitempty"`
+	Duration     int64     `json:"duration,omitempty"`
+	Position     int       `json:"position"`
+	Completed    bool      `json:"completed"`
+	ListenedAt   time.Time `json:"listened_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// HistoryFilters narrows the history listing. InProgress keeps only entries
+// that were started but not completed, for "continue listening".
+type HistoryFilters struct {
+	PodcastId  int64 `form:"podcast_id"`
+	InProgress bool  `form:"in_progress"`
+}
+
+type HistoryModel struct {
+	Db *sql.DB
+}
+
+type IHistory interface {
+	Sync(userId int64, entries []*HistoryEntry) ([]string, error)
+	GetAllForUser(int64, HistoryFilters, Filters) ([]*HistoryEntry, Metadata, error)
+}
+
+func NewHistoryModel(db *sql.DB) IHistory {
+	return &HistoryModel{Db: db}
+}
+
+func ValidateHistoryEntry(v *validator.Validator, entry *HistoryEntry) {
+	v.Check(entry.PodcastId > 0 || entry.EpisodeId != nil, "podcast_id", "must be provided when episode_id is not")
+	v.Check(entry.EpisodeId == nil || *entry.EpisodeId > 0, "episode_id", "must be greater than zero")
+	v.Check(entry.Position >= 0, "position", "must not be negative")
+	v.Check(!entry.ListenedAt.IsZero(), "listened_at", "must be provided")
+	v.Check(entry.ListenedAt.Before(time.Now().Add(5*time.Minute)), "listened_at", "must not be in the future")
+}
+
+// Sync stores a batch of entries recorded by a client, possibly while offline.
+// An entry only replaces what is stored when it was listened to later, so
+// devices syncing out of order cannot roll progress back. It reports the
+// outcome of each entry in order.
+func (hm HistoryModel) Sync(userId int64, entries []*HistoryEntry) ([]string, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tx, err := hm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// The podcast is taken from the episode when one is given, so clients only
+	// need to send the episode id.
+	query := `
+		INSERT INTO listening_history (user_id, podcast_id, episode_id, position, completed, listened_at)
+		SELECT $1, COALESCE(e.podcast_id, p.id), e.id, $4, $5, $6
+		FROM (SELECT 1) one
+		LEFT JOIN episodes e ON e.id = $3
+		LEFT JOIN podcasts p ON p.id = $2
+		WHERE CASE WHEN $3::bigint IS NULL THEN p.id IS NOT NULL ELSE e.id IS NOT NULL END
+		ON CONFLICT (user_id, podcast_id, (COALESCE(episode_id, 0))) DO UPDATE
+		SET position = EXCLUDED.position, completed = EXCLUDED.completed,
+			listened_at = EXCLUDED.listened_at, updated_at = NOW()
+		WHERE listening_history.listened_at < EXCLUDED.listened_at
+		RETURNING podcast_id, updated_at
+	`
+
+	outcomes := make([]string, len(entries))
+
+	for i, entry := range entries {
+		args := []any{userId, entry.PodcastId, entry.EpisodeId, entry.Position, entry.Completed, entry.ListenedAt}
+
+		err := tx.QueryRowContext(ctx, query, args...).Scan(&entry.PodcastId, &entry.UpdatedAt)
+		switch {
+		case err == nil:
+			outcomes[i] = HistoryApplied
+		case errors.Is(err, sql.ErrNoRows):
+			outcomes[i] = HistoryStale
+		default:
+			return nil, err
+		}
+	}
+
+	// Nothing is inserted for unknown podcasts or episodes either, so tell
+	// those apart from entries that lost to newer progress.
+	for i, entry := range entries {
+		if outcomes[i] != HistoryStale {
+			continue
+		}
+
+		var exists bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT CASE WHEN $2::bigint IS NULL
+				THEN EXISTS (SELECT 1 FROM podcasts WHERE id = $1)
+				ELSE EXISTS (SELECT 1 FROM episodes WHERE id = $2)
+			END
+		`, entry.PodcastId, entry.EpisodeId).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			outcomes[i] = HistoryNotFound
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return outcomes, nil
+}
+
+func (hm HistoryModel) GetAllForUser(userId int64, historyFilters HistoryFilters, filters Filters) ([]*HistoryEntry, Metadata, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT count(*) OVER(), h.podcast_id, p.title, h.episode_id, COALESCE(e.title, ''), COALESCE(e.duration, 0),
+			h.position, h.completed, h.listened_at, h.updated_at
+		FROM listening_history h
+		JOIN podcasts p ON p.id = h.podcast_id
+		LEFT JOIN episodes e ON e.id = h.episode_id
+		WHERE h.user_id = $1
+		AND ($2 = 0 OR h.podcast_id = $2)
+		AND (NOT $3 OR (NOT h.completed AND h.position > 0))
+		ORDER BY h.` + filters.sortColumn() + ` ` + filters.sortDirection() + `, h.id DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	args := []any{userId, historyFilters.PodcastId, historyFilters.InProgress, filters.Limit(), filters.Offset()}
+
+	rows, err := hm.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	entries := []*HistoryEntry{}
+
+	for rows.Next() {
+		var entry HistoryEntry
+		err := rows.Scan(
+			&totalRecords,
+			&entry.PodcastId,
+			&entry.PodcastTitle,
+			&entry.EpisodeId,
+			&entry.EpisodeTitle,
+			&entry.Duration,
+			&entry.Position,
+			&entry.Completed,
+			&entry.ListenedAt,
+			&entry.UpdatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return entries, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
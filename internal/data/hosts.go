@@ -0,0 +1,227 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+var (
+	ErrDuplicateHost = errors.New("duplicate host")
+	ErrHostInUse     = errors.New("host in use")
+)
+
+const (
+	HostKindPerson       = "person"
+	HostKindOrganization = "organization"
+)
+
+// Host is the person or organization presenting a podcast.
+type Host struct {
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Url       string    `json:"url"`
+	Podcasts  int       `json:"podcasts"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   int       `json:"version"`
+}
+
+type HostModel struct {
+	Db *sql.DB
+}
+
+type IHost interface {
+	Insert(*Host) error
+	FindById(int64) (*Host, error)
+	FindOrCreate(string) (*Host, error)
+	GetAll(string, Filters) ([]*Host, Metadata, error)
+	Update(*Host) error
+	DeleteById(int64) error
+}
+
+func NewHostModel(db *sql.DB) IHost {
+	return &HostModel{Db: db}
+}
+
+func ValidateHost(v *validator.Validator, host *Host) {
+	v.Check(host.Name != "", "name", "must be provided")
+	v.Check(len(host.Name) <= 500, "name", "must not be more than 500 bytes long")
+	v.Check(validator.PermitedValues(host.Kind, HostKindPerson, HostKindOrganization), "kind", "must be person or organization")
+	if host.Url != "" {
+		_, err := CanonicalURL(host.Url)
+		v.Check(err == nil, "url", "must be an absolute http or https url")
+	}
+}
+
+const hostColumns = `hosts.id, hosts.name, hosts.kind, hosts.url,
+	(SELECT count(*) FROM podcasts WHERE podcasts.host_id = hosts.id) AS podcasts,
+	hosts.created_at, hosts.version`
+
+func hostFields(host *Host) []any {
+	return []any{&host.Id, &host.Name, &host.Kind, &host.Url, &host.Podcasts, &host.CreatedAt, &host.Version}
+}
+
+func (hm HostModel) Insert(host *Host) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO hosts (name, kind, url)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, version
+	`
+
+	err := hm.Db.QueryRowContext(ctx, query, host.Name, host.Kind, host.Url).Scan(&host.Id, &host.CreatedAt, &host.Version)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return ErrDuplicateHost
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (hm HostModel) FindById(id int64) (*Host, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + hostColumns + ` FROM hosts WHERE id = $1`
+
+	var host Host
+	if err := hm.Db.QueryRowContext(ctx, query, id).Scan(hostFields(&host)...); err != nil {
+		return nil, err
+	}
+
+	return &host, nil
+}
+
+// FindOrCreate returns the host called name, ignoring case, creating a person
+// with that name if there is none.
+func (hm HostModel) FindOrCreate(name string) (*Host, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	name = strings.TrimSpace(name)
+
+	_, err := hm.Db.ExecContext(ctx, `INSERT INTO hosts (name) VALUES ($1) ON CONFLICT DO NOTHING`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + hostColumns + ` FROM hosts WHERE lower(name) = lower($1)`
+
+	var host Host
+	if err := hm.Db.QueryRowContext(ctx, query, name).Scan(hostFields(&host)...); err != nil {
+		return nil, err
+	}
+
+	return &host, nil
+}
+
+// GetAll lists hosts whose name contains name, or all hosts when name is empty.
+func (hm HostModel) GetAll(name string, filters Filters) ([]*Host, Metadata, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), %s
+		FROM hosts
+		WHERE ($1 = '' OR strpos(lower(name), lower($1)) > 0)
+		ORDER BY %s %s, id ASC
+		LIMIT $2 OFFSET $3
+	`, hostColumns, filters.sortColumn(), filters.sortDirection())
+
+	rows, err := hm.Db.QueryContext(ctx, query, strings.TrimSpace(name), filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	hosts := []*Host{}
+
+	for rows.Next() {
+		var host Host
+		if err := rows.Scan(append([]any{&totalRecords}, hostFields(&host)...)...); err != nil {
+			return nil, Metadata{}, err
+		}
+		hosts = append(hosts, &host)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return hosts, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Update saves host if it is still at the version that was read. Every podcast
+// linked to the host shows the new name.
+func (hm HostModel) Update(host *Host) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE hosts
+		SET name = $1, kind = $2, url = $3, version = version + 1
+		WHERE id = $4 AND version = $5
+		RETURNING version
+	`
+
+	args := []any{host.Name, host.Kind, host.Url, host.Id, host.Version}
+
+	err := hm.Db.QueryRowContext(ctx, query, args...).Scan(&host.Version)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return ErrDuplicateHost
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (hm HostModel) DeleteById(id int64) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := hm.Db.ExecContext(ctx, `DELETE FROM hosts WHERE id = $1`, id)
+	if err != nil {
+		switch {
+		case isForeignKeyViolation(err):
+			return ErrHostInUse
+		default:
+			return err
+		}
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package data
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/terajari/ipdb/internal/language"
+)
+
+type LanguageCount struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Podcasts int    `json:"podcasts"`
+}
+
+// NormalizeLanguages maps codes, BCP 47 tags and language names to ISO 639
+// codes. Values that name no known language are kept as they are so that
+// ValidatePodcast can report them.
+func NormalizeLanguages(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, value := range values {
+		if code, ok := language.Normalize(value); ok {
+			value = code
+		}
+		out = append(out, value)
+	}
+	return out
+}
+
+// CountLanguages returns every language in use with the number of podcasts
+// in it, most common first.
+func (pm PodcastModel) CountLanguages() ([]LanguageCount, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT code, count(*)
+		FROM podcasts, unnest(languages) AS code
+		GROUP BY code
+		ORDER BY count(*) DESC, code
+	`
+
+	rows, err := pm.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []LanguageCount{}
+
+	for rows.Next() {
+		var c LanguageCount
+		if err := rows.Scan(&c.Code, &c.Podcasts); err != nil {
+			return nil, err
+		}
+		c.Name = language.Name(c.Code)
+		counts = append(counts, c)
+	}
+
+	return counts, rows.Err()
+}
+
+// NormalizeStoredLanguages replaces stored language values that name a known
+// language with its code, as NormalizeLanguages does for new values, and
+// returns the number of podcasts changed. Migrations copy free-form values as
+// they are so that the language table stays the only place languages are
+// named.
+func (pm PodcastModel) NormalizeStoredLanguages() (int64, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rows, err := pm.Db.QueryContext(ctx, `SELECT DISTINCT value FROM podcasts, unnest(languages) AS value`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var values, codes []string
+
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return 0, err
+		}
+		if code, ok := language.Normalize(value); ok && code != value {
+			values = append(values, value)
+			codes = append(codes, code)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	// Values that normalize to a code the podcast already has are dropped,
+	// keeping the first position.
+	query := `
+		UPDATE podcasts
+		SET languages = ARRAY(
+			SELECT code FROM (
+				SELECT COALESCE(m.code, u.value) AS code, u.i
+				FROM unnest(podcasts.languages) WITH ORDINALITY AS u(value, i)
+				LEFT JOIN unnest($1::text[], $2::text[]) AS m(value, code) ON m.value = u.value
+			) c
+			GROUP BY code
+			ORDER BY min(i)
+		)
+		WHERE languages && $1::text[]
+	`
+
+	res, err := pm.Db.ExecContext(ctx, query, pq.Array(values), pq.Array(codes))
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,459 @@
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+const (
+	ListKindFavorites = "favorites"
+	ListKindCustom    = "custom"
+)
+
+// MaxListItems caps how many podcasts a single list can hold.
+const MaxListItems = 1000
+
+var (
+	ErrDuplicateListItem = errors.New("podcast already in list")
+	ErrListFull          = errors.New("list is full")
+)
+
+type List struct {
+	Id          int64      `json:"id"`
+	UserId      int64      `json:"user_id"`
+	Kind        string     `json:"kind"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Public      bool       `json:"public"`
+	ShareId     string     `json:"share_id"`
+	ItemCount   int        `json:"item_count"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Version     int        `json:"version"`
+	Items       []ListItem `json:"items,omitempty"`
+}
+
+type ListItem struct {
+	Position int       `json:"position"`
+	Note     string    `json:"note"`
+	AddedAt  time.Time `json:"added_at"`
+	Podcast  Podcast   `json:"podcast"`
+}
+
+type ListModel struct {
+	Db *sql.DB
+}
+
+type IList interface {
+	Insert(*List) error
+	Favorites(int64) (*List, error)
+	FindForUser(id, userId int64) (*List, error)
+	FindByShareId(string) (*List, error)
+	GetAllForUser(int64) ([]*List, error)
+	Update(*List) error
+	Delete(id, userId int64) error
+	GetItems(listId int64, safeMode bool) ([]ListItem, error)
+	AddItem(listId, podcastId int64, position int, note string) error
+	MoveItem(listId, podcastId int64, position int, note string) error
+	RemoveItem(listId, podcastId int64) error
+}
+
+func NewListModel(db *sql.DB) IList {
+	return &ListModel{Db: db}
+}
+
+func ValidateList(v *validator.Validator, list *List) {
+	v.Check(list.Name != "", "name", "must be provided")
+	v.Check(len(list.Name) <= 200, "name", "must not be more than 200 bytes long")
+	v.Check(len(list.Description) <= 2000, "description", "must not be more than 2000 bytes long")
+}
+
+func ValidateListItem(v *validator.Validator, position int, note string) {
+	v.Check(position >= 0, "position", "must not be negative")
+	v.Check(len(note) <= 1000, "note", "must not be more than 1000 bytes long")
+}
+
+// randomId returns a random, url-safe identifier for sharing a record without
+// exposing its sequential id.
+func randomId() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)), nil
+}
+
+const listColumns = `lists.id, lists.user_id, lists.kind, lists.name, lists.description, lists.public, lists.share_id,
+	(SELECT count(*) FROM list_items WHERE list_items.list_id = lists.id),
+	lists.created_at, lists.updated_at, lists.version`
+
+func listFields(list *List) []any {
+	return []any{
+		&list.Id,
+		&list.UserId,
+		&list.Kind,
+		&list.Name,
+		&list.Description,
+		&list.Public,
+		&list.ShareId,
+		&list.ItemCount,
+		&list.CreatedAt,
+		&list.UpdatedAt,
+		&list.Version,
+	}
+}
+
+func (lm ListModel) Insert(list *List) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	shareId, err := randomId()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO lists (user_id, kind, name, description, public, share_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, share_id, created_at, updated_at, version
+	`
+
+	args := []any{list.UserId, list.Kind, list.Name, list.Description, list.Public, shareId}
+
+	return lm.Db.QueryRowContext(ctx, query, args...).Scan(&list.Id, &list.ShareId, &list.CreatedAt, &list.UpdatedAt, &list.Version)
+}
+
+// Favorites returns the user's favorites list, creating it on first use.
+func (lm ListModel) Favorites(userId int64) (*List, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	shareId, err := randomId()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = lm.Db.ExecContext(ctx, `
+		INSERT INTO lists (user_id, kind, name, share_id)
+		VALUES ($1, 'favorites', 'Favorites', $2)
+		ON CONFLICT (user_id) WHERE kind = 'favorites' DO NOTHING
+	`, userId, shareId)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + listColumns + ` FROM lists WHERE user_id = $1 AND kind = 'favorites'`
+
+	var list List
+	if err := lm.Db.QueryRowContext(ctx, query, userId).Scan(listFields(&list)...); err != nil {
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+// FindForUser returns list id if it belongs to userId.
+func (lm ListModel) FindForUser(id, userId int64) (*List, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + listColumns + ` FROM lists WHERE id = $1 AND user_id = $2`
+
+	var list List
+	if err := lm.Db.QueryRowContext(ctx, query, id, userId).Scan(listFields(&list)...); err != nil {
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+// FindByShareId returns the public list with the given share id.
+func (lm ListModel) FindByShareId(shareId string) (*List, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + listColumns + ` FROM lists WHERE share_id = $1 AND public`
+
+	var list List
+	if err := lm.Db.QueryRowContext(ctx, query, shareId).Scan(listFields(&list)...); err != nil {
+		return nil, err
+	}
+
+	return &list, nil
+}
+
+func (lm ListModel) GetAllForUser(userId int64) ([]*List, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT ` + listColumns + `
+		FROM lists
+		WHERE user_id = $1
+		ORDER BY kind = 'favorites' DESC, name, id
+	`
+
+	rows, err := lm.Db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []*List{}
+
+	for rows.Next() {
+		var list List
+		if err := rows.Scan(listFields(&list)...); err != nil {
+			return nil, err
+		}
+		lists = append(lists, &list)
+	}
+
+	return lists, rows.Err()
+}
+
+// Update saves list if it is still at the version that was read.
+func (lm ListModel) Update(list *List) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE lists
+		SET name = $1, description = $2, public = $3, updated_at = NOW(), version = version + 1
+		WHERE id = $4 AND version = $5
+		RETURNING updated_at, version
+	`
+
+	args := []any{list.Name, list.Description, list.Public, list.Id, list.Version}
+
+	err := lm.Db.QueryRowContext(ctx, query, args...).Scan(&list.UpdatedAt, &list.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete removes a custom list owned by userId. Favorites cannot be deleted.
+func (lm ListModel) Delete(id, userId int64) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := lm.Db.ExecContext(ctx, `DELETE FROM lists WHERE id = $1 AND user_id = $2 AND kind = 'custom'`, id, userId)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetItems returns the podcasts in the list in order, leaving out hidden ones,
+// and those safe mode hides when it is on.
+func (lm ListModel) GetItems(listId int64, safeMode bool) ([]ListItem, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT li.position, li.note, li.added_at, ` + podcastColumnsFor("p") + `
+		FROM list_items li JOIN podcasts p ON p.id = li.podcast_id
+		WHERE li.list_id = $1
+		AND NOT p.hidden
+		AND ` + safeModeWhere("$2", "p") + `
+		ORDER BY li.position, li.added_at
+	`
+
+	rows, err := lm.Db.QueryContext(ctx, query, listId, safeMode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ListItem{}
+
+	for rows.Next() {
+		var item ListItem
+		dest := append([]any{&item.Position, &item.Note, &item.AddedAt}, podcastFields(&item.Podcast)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// AddItem puts podcastId into the list at position, counting from 1, shifting
+// the items after it down. A position of 0 or past the end appends.
+func (lm ListModel) AddItem(listId, podcastId int64, position int, note string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := lm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the list so concurrent additions do not pick the same position.
+	var count int
+	err = tx.QueryRowContext(ctx, `
+		SELECT (SELECT count(*) FROM list_items WHERE list_id = $1) FROM lists WHERE id = $1 FOR UPDATE
+	`, listId).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count >= MaxListItems {
+		return ErrListFull
+	}
+
+	if position < 1 || position > count {
+		position = count + 1
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE list_items SET position = position + 1 WHERE list_id = $1 AND position >= $2`, listId, position)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO list_items (list_id, podcast_id, position, note) VALUES ($1, $2, $3, $4)
+	`, listId, podcastId, position, note)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return ErrDuplicateListItem
+		default:
+			return err
+		}
+	}
+
+	if err := touchList(ctx, tx, listId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MoveItem moves podcastId to position and replaces its note. A position of 0
+// keeps the item where it is.
+func (lm ListModel) MoveItem(listId, podcastId int64, position int, note string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := lm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current, count int
+	err = tx.QueryRowContext(ctx, `
+		SELECT li.position, (SELECT count(*) FROM list_items WHERE list_id = $1)
+		FROM lists JOIN list_items li ON li.list_id = lists.id
+		WHERE lists.id = $1 AND li.podcast_id = $2
+		FOR UPDATE OF lists
+	`, listId, podcastId).Scan(&current, &count)
+	if err != nil {
+		return err
+	}
+
+	if position < 1 {
+		position = current
+	}
+	if position > count {
+		position = count
+	}
+
+	// Close the gap the item leaves, then open one where it goes.
+	_, err = tx.ExecContext(ctx, `UPDATE list_items SET position = position - 1 WHERE list_id = $1 AND position > $2`, listId, current)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE list_items SET position = position + 1 WHERE list_id = $1 AND position >= $2 AND podcast_id <> $3
+	`, listId, position, podcastId)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE list_items SET position = $1, note = $2 WHERE list_id = $3 AND podcast_id = $4
+	`, position, note, listId, podcastId)
+	if err != nil {
+		return err
+	}
+
+	if err := touchList(ctx, tx, listId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (lm ListModel) RemoveItem(listId, podcastId int64) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := lm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var position int
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM list_items WHERE list_id = $1 AND podcast_id = $2 RETURNING position
+	`, listId, podcastId).Scan(&position)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE list_items SET position = position - 1 WHERE list_id = $1 AND position > $2`, listId, position)
+	if err != nil {
+		return err
+	}
+
+	if err := touchList(ctx, tx, listId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func touchList(ctx context.Context, tx *sql.Tx, listId int64) error {
+	_, err := tx.ExecContext(ctx, `UPDATE lists SET updated_at = NOW() WHERE id = $1`, listId)
+	return err
+}
@@ -1,17 +1,54 @@
 package data
 
-import "database/sql"
+import (
+	"database/sql"
+	"errors"
+)
+
+var ErrEditConflict = errors.New("edit conflict")
 
 type Models struct {
-	Podcast IPodcast
-	User    IUser
-	Token   IToken
+	Podcast     IPodcast
+	User        IUser
+	Token       IToken
+	Episode     IEpisode
+	Feed        IFeed
+	Platform    IPlatform
+	Tag         ITag
+	Host        IHost
+	Program     IProgram
+	Review      IReview
+	List        IList
+	History     IHistory
+	Similar     ISimilarity
+	Chart       IChart
+	Stats       IStats
+	Change      IChangeSet
+	Report      IReport
+	Transcript  ITranscript
+	Translation ITranslation
 }
 
 func NewModels(db *sql.DB) Models {
 	return Models{
-		Podcast: NewPodcastModel(db),
-		User:    NewUserModel(db),
-		Token:   NewTokenModel(db),
+		Podcast:     NewPodcastModel(db),
+		User:        NewUserModel(db),
+		Token:       NewTokenModel(db),
+		Episode:     NewEpisodeModel(db),
+		Feed:        NewFeedModel(db),
+		Platform:    NewPlatformModel(db),
+		Tag:         NewTagModel(db),
+		Host:        NewHostModel(db),
+		Program:     NewProgramModel(db),
+		Review:      NewReviewModel(db),
+		List:        NewListModel(db),
+		History:     NewHistoryModel(db),
+		Similar:     NewSimilarityModel(db),
+		Chart:       NewChartModel(db),
+		Stats:       NewStatsModel(db),
+		Change:      NewChangeSetModel(db),
+		Report:      NewReportModel(db),
+		Transcript:  NewTranscriptModel(db),
+		Translation: NewTranslationModel(db),
 	}
 }
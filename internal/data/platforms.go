@@ -0,0 +1,313 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lib/pq"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+var (
+	ErrDuplicatePlatform = errors.New("duplicate platform")
+	ErrPlatformInUse     = errors.New("platform in use")
+)
+
+type Platform struct {
+	Id          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Slug        string    `json:"slug"`
+	Aliases     []string  `json:"aliases"`
+	UrlPatterns []string  `json:"url_patterns"`
+	CreatedAt   time.Time `json:"created_at"`
+	Version     int       `json:"version"`
+}
+
+// PodcastPlatform is one place a podcast can be listened to.
+type PodcastPlatform struct {
+	PlatformId int64  `json:"platform_id"`
+	Platform   string `json:"platform"`
+	Url        string `json:"url"`
+}
+
+type PlatformModel struct {
+	Db *sql.DB
+}
+
+type IPlatform interface {
+	Insert(*Platform) error
+	FindById(int64) (*Platform, error)
+	Resolve(string) (*Platform, error)
+	GetAll() ([]*Platform, error)
+	Update(*Platform, string) error
+	DeleteById(int64) error
+}
+
+func NewPlatformModel(db *sql.DB) IPlatform {
+	return &PlatformModel{Db: db}
+}
+
+// Slugify lowercases s and joins its runs of letters and digits with hyphens.
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+
+	return b.String()
+}
+
+func ValidatePlatform(v *validator.Validator, platform *Platform) {
+	v.Check(platform.Name != "", "name", "must be provided")
+	v.Check(len(platform.Name) <= 100, "name", "must not be more than 100 bytes long")
+	v.Check(platform.Slug != "" && Slugify(platform.Slug) == platform.Slug, "slug", "must contain only lowercase letters, digits and single hyphens")
+	v.Check(len(platform.Aliases) <= 20, "aliases", "must not contain more than 20 aliases")
+	v.Check(validator.Unique(platform.Aliases...), "aliases", "must not contain duplicate aliases")
+	v.Check(len(platform.UrlPatterns) <= 10, "url_patterns", "must not contain more than 10 patterns")
+
+	for _, pattern := range platform.UrlPatterns {
+		_, err := regexp.Compile(pattern)
+		v.Check(err == nil, "url_patterns", "must contain only valid regular expressions")
+	}
+}
+
+// Matches reports whether url belongs to the platform. Platforms without
+// patterns accept any url.
+func (p *Platform) Matches(url string) bool {
+	if len(p.UrlPatterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range p.UrlPatterns {
+		rx, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		if rx.MatchString(url) {
+			return true
+		}
+	}
+
+	return false
+}
+
+const platformColumns = `id, name, slug, aliases, url_patterns, created_at, version`
+
+func platformFields(platform *Platform) []any {
+	return []any{
+		&platform.Id,
+		&platform.Name,
+		&platform.Slug,
+		pq.Array(&platform.Aliases),
+		pq.Array(&platform.UrlPatterns),
+		&platform.CreatedAt,
+		&platform.Version,
+	}
+}
+
+func normalizeAliases(aliases []string) []string {
+	out := make([]string, 0, len(aliases))
+	for _, a := range aliases {
+		if a = strings.ToLower(strings.TrimSpace(a)); a != "" {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+func (pm PlatformModel) Insert(platform *Platform) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	platform.Aliases = normalizeAliases(platform.Aliases)
+
+	query := `
+		INSERT INTO platforms (name, slug, aliases, url_patterns)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, version
+	`
+
+	args := []any{platform.Name, platform.Slug, pq.Array(platform.Aliases), pq.Array(platform.UrlPatterns)}
+
+	err := pm.Db.QueryRowContext(ctx, query, args...).Scan(&platform.Id, &platform.CreatedAt, &platform.Version)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return ErrDuplicatePlatform
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (pm PlatformModel) FindById(id int64) (*Platform, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + platformColumns + ` FROM platforms WHERE id = $1`
+
+	var platform Platform
+	if err := pm.Db.QueryRowContext(ctx, query, id).Scan(platformFields(&platform)...); err != nil {
+		return nil, err
+	}
+
+	return &platform, nil
+}
+
+// Resolve finds the platform whose name, slug or one of whose aliases matches
+// name, ignoring case and surrounding whitespace.
+func (pm PlatformModel) Resolve(name string) (*Platform, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT ` + platformColumns + `
+		FROM platforms
+		WHERE lower(name) = $1 OR slug = $1 OR slug = $2 OR $1 = ANY(aliases)
+		ORDER BY lower(name) = $1 DESC, id
+		LIMIT 1
+	`
+
+	key := strings.ToLower(strings.TrimSpace(name))
+
+	var platform Platform
+	if err := pm.Db.QueryRowContext(ctx, query, key, Slugify(name)).Scan(platformFields(&platform)...); err != nil {
+		return nil, err
+	}
+
+	return &platform, nil
+}
+
+func (pm PlatformModel) GetAll() ([]*Platform, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + platformColumns + ` FROM platforms ORDER BY name`
+
+	rows, err := pm.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	platforms := []*Platform{}
+
+	for rows.Next() {
+		var platform Platform
+		if err := rows.Scan(platformFields(&platform)...); err != nil {
+			return nil, err
+		}
+		platforms = append(platforms, &platform)
+	}
+
+	return platforms, rows.Err()
+}
+
+// Update saves platform if it is still at the version that was read, and renames
+// the platform on every podcast that lists oldName as its primary platform.
+func (pm PlatformModel) Update(platform *Platform, oldName string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	platform.Aliases = normalizeAliases(platform.Aliases)
+
+	tx, err := pm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE platforms
+		SET name = $1, slug = $2, aliases = $3, url_patterns = $4, version = version + 1
+		WHERE id = $5 AND version = $6
+		RETURNING version
+	`
+
+	args := []any{
+		platform.Name,
+		platform.Slug,
+		pq.Array(platform.Aliases),
+		pq.Array(platform.UrlPatterns),
+		platform.Id,
+		platform.Version,
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&platform.Version)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return ErrDuplicatePlatform
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	if oldName != platform.Name {
+		_, err = tx.ExecContext(ctx, `UPDATE podcasts SET platform = $1, updated_at = NOW() WHERE platform = $2`, platform.Name, oldName)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// isForeignKeyViolation reports whether err is Postgres refusing to change or
+// delete a row that other rows still refer to.
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+func (pm PlatformModel) DeleteById(id int64) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := pm.Db.ExecContext(ctx, `DELETE FROM platforms WHERE id = $1`, id)
+	if err != nil {
+		switch {
+		case isForeignKeyViolation(err):
+			return ErrPlatformInUse
+		default:
+			return err
+		}
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestIsForeignKeyViolation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"foreign key violation", &pq.Error{Code: "23503", Constraint: "podcast_platforms_platform_id_fkey"}, true},
+		{"wrapped", fmt.Errorf("delete platform: %w", &pq.Error{Code: "23503"}), true},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+		{"message alone", errors.New(`pq: update or delete on table "platforms" violates foreign key constraint`), false},
+	}
+
+	for _, tt := range tests {
+		if got := isForeignKeyViolation(tt.err); got != tt.want {
+			t.Errorf("%s: isForeignKeyViolation() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -3,24 +3,257 @@ package data
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/terajari/ipdb/internal/country"
+	"github.com/terajari/ipdb/internal/language"
 	"github.com/terajari/ipdb/internal/validator"
 )
 
 type Podcast struct {
-	Id            int64     `json:"id"`
-	Title         string    `json:"title"`
-	Platform      string    `json:"platform"`
-	Url           string    `json:"url"`
-	Host          string    `json:"host"`
-	Program       string    `json:"program"`
-	GuestSpeakers []string  `json:"guest_speakers"`
-	Year          int64     `json:"year"`
-	Language      string    `json:"language"`
-	Tags          []string  `json:"tags"`
-	CreatedAt     time.Time `json:"created_at"`
+	Id             int64             `json:"id"`
+	PublicId       string            `json:"public_id"`
+	Slug           string            `json:"slug"`
+	Title          string            `json:"title"`
+	Description    string            `json:"description"`
+	Locale         string            `json:"locale,omitempty"`
+	Platform       string            `json:"platform"`
+	Url            string            `json:"url"`
+	HostId         int64             `json:"host_id"`
+	Host           string            `json:"host"`
+	ProgramId      int64             `json:"program_id"`
+	Program        string            `json:"program"`
+	GuestSpeakers  []string          `json:"guest_speakers"`
+	Year           int64             `json:"year"`
+	Languages      []string          `json:"languages"`
+	Tags           []string          `json:"tags"`
+	Explicit       bool              `json:"explicit"`
+	Advisories     []string          `json:"advisories"`
+	Audience       string            `json:"audience"`
+	Country        string            `json:"country"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+	CreatedBy      *int64            `json:"created_by"`
+	UpdatedBy      *int64            `json:"updated_by"`
+	Hidden         bool              `json:"hidden"`
+	Artwork        *Artwork          `json:"artwork"`
+	LinkStatus     string            `json:"link_status"`
+	LinkCheckedAt  *time.Time        `json:"link_checked_at"`
+	RatingAverage  float64           `json:"rating_average"`
+	RatingCount    int               `json:"rating_count"`
+	RatingScore    float64           `json:"rating_score"`
+	FavoritesCount int               `json:"favorites_count"`
+	Platforms      []PodcastPlatform `json:"platforms"`
+}
+
+// MarshalJSON adds language, the podcast's first language, for clients written
+// before a podcast could have several.
+func (p Podcast) MarshalJSON() ([]byte, error) {
+	type podcast Podcast
+
+	var language string
+	if len(p.Languages) > 0 {
+		language = p.Languages[0]
+	}
+
+	return json.Marshal(struct {
+		podcast
+		Language string `json:"language"`
+	}{podcast(p), language})
+}
+
+// HiddenInSafeMode reports whether safe mode leaves the podcast out: it is
+// explicit or aimed at adults.
+func (p *Podcast) HiddenInSafeMode() bool {
+	return p.Explicit || p.Audience == AudienceAdults
+}
+
+// Audiences a podcast can be aimed at.
+const (
+	AudienceGeneral = "general"
+	AudienceKids    = "kids"
+	AudienceTeens   = "teens"
+	AudienceAdults  = "adults"
+)
+
+var Audiences = []string{AudienceGeneral, AudienceKids, AudienceTeens, AudienceAdults}
+
+// Advisories describe content some listeners may want to avoid, whether or
+// not the podcast is explicit. Safe mode does not act on them: a podcast with
+// advisories may still be fit for a general audience.
+var Advisories = []string{"language", "violence", "sexual_content", "substances", "gambling", "mature_themes"}
+
+const (
+	LinkStatusUnknown = "unknown"
+	LinkStatusOk      = "ok"
+	LinkStatusBroken  = "broken"
+)
+
+// MaxDescriptionLength bounds podcast descriptions and their translations.
+const MaxDescriptionLength = 5000
+
+type PodcastFilters struct {
+	Platform   string   `form:"platform"`
+	Language   string   `form:"language"`
+	Tags       []string `form:"tags"`
+	LinkStatus string   `form:"link_status"`
+	HostId     int64    `form:"host_id"`
+	ProgramId  int64    `form:"program_id"`
+	Explicit   string   `form:"explicit"`
+	Audience   string   `form:"audience"`
+	Country    string   `form:"country"`
+}
+
+func ValidatePodcastFilters(v *validator.Validator, f PodcastFilters) {
+	v.Check(f.LinkStatus == "" || validator.PermitedValues(f.LinkStatus, LinkStatusUnknown, LinkStatusOk, LinkStatusBroken), "link_status", "must be one of unknown, ok or broken")
+	if f.Language != "" {
+		_, ok := language.Lookup(f.Language)
+		v.Check(ok, "language", "must be an ISO 639 language code, BCP 47 tag or language name")
+	}
+	v.Check(validator.PermitedValues(f.Explicit, "", "true", "false"), "explicit", "must be true or false")
+	v.Check(f.Audience == "" || validator.PermitedValues(f.Audience, Audiences...), "audience", "must be one of general, kids, teens or adults")
+	if f.Country != "" {
+		_, ok := country.Lookup(f.Country)
+		v.Check(ok, "country", "must be an ISO 3166-1 country code or name")
+	}
+}
+
+// podcastFiltersWhere matches the podcasts table against PodcastFilters, taking
+// the filters as $1 to $9 in the order given by args. Podcasts hidden after
+// being reported never match.
+const podcastFiltersWhere = `NOT podcasts.hidden
+		AND ($1 = '' OR EXISTS (
+			SELECT 1 FROM podcast_platforms pp JOIN platforms pl ON pl.id = pp.platform_id
+			WHERE pp.podcast_id = podcasts.id AND pl.slug = $1
+		))
+		AND ($2 = '' OR $2 = ANY(podcasts.languages))
+		AND NOT EXISTS (
+			SELECT 1 FROM unnest($3::text[]) AS f(slug)
+			WHERE NOT podcasts.tags && tag_descendants(f.slug)
+		)
+		AND (podcasts.link_status = $4 OR $4 = '')
+		AND (podcasts.host_id = $5 OR $5 = 0)
+		AND (podcasts.program_id = $6 OR $6 = 0)
+		AND ($7 = '' OR podcasts.explicit = ($7 = 'true'))
+		AND (podcasts.audience = $8 OR $8 = '')
+		AND (podcasts.country = $9 OR $9 = '')`
+
+func (f PodcastFilters) args() []any {
+	return []any{f.Platform, f.Language, pq.Array(f.Tags), f.LinkStatus, f.HostId, f.ProgramId, f.Explicit, f.Audience, f.Country}
+}
+
+// podcastColumnsTemplate lists the podcast columns for a table referenced as %[1]s,
+// weighting the rating with %[2]d votes at the catalog average.
+const podcastColumnsTemplate = `%[1]s.id, %[1]s.public_id, %[1]s.slug, %[1]s.title, %[1]s.description, %[1]s.platform, %[1]s.url,
+	%[1]s.host_id, (SELECT name FROM hosts WHERE hosts.id = %[1]s.host_id) AS host,
+	%[1]s.program_id, (SELECT name FROM programs WHERE programs.id = %[1]s.program_id) AS program,
+	%[1]s.guest_speakers, %[1]s.year, %[1]s.languages, %[1]s.tags,
+	%[1]s.explicit, %[1]s.advisories, %[1]s.audience, %[1]s.country, %[1]s.created_at, %[1]s.updated_at,
+	%[1]s.created_by, %[1]s.updated_by, %[1]s.hidden, %[1]s.artwork,
+	%[1]s.link_status, %[1]s.link_checked_at,
+	%[1]s.rating_average, %[1]s.rating_count,
+	(%[1]s.rating_count * %[1]s.rating_average + %[2]d * (SELECT COALESCE(avg(score), 0) FROM reviews))
+		/ (%[1]s.rating_count + %[2]d) AS rating,
+	(
+		SELECT count(*) FROM list_items li JOIN lists l ON l.id = li.list_id
+		WHERE l.kind = 'favorites' AND li.podcast_id = %[1]s.id
+	) AS favorites_count,
+	COALESCE((
+		SELECT json_agg(json_build_object('platform_id', pl.id, 'platform', pl.name, 'url', pp.url) ORDER BY pl.name)
+		FROM podcast_platforms pp JOIN platforms pl ON pl.id = pp.platform_id
+		WHERE pp.podcast_id = %[1]s.id
+	), '[]')`
+
+func podcastColumnsFor(alias string) string {
+	return fmt.Sprintf(podcastColumnsTemplate, alias, RatingPriorVotes)
+}
+
+var podcastColumns = podcastColumnsFor("podcasts")
+
+// safeModeWhere returns the condition that leaves out the podcasts safe mode
+// hides, as HiddenInSafeMode does, when the boolean parameter param is true.
+func safeModeWhere(param, alias string) string {
+	return fmt.Sprintf("NOT (%[1]s AND (%[2]s.explicit OR %[2]s.audience = '%[3]s'))", param, alias, AudienceAdults)
+}
+
+// podcastFields returns scan destinations matching podcastColumns.
+func podcastFields(podcast *Podcast) []any {
+	return []any{
+		&podcast.Id,
+		&podcast.PublicId,
+		&podcast.Slug,
+		&podcast.Title,
+		&podcast.Description,
+		&podcast.Platform,
+		&podcast.Url,
+		&podcast.HostId,
+		&podcast.Host,
+		&podcast.ProgramId,
+		&podcast.Program,
+		pq.Array(&podcast.GuestSpeakers),
+		&podcast.Year,
+		pq.Array(&podcast.Languages),
+		pq.Array(&podcast.Tags),
+		&podcast.Explicit,
+		pq.Array(&podcast.Advisories),
+		&podcast.Audience,
+		&podcast.Country,
+		&podcast.CreatedAt,
+		&podcast.UpdatedAt,
+		&podcast.CreatedBy,
+		&podcast.UpdatedBy,
+		&podcast.Hidden,
+		jsonColumn{&podcast.Artwork},
+		&podcast.LinkStatus,
+		&podcast.LinkCheckedAt,
+		&podcast.RatingAverage,
+		&podcast.RatingCount,
+		&podcast.RatingScore,
+		&podcast.FavoritesCount,
+		jsonColumn{&podcast.Platforms},
+	}
+}
+
+// jsonColumn scans a json or jsonb column into dest.
+type jsonColumn struct {
+	dest any
+}
+
+func (j jsonColumn) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, j.dest)
+	case string:
+		return json.Unmarshal([]byte(v), j.dest)
+	default:
+		return fmt.Errorf("jsonColumn: cannot scan %T", src)
+	}
+}
+
+// savePodcastPlatforms replaces the platforms listed for podcast.
+func savePodcastPlatforms(ctx context.Context, tx *sql.Tx, podcast *Podcast) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM podcast_platforms WHERE podcast_id = $1`, podcast.Id); err != nil {
+		return err
+	}
+
+	ids := make([]int64, len(podcast.Platforms))
+	urls := make([]string, len(podcast.Platforms))
+	for i, p := range podcast.Platforms {
+		ids[i], urls[i] = p.PlatformId, p.Url
+	}
+
+	query := `
+		INSERT INTO podcast_platforms (podcast_id, platform_id, url)
+		SELECT $1, unnest($2::bigint[]), unnest($3::text[])
+	`
+
+	_, err := tx.ExecContext(ctx, query, podcast.Id, pq.Array(ids), pq.Array(urls))
+	return err
 }
 
 type PodcastModel struct {
@@ -30,10 +263,27 @@ type PodcastModel struct {
 type IPodcast interface {
 	Insert(*Podcast) error
 	FindById(int64) (*Podcast, error)
+	FindByIds([]int64) ([]*Podcast, error)
+	FindByUrl(string) (*Podcast, error)
 	GetPodcasts() ([]*Podcast, error)
 	UpdatePodcast(*Podcast) error
 	DeleteById(int64) error
-	GetAll(string, []string, Filters) (*[]Podcast, Metadata, error)
+	GetAll(PodcastFilters, Filters) (*[]Podcast, Metadata, error)
+	Stream(PodcastFilters, Filters, func(*Podcast) error) error
+	FindDuplicates(*Podcast) ([]DuplicateCandidate, error)
+	GetDuplicatePairs(Filters) (*[]DuplicatePair, Metadata, error)
+	Merge(source, target *Podcast) error
+	FindRedirect(int64) (int64, error)
+	FindRedirects([]int64) (map[int64]int64, error)
+	Resolve(string) (*PodcastRef, error)
+	ResolveAll([]string) (map[string]int64, error)
+	GetForLinkCheck(time.Duration, int) ([]PodcastLink, error)
+	CatalogChangedAt() (time.Time, error)
+	UpdateLinkStatus(int64, string, int) error
+	SetArtwork(podcast *Podcast) error
+	CountLanguages() ([]LanguageCount, error)
+	NormalizeStoredLanguages() (int64, error)
+	GetAppearances() ([]Appearance, error)
 }
 
 func NewPodcastModel(db *sql.DB) IPodcast {
@@ -43,21 +293,41 @@ func NewPodcastModel(db *sql.DB) IPodcast {
 func ValidatePodcast(v *validator.Validator, podcast *Podcast) {
 	v.Check(podcast.Title != "", "title", "must be provided")
 	v.Check(len(podcast.Title) <= 500, "title", "must not be more than 500 bytes long")
+	v.Check(len(podcast.Description) <= MaxDescriptionLength, "description", fmt.Sprintf("must not be more than %d bytes long", MaxDescriptionLength))
 	v.Check(podcast.Platform != "", "platform", "must be provided")
 	v.Check(len(podcast.Platform) <= 500, "platform", "must not be more than 500 bytes long")
 	v.Check(podcast.Url != "", "url", "must be provided")
 	v.Check(len(podcast.Url) <= 500, "url", "must not be more than 500 bytes long")
+	if podcast.Url != "" {
+		_, err := CanonicalURL(podcast.Url)
+		v.Check(err == nil, "url", "must be an absolute http or https url")
+	}
 	v.Check(podcast.Host != "", "host", "must be provided")
 	v.Check(len(podcast.Host) <= 500, "host", "must not be more than 500 bytes long")
 	v.Check(podcast.Program != "", "program", "must be provided")
-	v.Check(podcast.Language != "", "language", "must be provided")
-	v.Check(len(podcast.Language) <= 500, "language", "must not be more than 500 bytes long")
+	v.Check(len(podcast.Languages) >= 1, "languages", "must contain at least 1 language")
+	v.Check(len(podcast.Languages) <= 5, "languages", "must not contain more than 5 languages")
+	v.Check(validator.Unique(podcast.Languages...), "languages", "must not contain duplicate languages")
+	for _, code := range podcast.Languages {
+		v.Check(language.Valid(code), "languages", fmt.Sprintf("%q is not an ISO 639 language", code))
+	}
 	v.Check(podcast.Year != 0, "year", "must be provided")
 	v.Check(podcast.Year >= 2003, "year", "must be greater than 2003")
 	v.Check(podcast.Year <= int64(time.Now().Year()), "year", "must not be in the future")
 	v.Check(len(podcast.Tags) >= 1, "tags", "must contain at least 1 tag")
 	v.Check(len(podcast.Tags) <= 10, "tags", "must not contain more than 10 tags")
 	v.Check(validator.Unique[string](podcast.Tags...), "tags", "must not contain duplicate tags")
+	for _, tag := range podcast.Tags {
+		v.Check(Slugify(tag) != "", "tags", "must contain only tags with letters or digits")
+		v.Check(len(tag) <= 100, "tags", "must not contain tags more than 100 bytes long")
+	}
+	v.Check(validator.Unique(podcast.Advisories...), "advisories", "must not contain duplicate advisories")
+	for _, advisory := range podcast.Advisories {
+		v.Check(validator.PermitedValues(advisory, Advisories...), "advisories", fmt.Sprintf("%q is not a known advisory", advisory))
+	}
+	v.Check(validator.PermitedValues(podcast.Audience, Audiences...), "audience", "must be one of general, kids, teens or adults")
+	v.Check(!(podcast.Explicit && podcast.Audience == AudienceKids), "audience", "must not be kids for an explicit podcast")
+	v.Check(podcast.Country == "" || country.Valid(podcast.Country), "country", "must be an ISO 3166-1 country code")
 	v.Check(len(podcast.GuestSpeakers) >= 1, "guest_speakers", "must contain at least 1 guest_speaker")
 	v.Check(len(podcast.GuestSpeakers) <= 10, "guest_speakers", "must not contain more than 10 guest_speakers")
 	v.Check(validator.Unique[string](podcast.GuestSpeakers...), "guest_speakers", "must not contain duplicate guest_speakers")
@@ -68,26 +338,71 @@ func (pm PodcastModel) Insert(podcast *Podcast) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := pm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = insertPodcast(ctx, tx, podcast); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertPodcast inserts the podcast, its slug and its platform links within tx.
+func insertPodcast(ctx context.Context, tx *sql.Tx, podcast *Podcast) error {
+
+	publicId, err := randomId()
+	if err != nil {
+		return err
+	}
+
+	slug, err := pickSlug(ctx, tx, PodcastSlug(podcast.Title), 0)
+	if err != nil {
+		return err
+	}
+
 	query := `
 		INSERT INTO podcasts 
-		(title, platform, url, host, program, guest_speakers, year, language, tags)
+		(title, platform, url, host_id, program_id, guest_speakers, year, languages, tags, created_by, updated_by, public_id, slug, description,
+			explicit, advisories, audience, country)
 		VALUES
-		($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		RETURNING id, created_at
+		($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10, $11, $12, $13, $14, $15, $16, $17)
+		RETURNING id, public_id, slug, created_at, updated_at, link_status, updated_by
 	`
 
 	args := []any{
 		podcast.Title,
 		podcast.Platform,
 		podcast.Url,
-		podcast.Host,
-		podcast.Program,
+		podcast.HostId,
+		podcast.ProgramId,
 		pq.Array(podcast.GuestSpeakers),
 		podcast.Year,
-		podcast.Language,
+		pq.Array(podcast.Languages),
 		pq.Array(podcast.Tags),
+		podcast.CreatedBy,
+		publicId,
+		slug,
+		podcast.Description,
+		podcast.Explicit,
+		pq.Array(podcast.Advisories),
+		podcast.Audience,
+		podcast.Country,
+	}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&podcast.Id, &podcast.PublicId, &podcast.Slug, &podcast.CreatedAt, &podcast.UpdatedAt, &podcast.LinkStatus, &podcast.UpdatedBy)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO podcast_slugs (slug, podcast_id) VALUES ($1, $2)`, podcast.Slug, podcast.Id); err != nil {
+		return err
 	}
-	return pm.Db.QueryRowContext(ctx, query, args...).Scan(&podcast.Id, &podcast.CreatedAt)
+
+	return savePodcastPlatforms(ctx, tx, podcast)
 }
 
 func (pm PodcastModel) FindById(id int64) (*Podcast, error) {
@@ -96,25 +411,69 @@ func (pm PodcastModel) FindById(id int64) (*Podcast, error) {
 	defer cancel()
 
 	query := `
-		SELECT id, title, platform, url, host, program, guest_speakers, year, language, tags, created_at 
+		SELECT ` + podcastColumns + `
 		FROM podcasts
 		WHERE id = $1
 	`
 
 	var podcast Podcast
-	if err := pm.Db.QueryRowContext(ctx, query, id).Scan(
-		&podcast.Id,
-		&podcast.Title,
-		&podcast.Platform,
-		&podcast.Url,
-		&podcast.Host,
-		&podcast.Program,
-		pq.Array(&podcast.GuestSpeakers),
-		&podcast.Year,
-		&podcast.Language,
-		pq.Array(&podcast.Tags),
-		&podcast.CreatedAt,
-	); err != nil {
+	if err := pm.Db.QueryRowContext(ctx, query, id).Scan(podcastFields(&podcast)...); err != nil {
+		return nil, err
+	}
+
+	return &podcast, nil
+}
+
+// MaxBatchSize is the most podcasts FindByIds looks up at once.
+const MaxBatchSize = 100
+
+// FindByIds returns the podcasts with the given ids in the order the ids are
+// given. Ids no podcast has are left out.
+func (pm PodcastModel) FindByIds(ids []int64) ([]*Podcast, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT ` + podcastColumns + `
+		FROM podcasts
+		WHERE id = ANY($1)
+		ORDER BY array_position($1, id)
+	`
+
+	rows, err := pm.Db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	podcasts := []*Podcast{}
+	for rows.Next() {
+		var podcast Podcast
+		if err := rows.Scan(podcastFields(&podcast)...); err != nil {
+			return nil, err
+		}
+		podcasts = append(podcasts, &podcast)
+	}
+
+	return podcasts, rows.Err()
+}
+
+func (pm PodcastModel) FindByUrl(url string) (*Podcast, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT ` + podcastColumns + `
+		FROM podcasts
+		WHERE url = $1
+		ORDER BY id
+		LIMIT 1
+	`
+
+	var podcast Podcast
+	if err := pm.Db.QueryRowContext(ctx, query, url).Scan(podcastFields(&podcast)...); err != nil {
 		return nil, err
 	}
 
@@ -126,26 +485,63 @@ func (pm PodcastModel) UpdatePodcast(podcast *Podcast) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := pm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = updatePodcast(ctx, tx, podcast); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// updatePodcast saves the podcast, its platform links and, if the title
+// changed, a new slug within tx.
+func updatePodcast(ctx context.Context, tx *sql.Tx, podcast *Podcast) error {
+
 	query := `
 		UPDATE podcasts
-		SET title = $1, platform = $2, url = $3, host = $4, program = $5, guest_speakers = $6, year = $7, language = $8, tags = $9
+		SET title = $1, platform = $2, url = $3, host_id = $4, program_id = $5, guest_speakers = $6, year = $7, languages = $8, tags = $9, updated_at = NOW(),
+			updated_by = $11, description = $12,
+			explicit = $13, advisories = $14, audience = $15, country = $16,
+			link_status = CASE WHEN url = $3 THEN link_status ELSE 'unknown' END,
+			link_status_code = CASE WHEN url = $3 THEN link_status_code ELSE 0 END,
+			link_checked_at = CASE WHEN url = $3 THEN link_checked_at ELSE NULL END
 		WHERE id = $10
-		RETURNING id, created_at
+		RETURNING id, public_id, slug, created_at, updated_at, link_status, link_checked_at
 	`
 	args := []any{
 		podcast.Title,
 		podcast.Platform,
 		podcast.Url,
-		podcast.Host,
-		podcast.Program,
+		podcast.HostId,
+		podcast.ProgramId,
 		pq.Array(podcast.GuestSpeakers),
 		podcast.Year,
-		podcast.Language,
+		pq.Array(podcast.Languages),
 		pq.Array(podcast.Tags),
 		podcast.Id,
+		podcast.UpdatedBy,
+		podcast.Description,
+		podcast.Explicit,
+		pq.Array(podcast.Advisories),
+		podcast.Audience,
+		podcast.Country,
+	}
+
+	err := tx.QueryRowContext(ctx, query, args...).Scan(&podcast.Id, &podcast.PublicId, &podcast.Slug, &podcast.CreatedAt, &podcast.UpdatedAt, &podcast.LinkStatus, &podcast.LinkCheckedAt)
+	if err != nil {
+		return err
 	}
 
-	return pm.Db.QueryRowContext(ctx, query, args...).Scan(&podcast.Id, &podcast.CreatedAt)
+	if err := renamePodcastSlug(ctx, tx, podcast); err != nil {
+		return err
+	}
+
+	return savePodcastPlatforms(ctx, tx, podcast)
 }
 
 func (pm PodcastModel) GetPodcasts() ([]*Podcast, error) {
@@ -169,43 +565,34 @@ func (pm PodcastModel) DeleteById(id int64) error {
 	return nil
 }
 
-func (pm PodcastModel) GetAll(platform string, tags []string, filters Filters) (*[]Podcast, Metadata, error) {
+func (pm PodcastModel) GetAll(podcastFilters PodcastFilters, filters Filters) (*[]Podcast, Metadata, error) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	query := `
-		SELECT id, title, platform, url, host, program, guest_speakers, year, language, tags, created_at
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), %s
 		FROM podcasts
-		WHERE (to_tsvector('simple', platform) @@ plainto_tsquery('simple', $1) OR $1 = '')
-		OR (tags @> $2 OR $2 = '{}')
-		ORDER BY id
-		LIMIT $3 OFFSET $4
-	`
+		WHERE %s
+		AND %s
+		ORDER BY %s %s, id ASC
+		LIMIT $11 OFFSET $12
+	`, podcastColumns, podcastFiltersWhere, safeModeWhere("$10", "podcasts"), filters.sortColumn(), filters.sortDirection())
+
+	args := append(podcastFilters.args(), filters.SafeMode, filters.Limit(), filters.Offset())
 
-	rows, err := pm.Db.QueryContext(ctx, query, platform, pq.Array(tags), filters.Limit(), filters.Offset())
+	rows, err := pm.Db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
 	defer rows.Close()
 
+	totalRecords := 0
 	podcasts := []Podcast{}
 
 	for rows.Next() {
 		var podcast Podcast
-		if err := rows.Scan(
-			&podcast.Id,
-			&podcast.Title,
-			&podcast.Platform,
-			&podcast.Url,
-			&podcast.Host,
-			&podcast.Program,
-			pq.Array(&podcast.GuestSpeakers),
-			&podcast.Year,
-			&podcast.Language,
-			pq.Array(&podcast.Tags),
-			&podcast.CreatedAt,
-		); err != nil {
+		if err := rows.Scan(append([]any{&totalRecords}, podcastFields(&podcast)...)...); err != nil {
 			return nil, Metadata{}, err
 		}
 		podcasts = append(podcasts, podcast)
@@ -215,7 +602,112 @@ func (pm PodcastModel) GetAll(platform string, tags []string, filters Filters) (
 		return nil, Metadata{}, err
 	}
 
-	metadata := calculateMetadata(len(podcasts), filters.Page, filters.PageSize)
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
 
 	return &podcasts, metadata, nil
 }
+
+// Stream calls fn with every podcast GetAll would match across all its pages,
+// in the same order, stopping at the first error fn returns.
+func (pm PodcastModel) Stream(podcastFilters PodcastFilters, filters Filters, fn func(*Podcast) error) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM podcasts
+		WHERE %s
+		AND %s
+		ORDER BY %s %s, id ASC
+	`, podcastColumns, podcastFiltersWhere, safeModeWhere("$10", "podcasts"), filters.sortColumn(), filters.sortDirection())
+
+	args := append(podcastFilters.args(), filters.SafeMode)
+
+	rows, err := pm.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var podcast Podcast
+		if err := rows.Scan(podcastFields(&podcast)...); err != nil {
+			return err
+		}
+		if err := fn(&podcast); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// PodcastLink is the url of a podcast due for a link check.
+type PodcastLink struct {
+	PodcastId int64
+	Url       string
+}
+
+// CatalogChangedAt returns when anything the podcast feeds publish last changed:
+// a podcast added, edited, hidden, merged or deleted, or one of its hosts,
+// programs or translations edited. Triggers keep it up to date.
+func (pm PodcastModel) CatalogChangedAt() (time.Time, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var changedAt time.Time
+	err := pm.Db.QueryRowContext(ctx, `SELECT changed_at FROM catalog_changes`).Scan(&changedAt)
+
+	return changedAt, err
+}
+
+// GetForLinkCheck returns up to limit podcasts whose link has not been checked
+// within interval, never-checked first.
+func (pm PodcastModel) GetForLinkCheck(interval time.Duration, limit int) ([]PodcastLink, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, url
+		FROM podcasts
+		WHERE link_checked_at IS NULL OR link_checked_at < $1
+		ORDER BY link_checked_at NULLS FIRST, id
+		LIMIT $2
+	`
+
+	rows, err := pm.Db.QueryContext(ctx, query, time.Now().Add(-interval), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []PodcastLink{}
+
+	for rows.Next() {
+		var link PodcastLink
+		if err := rows.Scan(&link.PodcastId, &link.Url); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+func (pm PodcastModel) UpdateLinkStatus(id int64, status string, statusCode int) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE podcasts
+		SET link_status = $1, link_status_code = $2, link_checked_at = NOW()
+		WHERE id = $3
+	`
+
+	_, err := pm.Db.ExecContext(ctx, query, status, statusCode, id)
+	return err
+}
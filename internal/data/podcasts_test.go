@@ -0,0 +1,24 @@
+package data
+
+import "testing"
+
+func TestHiddenInSafeMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		podcast Podcast
+		want    bool
+	}{
+		{"general", Podcast{Audience: AudienceGeneral}, false},
+		{"kids", Podcast{Audience: AudienceKids}, false},
+		{"teens", Podcast{Audience: AudienceTeens}, false},
+		{"adults", Podcast{Audience: AudienceAdults}, true},
+		{"explicit", Podcast{Explicit: true, Audience: AudienceGeneral}, true},
+		{"advisories only", Podcast{Advisories: []string{"language", "violence"}, Audience: AudienceTeens}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.podcast.HiddenInSafeMode(); got != tt.want {
+			t.Errorf("%s: HiddenInSafeMode() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+var (
+	ErrDuplicateProgram = errors.New("duplicate program")
+	ErrProgramInUse     = errors.New("program in use")
+)
+
+// Program is the show or network a podcast is published under.
+type Program struct {
+	Id          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Url         string    `json:"url"`
+	Description string    `json:"description"`
+	Podcasts    int       `json:"podcasts"`
+	CreatedAt   time.Time `json:"created_at"`
+	Version     int       `json:"version"`
+}
+
+type ProgramModel struct {
+	Db *sql.DB
+}
+
+type IProgram interface {
+	Insert(*Program) error
+	FindById(int64) (*Program, error)
+	FindOrCreate(string) (*Program, error)
+	GetAll(string, Filters) ([]*Program, Metadata, error)
+	Update(*Program) error
+	DeleteById(int64) error
+}
+
+func NewProgramModel(db *sql.DB) IProgram {
+	return &ProgramModel{Db: db}
+}
+
+func ValidateProgram(v *validator.Validator, program *Program) {
+	v.Check(program.Name != "", "name", "must be provided")
+	v.Check(len(program.Name) <= 500, "name", "must not be more than 500 bytes long")
+	v.Check(len(program.Description) <= 5000, "description", "must not be more than 5000 bytes long")
+	if program.Url != "" {
+		_, err := CanonicalURL(program.Url)
+		v.Check(err == nil, "url", "must be an absolute http or https url")
+	}
+}
+
+const programColumns = `programs.id, programs.name, programs.url, programs.description,
+	(SELECT count(*) FROM podcasts WHERE podcasts.program_id = programs.id) AS podcasts,
+	programs.created_at, programs.version`
+
+func programFields(program *Program) []any {
+	return []any{
+		&program.Id,
+		&program.Name,
+		&program.Url,
+		&program.Description,
+		&program.Podcasts,
+		&program.CreatedAt,
+		&program.Version,
+	}
+}
+
+func (pm ProgramModel) Insert(program *Program) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO programs (name, url, description)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, version
+	`
+
+	args := []any{program.Name, program.Url, program.Description}
+
+	err := pm.Db.QueryRowContext(ctx, query, args...).Scan(&program.Id, &program.CreatedAt, &program.Version)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return ErrDuplicateProgram
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (pm ProgramModel) FindById(id int64) (*Program, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + programColumns + ` FROM programs WHERE id = $1`
+
+	var program Program
+	if err := pm.Db.QueryRowContext(ctx, query, id).Scan(programFields(&program)...); err != nil {
+		return nil, err
+	}
+
+	return &program, nil
+}
+
+// FindOrCreate returns the program called name, ignoring case, creating it if
+// there is none.
+func (pm ProgramModel) FindOrCreate(name string) (*Program, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	name = strings.TrimSpace(name)
+
+	_, err := pm.Db.ExecContext(ctx, `INSERT INTO programs (name) VALUES ($1) ON CONFLICT DO NOTHING`, name)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + programColumns + ` FROM programs WHERE lower(name) = lower($1)`
+
+	var program Program
+	if err := pm.Db.QueryRowContext(ctx, query, name).Scan(programFields(&program)...); err != nil {
+		return nil, err
+	}
+
+	return &program, nil
+}
+
+// GetAll lists programs whose name contains name, or all programs when name is
+// empty.
+func (pm ProgramModel) GetAll(name string, filters Filters) ([]*Program, Metadata, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), %s
+		FROM programs
+		WHERE ($1 = '' OR strpos(lower(name), lower($1)) > 0)
+		ORDER BY %s %s, id ASC
+		LIMIT $2 OFFSET $3
+	`, programColumns, filters.sortColumn(), filters.sortDirection())
+
+	rows, err := pm.Db.QueryContext(ctx, query, strings.TrimSpace(name), filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	programs := []*Program{}
+
+	for rows.Next() {
+		var program Program
+		if err := rows.Scan(append([]any{&totalRecords}, programFields(&program)...)...); err != nil {
+			return nil, Metadata{}, err
+		}
+		programs = append(programs, &program)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return programs, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Update saves program if it is still at the version that was read. Every
+// podcast linked to the program shows the new name.
+func (pm ProgramModel) Update(program *Program) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		UPDATE programs
+		SET name = $1, url = $2, description = $3, version = version + 1
+		WHERE id = $4 AND version = $5
+		RETURNING version
+	`
+
+	args := []any{program.Name, program.Url, program.Description, program.Id, program.Version}
+
+	err := pm.Db.QueryRowContext(ctx, query, args...).Scan(&program.Version)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return ErrDuplicateProgram
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (pm ProgramModel) DeleteById(id int64) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := pm.Db.ExecContext(ctx, `DELETE FROM programs WHERE id = $1`, id)
+	if err != nil {
+		switch {
+		case isForeignKeyViolation(err):
+			return ErrProgramInUse
+		default:
+			return err
+		}
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+const (
+	ReportIncorrect = "incorrect"
+	ReportSpam      = "spam"
+	ReportOffensive = "offensive"
+	ReportDuplicate = "duplicate"
+	ReportCopyright = "copyright"
+	ReportOther     = "other"
+)
+
+var ReportReasons = []string{ReportIncorrect, ReportSpam, ReportOffensive, ReportDuplicate, ReportCopyright, ReportOther}
+
+const (
+	ReportOpen      = "open"
+	ReportUpheld    = "upheld"
+	ReportDismissed = "dismissed"
+)
+
+var (
+	ErrDuplicateReport = errors.New("duplicate report")
+	ErrReportResolved  = errors.New("report has already been resolved")
+)
+
+// Report flags a podcast entry as wrong, spam or otherwise unfit for the
+// public catalog.
+type Report struct {
+	Id                int64      `json:"id"`
+	PodcastId         int64      `json:"podcast_id"`
+	PodcastTitle      string     `json:"podcast_title"`
+	UserId            int64      `json:"user_id"`
+	UserName          string     `json:"user_name"`
+	Reason            string     `json:"reason"`
+	Details           string     `json:"details"`
+	Status            string     `json:"status"`
+	ResolverId        *int64     `json:"resolver_id"`
+	ResolutionComment string     `json:"resolution_comment"`
+	CreatedAt         time.Time  `json:"created_at"`
+	ResolvedAt        *time.Time `json:"resolved_at"`
+}
+
+type ReportFilters struct {
+	Status    string `form:"status"`
+	Reason    string `form:"reason"`
+	PodcastId int64  `form:"podcast_id"`
+}
+
+type ReportModel struct {
+	Db *sql.DB
+}
+
+type IReport interface {
+	Insert(report *Report, hideThreshold int) (hidden bool, err error)
+	FindById(int64) (*Report, error)
+	GetAll(ReportFilters, Filters) ([]*Report, Metadata, error)
+	Resolve(report *Report, status string, resolverId int64, comment string) error
+}
+
+func NewReportModel(db *sql.DB) IReport {
+	return &ReportModel{Db: db}
+}
+
+func ValidateReport(v *validator.Validator, report *Report) {
+	v.Check(validator.PermitedValues(report.Reason, ReportReasons...), "reason", "must be one of incorrect, spam, offensive, duplicate, copyright or other")
+	v.Check(report.Reason != ReportOther || report.Details != "", "details", "must be provided when the reason is other")
+	v.Check(len(report.Details) <= 2000, "details", "must not be more than 2000 bytes long")
+}
+
+func ValidateReportFilters(v *validator.Validator, f ReportFilters) {
+	v.Check(f.Status == "" || validator.PermitedValues(f.Status, ReportOpen, ReportUpheld, ReportDismissed), "status", "must be one of open, upheld or dismissed")
+	v.Check(f.Reason == "" || validator.PermitedValues(f.Reason, ReportReasons...), "reason", "must be one of incorrect, spam, offensive, duplicate, copyright or other")
+}
+
+const reportColumns = `reports.id, reports.podcast_id, podcasts.title, reports.user_id, users.name, reports.reason,
+	reports.details, reports.status, reports.resolver_id, reports.resolution_comment, reports.created_at, reports.resolved_at`
+
+const reportTables = `reports
+	JOIN podcasts ON podcasts.id = reports.podcast_id
+	JOIN users ON users.id = reports.user_id`
+
+func reportFields(report *Report) []any {
+	return []any{
+		&report.Id,
+		&report.PodcastId,
+		&report.PodcastTitle,
+		&report.UserId,
+		&report.UserName,
+		&report.Reason,
+		&report.Details,
+		&report.Status,
+		&report.ResolverId,
+		&report.ResolutionComment,
+		&report.CreatedAt,
+		&report.ResolvedAt,
+	}
+}
+
+// Insert files the report and hides the podcast once hideThreshold users have
+// open reports against it. A threshold of zero never hides. hidden reports
+// whether the podcast is hidden after the report.
+func (rm ReportModel) Insert(report *Report, hideThreshold int) (bool, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := rm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO reports (podcast_id, user_id, reason, details)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, created_at
+	`
+
+	args := []any{report.PodcastId, report.UserId, report.Reason, report.Details}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&report.Id, &report.Status, &report.CreatedAt)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return false, ErrDuplicateReport
+		default:
+			return false, err
+		}
+	}
+
+	query = `
+		UPDATE podcasts
+		SET hidden = hidden OR ($2 > 0 AND (
+			SELECT count(*) FROM reports WHERE podcast_id = $1 AND status = 'open'
+		) >= $2)
+		WHERE id = $1
+		RETURNING hidden
+	`
+
+	var hidden bool
+	if err := tx.QueryRowContext(ctx, query, report.PodcastId, hideThreshold).Scan(&hidden); err != nil {
+		return false, err
+	}
+
+	return hidden, tx.Commit()
+}
+
+func (rm ReportModel) FindById(id int64) (*Report, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + reportColumns + ` FROM ` + reportTables + ` WHERE reports.id = $1`
+
+	var report Report
+	if err := rm.Db.QueryRowContext(ctx, query, id).Scan(reportFields(&report)...); err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+func (rm ReportModel) GetAll(reportFilters ReportFilters, filters Filters) ([]*Report, Metadata, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT count(*) OVER(), ` + reportColumns + `
+		FROM ` + reportTables + `
+		WHERE ($1 = '' OR reports.status = $1)
+		AND ($2 = '' OR reports.reason = $2)
+		AND ($3 = 0 OR reports.podcast_id = $3)
+		ORDER BY reports.` + filters.sortColumn() + ` ` + filters.sortDirection() + `, reports.id
+		LIMIT $4 OFFSET $5
+	`
+
+	args := []any{reportFilters.Status, reportFilters.Reason, reportFilters.PodcastId, filters.Limit(), filters.Offset()}
+
+	rows, err := rm.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	reports := []*Report{}
+
+	for rows.Next() {
+		var report Report
+		if err := rows.Scan(append([]any{&totalRecords}, reportFields(&report)...)...); err != nil {
+			return nil, Metadata{}, err
+		}
+		reports = append(reports, &report)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return reports, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Resolve closes every open report against the report's podcast with the same
+// outcome, since hiding is decided per podcast. Upholding hides the podcast;
+// dismissing shows it again. It returns ErrReportResolved when the report was
+// already closed.
+func (rm ReportModel) Resolve(report *Report, status string, resolverId int64, comment string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := rm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current string
+	if err := tx.QueryRowContext(ctx, `SELECT status FROM reports WHERE id = $1 FOR UPDATE`, report.Id).Scan(&current); err != nil {
+		return err
+	}
+	if current != ReportOpen {
+		return ErrReportResolved
+	}
+
+	query := `
+		UPDATE reports
+		SET status = $1, resolver_id = $2, resolution_comment = $3, resolved_at = NOW()
+		WHERE podcast_id = $4 AND status = 'open'
+	`
+
+	if _, err := tx.ExecContext(ctx, query, status, resolverId, comment, report.PodcastId); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE podcasts SET hidden = $1 WHERE id = $2`, status == ReportUpheld, report.PodcastId); err != nil {
+		return err
+	}
+
+	query = `SELECT ` + reportColumns + ` FROM ` + reportTables + ` WHERE reports.id = $1`
+
+	if err := tx.QueryRowContext(ctx, query, report.Id).Scan(reportFields(report)...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
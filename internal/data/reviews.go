@@ -0,0 +1,265 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+// RatingPriorVotes is how many votes at the catalog-wide average every podcast
+// is assumed to have when ranking by rating, so that a handful of votes cannot
+// put a show at the top or bottom of the list.
+const RatingPriorVotes = 10
+
+var ErrDuplicateReview = errors.New("duplicate review")
+
+type Review struct {
+	Id        int64     `json:"id"`
+	UserId    int64     `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	PodcastId int64     `json:"podcast_id"`
+	Score     int       `json:"score"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Version   int       `json:"version"`
+}
+
+type ReviewModel struct {
+	Db *sql.DB
+}
+
+type IReview interface {
+	Insert(*Review) error
+	Find(userId, podcastId int64) (*Review, error)
+	GetAllForPodcast(int64, Filters) ([]*Review, Metadata, error)
+	Update(*Review) error
+	Delete(userId, podcastId int64) error
+}
+
+func NewReviewModel(db *sql.DB) IReview {
+	return &ReviewModel{Db: db}
+}
+
+func ValidateReview(v *validator.Validator, review *Review) {
+	v.Check(review.Score >= 1 && review.Score <= 10, "score", "must be between 1 and 10")
+	v.Check(len(review.Body) <= 10_000, "body", "must not be more than 10000 bytes long")
+}
+
+const reviewColumns = `reviews.id, reviews.user_id, users.name, reviews.podcast_id, reviews.score, reviews.body,
+	reviews.created_at, reviews.updated_at, reviews.version`
+
+func reviewFields(review *Review) []any {
+	return []any{
+		&review.Id,
+		&review.UserId,
+		&review.UserName,
+		&review.PodcastId,
+		&review.Score,
+		&review.Body,
+		&review.CreatedAt,
+		&review.UpdatedAt,
+		&review.Version,
+	}
+}
+
+// lockRating locks the podcast's row until tx ends, so that reviews of the
+// same podcast written at once update its rating one after another, each
+// counting the other's review.
+func lockRating(ctx context.Context, tx *sql.Tx, podcastId int64) error {
+	var id int64
+	return tx.QueryRowContext(ctx, `SELECT id FROM podcasts WHERE id = $1 FOR UPDATE`, podcastId).Scan(&id)
+}
+
+// updateRating recomputes the rating aggregates stored on the podcast, whose
+// row tx must already have locked.
+func updateRating(ctx context.Context, tx *sql.Tx, podcastId int64) error {
+
+	query := `
+		UPDATE podcasts
+		SET rating_count = r.count, rating_average = r.average
+		FROM (SELECT count(*) AS count, COALESCE(avg(score), 0) AS average FROM reviews WHERE podcast_id = $1) r
+		WHERE podcasts.id = $1
+	`
+
+	_, err := tx.ExecContext(ctx, query, podcastId)
+	return err
+}
+
+func (rm ReviewModel) Insert(review *Review) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := rm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockRating(ctx, tx, review.PodcastId); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO reviews (user_id, podcast_id, score, body)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at, version
+	`
+
+	args := []any{review.UserId, review.PodcastId, review.Score, review.Body}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&review.Id, &review.CreatedAt, &review.UpdatedAt, &review.Version)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return ErrDuplicateReview
+		default:
+			return err
+		}
+	}
+
+	if err := updateRating(ctx, tx, review.PodcastId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Find returns the review userId wrote for podcastId.
+func (rm ReviewModel) Find(userId, podcastId int64) (*Review, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT ` + reviewColumns + `
+		FROM reviews JOIN users ON users.id = reviews.user_id
+		WHERE reviews.user_id = $1 AND reviews.podcast_id = $2
+	`
+
+	var review Review
+	if err := rm.Db.QueryRowContext(ctx, query, userId, podcastId).Scan(reviewFields(&review)...); err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+func (rm ReviewModel) GetAllForPodcast(podcastId int64, filters Filters) ([]*Review, Metadata, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT count(*) OVER(), ` + reviewColumns + `
+		FROM reviews JOIN users ON users.id = reviews.user_id
+		WHERE reviews.podcast_id = $1
+		ORDER BY reviews.` + filters.sortColumn() + ` ` + filters.sortDirection() + `, reviews.id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := rm.Db.QueryContext(ctx, query, podcastId, filters.Limit(), filters.Offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	reviews := []*Review{}
+
+	for rows.Next() {
+		var review Review
+		if err := rows.Scan(append([]any{&totalRecords}, reviewFields(&review)...)...); err != nil {
+			return nil, Metadata{}, err
+		}
+		reviews = append(reviews, &review)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return reviews, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// Update saves review if it is still at the version that was read.
+func (rm ReviewModel) Update(review *Review) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := rm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockRating(ctx, tx, review.PodcastId); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE reviews
+		SET score = $1, body = $2, updated_at = NOW(), version = version + 1
+		WHERE id = $3 AND version = $4
+		RETURNING updated_at, version
+	`
+
+	args := []any{review.Score, review.Body, review.Id, review.Version}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&review.UpdatedAt, &review.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	if err := updateRating(ctx, tx, review.PodcastId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (rm ReviewModel) Delete(userId, podcastId int64) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := rm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockRating(ctx, tx, podcastId); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM reviews WHERE user_id = $1 AND podcast_id = $2`, userId, podcastId)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := updateRating(ctx, tx, podcastId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,206 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Weights of the features two podcasts can share. A shared host says more
+// about two shows than a shared tag does.
+const (
+	similarityTagWeight     = 1.0
+	similarityGuestWeight   = 2.0
+	similarityHostWeight    = 3.0
+	similarityProgramWeight = 1.5
+)
+
+// similarityMaxFrequency skips features carried by so many podcasts that they
+// say nothing about any pair, and would make comparing every pair that shares
+// them too expensive.
+const similarityMaxFrequency = 1000
+
+// Similar is a podcast ranked by how well it matches another podcast, or a
+// user's taste.
+type Similar struct {
+	Score   float64 `json:"score"`
+	Podcast Podcast `json:"podcast"`
+}
+
+type SimilarityModel struct {
+	Db *sql.DB
+}
+
+type ISimilarity interface {
+	Recompute(neighbours int) (int64, error)
+	GetSimilar(int64, Filters) ([]*Similar, Metadata, error)
+	Recommend(int64, Filters) ([]*Similar, Metadata, error)
+}
+
+func NewSimilarityModel(db *sql.DB) ISimilarity {
+	return &SimilarityModel{Db: db}
+}
+
+// Recompute replaces the stored similarities with the weighted Jaccard index
+// of every pair of podcasts sharing tags, guests, a host or a program, keeping
+// the best neighbours of each podcast. Each feature is weighted by its kind and
+// by how rare it is, so sharing an uncommon tag counts for more than sharing a
+// popular one. It returns how many pairs were stored.
+func (sm SimilarityModel) Recompute(neighbours int) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	tx, err := sm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM podcast_similarities`); err != nil {
+		return 0, err
+	}
+
+	query := `
+		WITH features AS (
+			SELECT id AS podcast_id, 'tag:' || t AS feature, $1::float8 AS weight FROM podcasts, unnest(tags) t
+			UNION
+			SELECT id, 'guest:' || lower(g), $2::float8 FROM podcasts, unnest(guest_speakers) g
+			UNION
+			SELECT id, 'host:' || host_id, $3::float8 FROM podcasts
+			UNION
+			SELECT id, 'program:' || program_id, $4::float8 FROM podcasts
+		),
+		counted AS (
+			SELECT podcast_id, feature, weight, count(*) OVER (PARTITION BY feature) AS frequency
+			FROM features
+		),
+		weighted AS (
+			SELECT podcast_id, feature, frequency,
+				weight * ln(1 + (SELECT count(*) FROM podcasts)::float8 / frequency) AS weight
+			FROM counted
+		),
+		totals AS (
+			SELECT podcast_id, sum(weight) AS total FROM weighted GROUP BY podcast_id
+		),
+		shared AS (
+			SELECT a.podcast_id, b.podcast_id AS similar_id, sum(a.weight) AS shared
+			FROM weighted a JOIN weighted b ON b.feature = a.feature AND b.podcast_id <> a.podcast_id
+			WHERE a.frequency <= $5
+			GROUP BY a.podcast_id, b.podcast_id
+		),
+		scored AS (
+			SELECT s.podcast_id, s.similar_id, s.shared / (ta.total + tb.total - s.shared) AS score
+			FROM shared s
+			JOIN totals ta ON ta.podcast_id = s.podcast_id
+			JOIN totals tb ON tb.podcast_id = s.similar_id
+		),
+		ranked AS (
+			SELECT *, row_number() OVER (PARTITION BY podcast_id ORDER BY score DESC, similar_id) AS rank
+			FROM scored
+		)
+		INSERT INTO podcast_similarities (podcast_id, similar_id, score)
+		SELECT podcast_id, similar_id, score FROM ranked WHERE rank <= $6
+	`
+
+	args := []any{
+		similarityTagWeight,
+		similarityGuestWeight,
+		similarityHostWeight,
+		similarityProgramWeight,
+		similarityMaxFrequency,
+		neighbours,
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	pairs, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return pairs, tx.Commit()
+}
+
+// GetSimilar returns the podcasts most similar to podcastId, best first.
+func (sm SimilarityModel) GetSimilar(podcastId int64, filters Filters) ([]*Similar, Metadata, error) {
+
+	query := `
+		SELECT count(*) OVER(), s.score, ` + podcastColumnsFor("p") + `
+		FROM podcast_similarities s JOIN podcasts p ON p.id = s.similar_id
+		WHERE s.podcast_id = $1
+		AND NOT p.hidden
+		AND ` + safeModeWhere("$4", "p") + `
+		ORDER BY s.score DESC, p.id
+		LIMIT $2 OFFSET $3
+	`
+
+	return sm.getRanked(query, podcastId, filters)
+}
+
+// Recommend ranks podcasts by their similarity to the ones the user favorited
+// or reviewed. Favorites count fully, and reviews pull towards or away from
+// similar shows depending on the score given. Podcasts the user already
+// favorited or reviewed are left out.
+func (sm SimilarityModel) Recommend(userId int64, filters Filters) ([]*Similar, Metadata, error) {
+
+	query := `
+		WITH seeds AS (
+			SELECT li.podcast_id, 1.0 AS weight
+			FROM list_items li JOIN lists l ON l.id = li.list_id
+			WHERE l.user_id = $1 AND l.kind = 'favorites'
+			UNION ALL
+			SELECT podcast_id, (score - 5.5) / 4.5 FROM reviews WHERE user_id = $1
+		),
+		seed_weights AS (
+			SELECT podcast_id, sum(weight) AS weight FROM seeds GROUP BY podcast_id
+		),
+		ranked AS (
+			SELECT s.similar_id, sum(w.weight * s.score) AS score
+			FROM seed_weights w JOIN podcast_similarities s ON s.podcast_id = w.podcast_id
+			WHERE s.similar_id NOT IN (SELECT podcast_id FROM seeds)
+			GROUP BY s.similar_id
+			HAVING sum(w.weight * s.score) > 0
+		)
+		SELECT count(*) OVER(), r.score, ` + podcastColumnsFor("p") + `
+		FROM ranked r JOIN podcasts p ON p.id = r.similar_id
+		WHERE NOT p.hidden
+		AND ` + safeModeWhere("$4", "p") + `
+		ORDER BY r.score DESC, p.id
+		LIMIT $2 OFFSET $3
+	`
+
+	return sm.getRanked(query, userId, filters)
+}
+
+func (sm SimilarityModel) getRanked(query string, id int64, filters Filters) ([]*Similar, Metadata, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := sm.Db.QueryContext(ctx, query, id, filters.Limit(), filters.Offset(), filters.SafeMode)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	similar := []*Similar{}
+
+	for rows.Next() {
+		var s Similar
+		if err := rows.Scan(append([]any{&totalRecords, &s.Score}, podcastFields(&s.Podcast)...)...); err != nil {
+			return nil, Metadata{}, err
+		}
+		similar = append(similar, &s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return similar, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
@@ -0,0 +1,225 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// maxSlugLength bounds slugs in runes; long titles are cut at a word.
+const maxSlugLength = 80
+
+// reservedPodcastSlugs are taken by routes under /v1/podcasts, so a podcast
+// using one could never be reached by it.
+var reservedPodcastSlugs = map[string]bool{
+	"duplicates": true,
+	"import":     true,
+	"export":     true,
+	"batch-get":  true,
+}
+
+// PodcastRef is what a podcast is addressed by in urls.
+type PodcastRef struct {
+	Id       int64
+	PublicId string
+	Slug     string
+}
+
+// PodcastSlug returns the slug a podcast with the given title would prefer,
+// before any suffix added to keep it unique. Slugs never consist only of
+// digits, so they cannot be mistaken for ids.
+func PodcastSlug(title string) string {
+	slug := Slugify(title)
+
+	if runes := []rune(slug); len(runes) > maxSlugLength {
+		slug = strings.TrimRight(string(runes[:maxSlugLength]), "-")
+	}
+
+	switch {
+	case slug == "":
+		return "podcast"
+	case isDigits(slug):
+		return "podcast-" + slug
+	case reservedPodcastSlugs[slug]:
+		return slug + "-podcast"
+	}
+
+	return slug
+}
+
+func isDigits(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 64)
+	return err == nil
+}
+
+// hasSlugBase reports whether slug is base, or base with a numeric suffix
+// added to keep it unique.
+func hasSlugBase(slug, base string) bool {
+	if slug == base {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(slug, base+"-")
+	return ok && isDigits(suffix)
+}
+
+// slugStem strips the numeric suffixes pickSlug adds from slug. Every slug
+// pickSlug could choose for a base has the base's stem.
+func slugStem(slug string) string {
+	for {
+		i := strings.LastIndexByte(slug, '-')
+		if i <= 0 || !isDigits(slug[i+1:]) {
+			return slug
+		}
+		slug = slug[:i]
+	}
+}
+
+// pickSlug returns the first of base, base-2, base-3 and so on that no podcast
+// other than podcastId has ever used. Slugs are never handed to another
+// podcast, so old links keep leading where they did.
+func pickSlug(ctx context.Context, tx *sql.Tx, base string, podcastId int64) (string, error) {
+	// Two transactions picking from the same stem would both see the same slug
+	// free, so the second waits for the first to commit before it looks.
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('podcast_slugs'), hashtext($1))`, slugStem(base)); err != nil {
+		return "", err
+	}
+
+	query := `
+		SELECT slug, podcast_id
+		FROM podcast_slugs
+		WHERE slug = $1 OR slug LIKE $1 || '-%'
+	`
+
+	rows, err := tx.QueryContext(ctx, query, base)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	owners := map[string]int64{}
+	for rows.Next() {
+		var (
+			slug  string
+			owner int64
+		)
+		if err := rows.Scan(&slug, &owner); err != nil {
+			return "", err
+		}
+		owners[slug] = owner
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	return nextSlug(base, owners, podcastId), nil
+}
+
+// nextSlug returns the first of base, base-2, base-3 and so on that owners,
+// which maps slugs to the podcast that used them, does not give to a podcast
+// other than podcastId.
+func nextSlug(base string, owners map[string]int64, podcastId int64) string {
+	slug := base
+	for n := 2; ; n++ {
+		if owner, taken := owners[slug]; !taken || owner == podcastId {
+			return slug
+		}
+		slug = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// renamePodcastSlug gives the podcast a new slug when its title no longer
+// matches the current one. The old slug stays in podcast_slugs, so links to
+// it can be redirected.
+func renamePodcastSlug(ctx context.Context, tx *sql.Tx, podcast *Podcast) error {
+	base := PodcastSlug(podcast.Title)
+	if hasSlugBase(podcast.Slug, base) {
+		return nil
+	}
+
+	slug, err := pickSlug(ctx, tx, base, podcast.Id)
+	if err != nil {
+		return err
+	}
+
+	stmts := []string{
+		`INSERT INTO podcast_slugs (slug, podcast_id) VALUES ($1, $2) ON CONFLICT (slug) DO NOTHING`,
+		`UPDATE podcasts SET slug = $1 WHERE id = $2`,
+	}
+
+	for _, stmt := range stmts {
+		if _, err := tx.ExecContext(ctx, stmt, slug, podcast.Id); err != nil {
+			return err
+		}
+	}
+
+	podcast.Slug = slug
+	return nil
+}
+
+// Resolve finds the podcast key names, as its public id or a current or former
+// slug. Callers tell a former slug by comparing key with the returned Slug.
+func (pm PodcastModel) Resolve(key string) (*PodcastRef, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, public_id, slug
+		FROM podcasts
+		WHERE public_id = $1 OR id = (SELECT podcast_id FROM podcast_slugs WHERE slug = $1)
+		ORDER BY public_id = $1 DESC
+		LIMIT 1
+	`
+
+	var ref PodcastRef
+	if err := pm.Db.QueryRowContext(ctx, query, key).Scan(&ref.Id, &ref.PublicId, &ref.Slug); err != nil {
+		return nil, err
+	}
+
+	return &ref, nil
+}
+
+// ResolveAll is Resolve for many keys at once, mapping each key to the id of
+// the podcast it names. Keys that name no podcast are left out of the map.
+func (pm PodcastModel) ResolveAll(keys []string) (map[string]int64, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT k.key, p.id
+		FROM unnest($1::text[]) AS k(key)
+		JOIN LATERAL (
+			SELECT id
+			FROM podcasts
+			WHERE public_id = k.key OR id = (SELECT podcast_id FROM podcast_slugs WHERE slug = k.key)
+			ORDER BY public_id = k.key DESC
+			LIMIT 1
+		) p ON true
+	`
+
+	rows, err := pm.Db.QueryContext(ctx, query, pq.Array(keys))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int64, len(keys))
+	for rows.Next() {
+		var (
+			key string
+			id  int64
+		)
+		if err := rows.Scan(&key, &id); err != nil {
+			return nil, err
+		}
+		ids[key] = id
+	}
+
+	return ids, rows.Err()
+}
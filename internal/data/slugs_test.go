@@ -0,0 +1,95 @@
+package data
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPodcastSlug(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Ngobrol Santai", "ngobrol-santai"},
+		{"  The Daily: News & Views!  ", "the-daily-news-views"},
+		{"Café Olé", "café-olé"},
+		{"2024", "podcast-2024"},
+		{"!!!", "podcast"},
+		{"", "podcast"},
+		{"Import", "import-podcast"},
+		{"Batch Get", "batch-get-podcast"},
+		{strings.Repeat("a", 90), strings.Repeat("a", 80)},
+		{strings.Repeat("a", 79) + " b", strings.Repeat("a", 79)},
+	}
+
+	for _, tt := range tests {
+		if got := PodcastSlug(tt.title); got != tt.want {
+			t.Errorf("PodcastSlug(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestHasSlugBase(t *testing.T) {
+	tests := []struct {
+		slug string
+		base string
+		want bool
+	}{
+		{"ngobrol", "ngobrol", true},
+		{"ngobrol-2", "ngobrol", true},
+		{"ngobrol-12", "ngobrol", true},
+		{"ngobrol-santai", "ngobrol", false},
+		{"ngobrol-", "ngobrol", false},
+		{"ngobrol-2", "ngobrol-2", true},
+		{"other", "ngobrol", false},
+	}
+
+	for _, tt := range tests {
+		if got := hasSlugBase(tt.slug, tt.base); got != tt.want {
+			t.Errorf("hasSlugBase(%q, %q) = %v, want %v", tt.slug, tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestSlugStem(t *testing.T) {
+	tests := []struct {
+		slug string
+		want string
+	}{
+		{"ngobrol", "ngobrol"},
+		{"ngobrol-2", "ngobrol"},
+		{"ngobrol-2-3", "ngobrol"},
+		{"ngobrol-santai-2", "ngobrol-santai"},
+		{"podcast-2024", "podcast"},
+		{"2024-show", "2024-show"},
+	}
+
+	for _, tt := range tests {
+		if got := slugStem(tt.slug); got != tt.want {
+			t.Errorf("slugStem(%q) = %q, want %q", tt.slug, got, tt.want)
+		}
+	}
+}
+
+func TestNextSlug(t *testing.T) {
+	owners := map[string]int64{"ngobrol": 1, "ngobrol-2": 2, "ngobrol-4": 4}
+
+	tests := []struct {
+		name      string
+		base      string
+		podcastId int64
+		want      string
+	}{
+		{name: "free", base: "santai", podcastId: 0, want: "santai"},
+		{name: "new podcast", base: "ngobrol", podcastId: 0, want: "ngobrol-3"},
+		{name: "own slug", base: "ngobrol", podcastId: 1, want: "ngobrol"},
+		{name: "own suffixed slug", base: "ngobrol", podcastId: 2, want: "ngobrol-2"},
+		{name: "former slug of another podcast", base: "ngobrol", podcastId: 5, want: "ngobrol-3"},
+	}
+
+	for _, tt := range tests {
+		if got := nextSlug(tt.base, owners, tt.podcastId); got != tt.want {
+			t.Errorf("%s: nextSlug(%q, %d) = %q, want %q", tt.name, tt.base, tt.podcastId, got, tt.want)
+		}
+	}
+}
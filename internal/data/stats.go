@@ -0,0 +1,190 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/terajari/ipdb/internal/language"
+)
+
+// StatsTop caps how many tags, guests and hosts the statistics list.
+const StatsTop = 20
+
+// StatsBucket is how many podcasts share a value. Key is what the value is
+// filtered by, and Name its display name where that differs.
+type StatsBucket struct {
+	Key   string `json:"key"`
+	Name  string `json:"name,omitempty"`
+	Count int    `json:"count"`
+}
+
+// StatsGrowth is how many podcasts were added in a month, and how many there
+// were at its end.
+type StatsGrowth struct {
+	Month string `json:"month"`
+	Added int    `json:"added"`
+	Total int    `json:"total"`
+}
+
+type Stats struct {
+	Total     int           `json:"total"`
+	Platforms []StatsBucket `json:"platforms"`
+	Languages []StatsBucket `json:"languages"`
+	Years     []StatsBucket `json:"years"`
+	Tags      []StatsBucket `json:"tags"`
+	Guests    []StatsBucket `json:"guests"`
+	Hosts     []StatsBucket `json:"hosts"`
+	Growth    []StatsGrowth `json:"growth"`
+}
+
+type StatsModel struct {
+	Db *sql.DB
+}
+
+type IStats interface {
+	Get(PodcastFilters) (*Stats, error)
+}
+
+func NewStatsModel(db *sql.DB) IStats {
+	return &StatsModel{Db: db}
+}
+
+// Get aggregates the podcasts matching podcastFilters. All figures are read
+// from the same snapshot so they add up.
+func (sm StatsModel) Get(podcastFilters PodcastFilters) (*Stats, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := sm.Db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	filtered := `WITH filtered AS (SELECT * FROM podcasts WHERE ` + podcastFiltersWhere + `) `
+	args := podcastFilters.args()
+
+	stats := &Stats{}
+
+	if err := tx.QueryRowContext(ctx, filtered+`SELECT count(*) FROM filtered`, args...).Scan(&stats.Total); err != nil {
+		return nil, err
+	}
+
+	buckets := []struct {
+		dest  *[]StatsBucket
+		top   bool
+		query string
+	}{
+		{&stats.Platforms, false, `
+			SELECT pl.slug, pl.name, count(DISTINCT f.id)
+			FROM filtered f
+			JOIN podcast_platforms pp ON pp.podcast_id = f.id
+			JOIN platforms pl ON pl.id = pp.platform_id
+			GROUP BY pl.slug, pl.name
+			ORDER BY 3 DESC, 1
+		`},
+		{&stats.Languages, false, `
+			SELECT l, '', count(*) FROM filtered, unnest(languages) l
+			GROUP BY l
+			ORDER BY 3 DESC, 1
+		`},
+		{&stats.Years, false, `
+			SELECT year::text, '', count(*) FROM filtered
+			GROUP BY year
+			ORDER BY year
+		`},
+		{&stats.Tags, true, `
+			SELECT t.slug, COALESCE((SELECT name FROM tags WHERE tags.slug = t.slug), ''), count(*)
+			FROM filtered, unnest(filtered.tags) AS t(slug)
+			GROUP BY t.slug
+			ORDER BY 3 DESC, 1
+			LIMIT $10
+		`},
+		{&stats.Guests, true, `
+			SELECT g, '', count(DISTINCT f.id) FROM filtered f, unnest(guest_speakers) g
+			GROUP BY g
+			ORDER BY 3 DESC, 1
+			LIMIT $10
+		`},
+		{&stats.Hosts, true, `
+			SELECT h.id::text, h.name, count(*)
+			FROM filtered f JOIN hosts h ON h.id = f.host_id
+			GROUP BY h.id, h.name
+			ORDER BY 3 DESC, 2
+			LIMIT $10
+		`},
+	}
+
+	for _, b := range buckets {
+		queryArgs := args
+		if b.top {
+			queryArgs = append(args[:len(args):len(args)], StatsTop)
+		}
+
+		*b.dest, err = scanStatsBuckets(ctx, tx, filtered+b.query, queryArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for i, b := range stats.Languages {
+		stats.Languages[i].Name = language.Name(b.Key)
+	}
+
+	stats.Growth, err = scanStatsGrowth(ctx, tx, filtered+`
+		SELECT to_char(month, 'YYYY-MM'), added, sum(added) OVER (ORDER BY month)
+		FROM (
+			SELECT date_trunc('month', created_at) AS month, count(*) AS added
+			FROM filtered
+			GROUP BY 1
+		) m
+		ORDER BY month
+	`, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, tx.Commit()
+}
+
+func scanStatsBuckets(ctx context.Context, tx *sql.Tx, query string, args []any) ([]StatsBucket, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := []StatsBucket{}
+
+	for rows.Next() {
+		var b StatsBucket
+		if err := rows.Scan(&b.Key, &b.Name, &b.Count); err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, b)
+	}
+
+	return buckets, rows.Err()
+}
+
+func scanStatsGrowth(ctx context.Context, tx *sql.Tx, query string, args []any) ([]StatsGrowth, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	growth := []StatsGrowth{}
+
+	for rows.Next() {
+		var g StatsGrowth
+		if err := rows.Scan(&g.Month, &g.Added, &g.Total); err != nil {
+			return nil, err
+		}
+		growth = append(growth, g)
+	}
+
+	return growth, rows.Err()
+}
@@ -0,0 +1,557 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+var (
+	ErrDuplicateTag = errors.New("duplicate tag")
+	ErrTagInUse     = errors.New("tag in use")
+	ErrTagCycle     = errors.New("tag cycle")
+)
+
+type Tag struct {
+	Id         int64     `json:"id"`
+	Slug       string    `json:"slug"`
+	Name       string    `json:"name"`
+	Parent     string    `json:"parent,omitempty"`
+	Synonyms   []string  `json:"synonyms"`
+	UsageCount int64     `json:"usage_count"`
+	TotalCount int64     `json:"total_count"`
+	CreatedAt  time.Time `json:"created_at"`
+	Version    int       `json:"version"`
+}
+
+type TagFilters struct {
+	Q      string `form:"q"`
+	Parent string `form:"parent"`
+}
+
+type TagModel struct {
+	Db *sql.DB
+}
+
+type ITag interface {
+	Insert(*Tag) error
+	FindBySlug(string) (*Tag, error)
+	GetAll(TagFilters, Filters) ([]*Tag, Metadata, error)
+	GetAncestors(int64) ([]*Tag, error)
+	GetChildren(int64) ([]*Tag, error)
+	Update(*Tag, string) error
+	DeleteById(int64) error
+	Canonicalize([]string) ([]string, error)
+	Normalize([]string) ([]string, error)
+}
+
+func NewTagModel(db *sql.DB) ITag {
+	return &TagModel{Db: db}
+}
+
+func ValidateTag(v *validator.Validator, tag *Tag) {
+	v.Check(tag.Name != "", "name", "must be provided")
+	v.Check(len(tag.Name) <= 100, "name", "must not be more than 100 bytes long")
+	v.Check(tag.Slug != "" && Slugify(tag.Slug) == tag.Slug, "slug", "must contain only lowercase letters, digits and single hyphens")
+	v.Check(tag.Parent != tag.Slug, "parent", "must not be the tag itself")
+	v.Check(len(tag.Synonyms) <= 20, "synonyms", "must not contain more than 20 synonyms")
+	v.Check(validator.Unique(tag.Synonyms...), "synonyms", "must not contain duplicate synonyms")
+
+	for _, synonym := range tag.Synonyms {
+		v.Check(Slugify(synonym) != "", "synonyms", "must contain only letters or digits")
+		v.Check(Slugify(synonym) != tag.Slug, "synonyms", "must not contain the tag's own slug")
+	}
+}
+
+// tagColumns selects a tag aliased t, its parent slug, synonyms and the number
+// of podcasts tagged with it directly and with it or any of its descendants.
+const tagColumns = `t.id, t.slug, t.name, COALESCE(p.slug, '') AS parent,
+	ARRAY(SELECT synonym FROM tag_synonyms s WHERE s.tag_id = t.id ORDER BY synonym),
+	(SELECT count(*) FROM podcasts WHERE podcasts.tags @> ARRAY[t.slug]) AS usage_count,
+	(SELECT count(*) FROM podcasts WHERE podcasts.tags && tag_descendants(t.slug)) AS total_count,
+	t.created_at, t.version`
+
+const tagTables = `tags t LEFT JOIN tags p ON p.id = t.parent_id`
+
+func tagFields(tag *Tag) []any {
+	return []any{
+		&tag.Id,
+		&tag.Slug,
+		&tag.Name,
+		&tag.Parent,
+		pq.Array(&tag.Synonyms),
+		&tag.UsageCount,
+		&tag.TotalCount,
+		&tag.CreatedAt,
+		&tag.Version,
+	}
+}
+
+func slugifyAll(names []string) []string {
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		out = append(out, Slugify(name))
+	}
+	return out
+}
+
+// synonymSlugs slugifies synonyms, dropping the ones that collapse into an
+// earlier synonym.
+func synonymSlugs(synonyms []string) []string {
+	out := []string{}
+	for _, slug := range slugifyAll(synonyms) {
+		if slug != "" && !validator.PermitedValues(slug, out...) {
+			out = append(out, slug)
+		}
+	}
+	return out
+}
+
+// isUniqueViolation reports whether err is Postgres rejecting a row for
+// breaking any unique constraint.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func (tm TagModel) Insert(tag *Tag) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tag.Synonyms = synonymSlugs(tag.Synonyms)
+
+	tx, err := tm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var taken bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tag_synonyms WHERE synonym = $1)`, tag.Slug).Scan(&taken)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrDuplicateTag
+	}
+
+	query := `
+		INSERT INTO tags (slug, name, parent_id)
+		VALUES ($1, $2, (SELECT id FROM tags WHERE slug = NULLIF($3, '')))
+		RETURNING id, created_at, version
+	`
+
+	err = tx.QueryRowContext(ctx, query, tag.Slug, tag.Name, tag.Parent).Scan(&tag.Id, &tag.CreatedAt, &tag.Version)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return ErrDuplicateTag
+		default:
+			return err
+		}
+	}
+
+	if err := saveTagSynonyms(ctx, tx, tag); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// saveTagSynonyms replaces the synonyms of tag. A synonym that is itself an
+// existing tag is folded into tag: its children and synonyms move over and the
+// podcasts using it are retagged before it is deleted.
+func saveTagSynonyms(ctx context.Context, tx *sql.Tx, tag *Tag) error {
+	if len(tag.Synonyms) > 0 {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE tags SET parent_id = NULL
+			WHERE id = $1 AND parent_id IN (SELECT id FROM tags WHERE slug = ANY($2))
+		`, tag.Id, pq.Array(tag.Synonyms))
+		if err != nil {
+			return err
+		}
+
+		stmts := []string{
+			`UPDATE tags SET parent_id = $1 WHERE id <> $1 AND parent_id IN (SELECT id FROM tags WHERE slug = ANY($2))`,
+			`UPDATE tag_synonyms SET tag_id = $1 WHERE tag_id IN (SELECT id FROM tags WHERE slug = ANY($2))`,
+			`DELETE FROM tags WHERE id <> $1 AND slug = ANY($2)`,
+		}
+
+		for _, stmt := range stmts {
+			if _, err := tx.ExecContext(ctx, stmt, tag.Id, pq.Array(tag.Synonyms)); err != nil {
+				return err
+			}
+		}
+
+		if err := retagPodcasts(ctx, tx, tag.Synonyms, tag.Slug); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, `DELETE FROM tag_synonyms WHERE tag_id = $1 AND NOT (synonym = ANY($2))`, tag.Id, pq.Array(tag.Synonyms))
+	if err != nil {
+		return err
+	}
+
+	for _, synonym := range tag.Synonyms {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO tag_synonyms (synonym, tag_id) VALUES ($1, $2)
+			ON CONFLICT (synonym) DO UPDATE SET tag_id = EXCLUDED.tag_id
+			WHERE tag_synonyms.tag_id = EXCLUDED.tag_id
+		`, synonym, tag.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	var owned int
+	err = tx.QueryRowContext(ctx, `SELECT count(*) FROM tag_synonyms WHERE tag_id = $1`, tag.Id).Scan(&owned)
+	if err != nil {
+		return err
+	}
+	if owned != len(tag.Synonyms) {
+		return ErrDuplicateTag
+	}
+
+	return nil
+}
+
+// retagPodcasts replaces every tag in from with to on the podcasts that use
+// them, keeping the original order and dropping the duplicates it creates.
+func retagPodcasts(ctx context.Context, tx *sql.Tx, from []string, to string) error {
+
+	query := `
+		UPDATE podcasts
+		SET tags = ARRAY(
+			SELECT tag FROM (
+				SELECT CASE WHEN u.tag = ANY($1) THEN $2 ELSE u.tag END AS tag, min(u.position) AS position
+				FROM unnest(podcasts.tags) WITH ORDINALITY AS u(tag, position)
+				GROUP BY 1
+			) retagged
+			ORDER BY position
+		), updated_at = NOW()
+		WHERE tags && $1
+	`
+
+	_, err := tx.ExecContext(ctx, query, pq.Array(from), to)
+	return err
+}
+
+// FindBySlug returns the tag with the given slug or synonym.
+func (tm TagModel) FindBySlug(slug string) (*Tag, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT ` + tagColumns + `
+		FROM ` + tagTables + `
+		WHERE t.slug = $1 OR t.id = (SELECT tag_id FROM tag_synonyms WHERE synonym = $1)
+	`
+
+	var tag Tag
+	if err := tm.Db.QueryRowContext(ctx, query, Slugify(slug)).Scan(tagFields(&tag)...); err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// GetAll lists tags whose slug, name or synonym starts with filters.Q, for
+// autocomplete, optionally restricted to the direct children of a parent.
+func (tm TagModel) GetAll(tagFilters TagFilters, filters Filters) ([]*Tag, Metadata, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(`
+		SELECT count(*) OVER(), %s
+		FROM %s
+		WHERE ($1 = '' OR t.slug LIKE $2 || '%%' OR lower(t.name) LIKE $3 || '%%' OR EXISTS (
+			SELECT 1 FROM tag_synonyms s WHERE s.tag_id = t.id AND s.synonym LIKE $2 || '%%'
+		))
+		AND ($4 = '' OR p.slug = $4)
+		ORDER BY %s %s, t.id ASC
+		LIMIT $5 OFFSET $6
+	`, tagColumns, tagTables, filters.sortColumn(), filters.sortDirection())
+
+	escape := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	q := strings.TrimSpace(tagFilters.Q)
+
+	args := []any{
+		q,
+		Slugify(q),
+		escape.Replace(strings.ToLower(q)),
+		Slugify(tagFilters.Parent),
+		filters.Limit(),
+		filters.Offset(),
+	}
+
+	rows, err := tm.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	tags := []*Tag{}
+
+	for rows.Next() {
+		var tag Tag
+		if err := rows.Scan(append([]any{&totalRecords}, tagFields(&tag)...)...); err != nil {
+			return nil, Metadata{}, err
+		}
+		tags = append(tags, &tag)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return tags, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
+
+// GetAncestors returns the tags above id, root first.
+func (tm TagModel) GetAncestors(id int64) ([]*Tag, error) {
+
+	query := `
+		WITH RECURSIVE ancestors AS (
+			SELECT parent_id AS id, 1 AS depth FROM tags WHERE id = $1 AND parent_id IS NOT NULL
+			UNION
+			SELECT tags.parent_id, ancestors.depth + 1
+			FROM tags JOIN ancestors ON tags.id = ancestors.id
+			WHERE tags.parent_id IS NOT NULL AND ancestors.depth < 32
+		)
+		SELECT ` + tagColumns + `
+		FROM ` + tagTables + ` JOIN ancestors a ON a.id = t.id
+		ORDER BY a.depth DESC
+	`
+
+	return tm.query(query, id)
+}
+
+func (tm TagModel) GetChildren(id int64) ([]*Tag, error) {
+
+	query := `
+		SELECT ` + tagColumns + `
+		FROM ` + tagTables + `
+		WHERE t.parent_id = $1
+		ORDER BY t.name
+	`
+
+	return tm.query(query, id)
+}
+
+func (tm TagModel) query(query string, args ...any) ([]*Tag, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := tm.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*Tag{}
+
+	for rows.Next() {
+		var tag Tag
+		if err := rows.Scan(tagFields(&tag)...); err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// Update saves tag if it is still at the version that was read. Renaming the
+// slug retags the podcasts using oldSlug and keeps oldSlug as a synonym.
+func (tm TagModel) Update(tag *Tag, oldSlug string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tag.Synonyms = synonymSlugs(tag.Synonyms)
+	if oldSlug != tag.Slug && !validator.PermitedValues(oldSlug, tag.Synonyms...) {
+		tag.Synonyms = append(tag.Synonyms, oldSlug)
+	}
+
+	tx, err := tm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if tag.Parent != "" {
+		var cycle bool
+		err = tx.QueryRowContext(ctx, `SELECT $1 = ANY(tag_descendants($2))`, tag.Parent, oldSlug).Scan(&cycle)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return ErrTagCycle
+		}
+	}
+
+	query := `
+		UPDATE tags
+		SET slug = $1, name = $2, parent_id = (SELECT id FROM tags WHERE slug = NULLIF($3, '')), version = version + 1
+		WHERE id = $4 AND version = $5
+		RETURNING version
+	`
+
+	args := []any{tag.Slug, tag.Name, tag.Parent, tag.Id, tag.Version}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&tag.Version)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return ErrDuplicateTag
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrEditConflict
+		default:
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM tag_synonyms WHERE synonym = $1 AND tag_id = $2`, tag.Slug, tag.Id)
+	if err != nil {
+		return err
+	}
+
+	if err := saveTagSynonyms(ctx, tx, tag); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tm TagModel) DeleteById(id int64) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		DELETE FROM tags t
+		WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM podcasts WHERE podcasts.tags @> ARRAY[t.slug])
+	`
+
+	res, err := tm.Db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		var exists bool
+		if err := tm.Db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM tags WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return ErrTagInUse
+		}
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Canonicalize slugifies names and replaces synonyms with the tags they stand
+// for. Unknown tags are kept as their slug; duplicates and empty slugs are
+// dropped.
+func (tm TagModel) Canonicalize(names []string) ([]string, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return canonicalTags(ctx, tm.Db, slugifyAll(names))
+}
+
+func canonicalTags(ctx context.Context, db *sql.DB, slugs []string) ([]string, error) {
+
+	query := `
+		SELECT s.slug, COALESCE(t.slug, s.slug)
+		FROM unnest($1::text[]) AS s(slug)
+		LEFT JOIN tag_synonyms sy ON sy.synonym = s.slug
+		LEFT JOIN tags t ON t.id = sy.tag_id
+	`
+
+	rows, err := db.QueryContext(ctx, query, pq.Array(slugs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	canonical := map[string]string{}
+
+	for rows.Next() {
+		var slug, tag string
+		if err := rows.Scan(&slug, &tag); err != nil {
+			return nil, err
+		}
+		canonical[slug] = tag
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	out := []string{}
+	seen := map[string]bool{}
+
+	for _, slug := range slugs {
+		tag := canonical[slug]
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+
+	return out, nil
+}
+
+// Normalize canonicalizes names like Canonicalize and creates the tags that do
+// not exist yet, named after the text they were first written as.
+func (tm TagModel) Normalize(names []string) ([]string, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	slugs := slugifyAll(names)
+
+	tags, err := canonicalTags(ctx, tm.Db, slugs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, slug := range slugs {
+		if slug == "" {
+			continue
+		}
+		_, err := tm.Db.ExecContext(ctx, `
+			INSERT INTO tags (slug, name)
+			SELECT $1, $2
+			WHERE NOT EXISTS (SELECT 1 FROM tag_synonyms WHERE synonym = $1)
+			ON CONFLICT (slug) DO NOTHING
+		`, slug, strings.TrimSpace(names[i]))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tags, nil
+}
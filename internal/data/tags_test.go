@@ -0,0 +1,28 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unique violation", &pq.Error{Code: "23505", Constraint: "tags_slug_key"}, true},
+		{"wrapped", fmt.Errorf("insert tag: %w", &pq.Error{Code: "23505"}), true},
+		{"foreign key violation", &pq.Error{Code: "23503"}, false},
+		{"message alone", errors.New(`pq: duplicate key value violates unique constraint "tags_slug_key"`), false},
+	}
+
+	for _, tt := range tests {
+		if got := isUniqueViolation(tt.err); got != tt.want {
+			t.Errorf("%s: isUniqueViolation() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
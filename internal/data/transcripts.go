@@ -0,0 +1,304 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/terajari/ipdb/internal/language"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+// MaxTranscriptSegments bounds how many segments one transcript may have.
+const MaxTranscriptSegments = 20_000
+
+// Transcript is the text of an episode, split into segments. Segments of
+// plain text transcripts carry no timestamps.
+type Transcript struct {
+	Id        int64               `json:"id"`
+	EpisodeId int64               `json:"episode_id"`
+	Format    string              `json:"format"`
+	Language  string              `json:"language"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+	Segments  []TranscriptSegment `json:"segments"`
+}
+
+type TranscriptSegment struct {
+	Position int    `json:"position"`
+	StartMs  *int64 `json:"start_ms"`
+	EndMs    *int64 `json:"end_ms"`
+	Speaker  string `json:"speaker,omitempty"`
+	Text     string `json:"text"`
+}
+
+// TranscriptHit is a transcript segment matching a search, with the episode it
+// belongs to.
+type TranscriptHit struct {
+	EpisodeId    int64   `json:"episode_id"`
+	EpisodeTitle string  `json:"episode_title"`
+	PodcastId    int64   `json:"podcast_id"`
+	PodcastTitle string  `json:"podcast_title"`
+	Position     int     `json:"position"`
+	StartMs      *int64  `json:"start_ms"`
+	EndMs        *int64  `json:"end_ms"`
+	Speaker      string  `json:"speaker,omitempty"`
+	Snippet      string  `json:"snippet"`
+	Rank         float64 `json:"rank"`
+}
+
+type TranscriptSearch struct {
+	Query     string `form:"q"`
+	PodcastId int64  `form:"podcast_id"`
+	Language  string `form:"language"`
+}
+
+type TranscriptModel struct {
+	Db *sql.DB
+}
+
+type ITranscript interface {
+	Save(*Transcript) error
+	FindByEpisode(int64) (*Transcript, error)
+	Delete(episodeId int64) error
+	Search(TranscriptSearch, Filters) ([]*TranscriptHit, Metadata, error)
+}
+
+func NewTranscriptModel(db *sql.DB) ITranscript {
+	return &TranscriptModel{Db: db}
+}
+
+func ValidateTranscript(v *validator.Validator, t *Transcript) {
+	if t.Language != "" {
+		_, ok := language.Lookup(t.Language)
+		v.Check(ok, "language", "must be an ISO 639 language code, BCP 47 tag or language name")
+	}
+	v.Check(len(t.Segments) > 0, "transcript", "must contain some text")
+	v.Check(len(t.Segments) <= MaxTranscriptSegments, "transcript", "must not have more than 20000 segments")
+}
+
+func ValidateTranscriptSearch(v *validator.Validator, s TranscriptSearch) {
+	v.Check(s.Query != "", "q", "must be provided")
+	v.Check(len(s.Query) <= 200, "q", "must not be more than 200 bytes long")
+}
+
+// Save stores the transcript of an episode, replacing any it already had.
+func (tm TranscriptModel) Save(t *Transcript) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tx, err := tm.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO transcripts (episode_id, format, language)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (episode_id) DO UPDATE
+		SET format = EXCLUDED.format, language = EXCLUDED.language, updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+
+	err = tx.QueryRowContext(ctx, query, t.EpisodeId, t.Format, t.Language).Scan(&t.Id, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM transcript_segments WHERE transcript_id = $1`, t.Id); err != nil {
+		return err
+	}
+
+	n := len(t.Segments)
+	positions := make([]int64, n)
+	starts := make([]sql.NullInt64, n)
+	ends := make([]sql.NullInt64, n)
+	speakers := make([]string, n)
+	bodies := make([]string, n)
+
+	for i, s := range t.Segments {
+		positions[i] = int64(s.Position)
+		if s.StartMs != nil {
+			starts[i] = sql.NullInt64{Int64: *s.StartMs, Valid: true}
+		}
+		if s.EndMs != nil {
+			ends[i] = sql.NullInt64{Int64: *s.EndMs, Valid: true}
+		}
+		speakers[i] = s.Speaker
+		bodies[i] = s.Text
+	}
+
+	query = `
+		INSERT INTO transcript_segments (transcript_id, position, start_ms, end_ms, speaker, body)
+		SELECT $1, s.position, s.start_ms, s.end_ms, s.speaker, s.body
+		FROM unnest($2::int[], $3::bigint[], $4::bigint[], $5::text[], $6::text[])
+			AS s(position, start_ms, end_ms, speaker, body)
+	`
+
+	args := []any{t.Id, pq.Array(positions), pq.GenericArray{A: starts}, pq.GenericArray{A: ends}, pq.Array(speakers), pq.Array(bodies)}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (tm TranscriptModel) FindByEpisode(episodeId int64) (*Transcript, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT id, episode_id, format, language, created_at, updated_at
+		FROM transcripts
+		WHERE episode_id = $1
+	`
+
+	var t Transcript
+	err := tm.Db.QueryRowContext(ctx, query, episodeId).Scan(&t.Id, &t.EpisodeId, &t.Format, &t.Language, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT position, start_ms, end_ms, speaker, body
+		FROM transcript_segments
+		WHERE transcript_id = $1
+		ORDER BY position
+	`
+
+	rows, err := tm.Db.QueryContext(ctx, query, t.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	t.Segments = []TranscriptSegment{}
+
+	for rows.Next() {
+		var s TranscriptSegment
+		if err := rows.Scan(&s.Position, &s.StartMs, &s.EndMs, &s.Speaker, &s.Text); err != nil {
+			return nil, err
+		}
+		t.Segments = append(t.Segments, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
+
+// Delete removes the episode's transcript. It returns sql.ErrNoRows when the
+// episode has none.
+func (tm TranscriptModel) Delete(episodeId int64) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := tm.Db.ExecContext(ctx, `DELETE FROM transcripts WHERE episode_id = $1`, episodeId)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// snippetStart and snippetStop are private use characters ts_headline puts
+// around matches in place of markup, so that the text around them can be
+// escaped before they are turned into <mark> elements. They are removed from
+// the text first so that a segment cannot forge them.
+const (
+	snippetStart = "\uE000"
+	snippetStop  = "\uE001"
+
+	snippetOptions = "MaxWords=30, MinWords=10, StartSel=" + snippetStart + ", StopSel=" + snippetStop
+)
+
+// highlightSnippet escapes a ts_headline snippet for HTML and marks up the
+// matches it delimits.
+func highlightSnippet(s string) string {
+	return strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>").Replace(html.EscapeString(s))
+}
+
+// Search finds transcript segments matching a web search style query (quoted
+// phrases, "or", and "-" to exclude words), best matches first.
+func (tm TranscriptModel) Search(search TranscriptSearch, filters Filters) ([]*TranscriptHit, Metadata, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `
+		WITH q AS (SELECT websearch_to_tsquery('simple', $1) AS query)
+		SELECT count(*) OVER(), e.id, e.title, p.id, p.title,
+			s.position, s.start_ms, s.end_ms, s.speaker,
+			ts_headline('simple', translate(s.body, $7, ''), q.query, $8),
+			ts_rank(s.search, q.query) AS rank
+		FROM q, transcript_segments s
+		JOIN transcripts t ON t.id = s.transcript_id
+		JOIN episodes e ON e.id = t.episode_id
+		JOIN podcasts p ON p.id = e.podcast_id
+		WHERE s.search @@ q.query
+		AND NOT p.hidden
+		AND ($2 = 0 OR p.id = $2)
+		AND ($3 = '' OR t.language = $3)
+		AND ` + safeModeWhere("$6", "p") + `
+		ORDER BY rank DESC, e.id, s.position
+		LIMIT $4 OFFSET $5
+	`
+
+	args := []any{search.Query, search.PodcastId, search.Language, filters.Limit(), filters.Offset(), filters.SafeMode, snippetStart + snippetStop, snippetOptions}
+
+	rows, err := tm.Db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	hits := []*TranscriptHit{}
+
+	for rows.Next() {
+		var h TranscriptHit
+		err := rows.Scan(
+			&totalRecords,
+			&h.EpisodeId,
+			&h.EpisodeTitle,
+			&h.PodcastId,
+			&h.PodcastTitle,
+			&h.Position,
+			&h.StartMs,
+			&h.EndMs,
+			&h.Speaker,
+			&h.Snippet,
+			&h.Rank,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		h.Snippet = highlightSnippet(h.Snippet)
+		hits = append(hits, &h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	return hits, calculateMetadata(totalRecords, filters.Page, filters.PageSize), nil
+}
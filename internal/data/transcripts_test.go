@@ -0,0 +1,22 @@
+package data
+
+import "testing"
+
+func TestHighlightSnippet(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"no match here", "no match here"},
+		{"say \uE000halo\uE001 to \uE000all\uE001", "say <mark>halo</mark> to <mark>all</mark>"},
+		{"<script>alert(1)</script> \uE000halo\uE001", "&lt;script&gt;alert(1)&lt;/script&gt; <mark>halo</mark>"},
+		{"\uE000Tom & \"Jerry\"\uE001", "<mark>Tom &amp; &#34;Jerry&#34;</mark>"},
+		{"<mark>fake</mark>", "&lt;mark&gt;fake&lt;/mark&gt;"},
+	}
+
+	for _, tt := range tests {
+		if got := highlightSnippet(tt.in); got != tt.want {
+			t.Errorf("highlightSnippet(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package data
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/terajari/ipdb/internal/validator"
+)
+
+// Translation is a podcast's title and description in another language.
+// Either may be left empty, in which case the original is shown.
+type Translation struct {
+	PodcastId   int64     `json:"podcast_id"`
+	Language    string    `json:"language"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type TranslationModel struct {
+	Db *sql.DB
+}
+
+type ITranslation interface {
+	GetAll(podcastId int64) ([]*Translation, error)
+	Upsert(*Translation) error
+	Delete(podcastId int64, language string) error
+	Localize(podcasts []*Podcast, languages []string) error
+}
+
+func NewTranslationModel(db *sql.DB) ITranslation {
+	return &TranslationModel{Db: db}
+}
+
+func ValidateTranslation(v *validator.Validator, t *Translation) {
+	v.Check(t.Title != "" || t.Description != "", "translation", "must have a title or a description")
+	v.Check(len(t.Title) <= 500, "title", "must not be more than 500 bytes long")
+	v.Check(len(t.Description) <= MaxDescriptionLength, "description", fmt.Sprintf("must not be more than %d bytes long", MaxDescriptionLength))
+}
+
+func (tm TranslationModel) GetAll(podcastId int64) ([]*Translation, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT podcast_id, language, title, description, created_at, updated_at
+		FROM podcast_translations
+		WHERE podcast_id = $1
+		ORDER BY language
+	`
+
+	rows, err := tm.Db.QueryContext(ctx, query, podcastId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := []*Translation{}
+	for rows.Next() {
+		var t Translation
+		if err := rows.Scan(&t.PodcastId, &t.Language, &t.Title, &t.Description, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		translations = append(translations, &t)
+	}
+
+	return translations, rows.Err()
+}
+
+// Upsert adds the translation, or replaces the podcast's existing one in the
+// same language.
+func (tm TranslationModel) Upsert(t *Translation) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		INSERT INTO podcast_translations (podcast_id, language, title, description)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (podcast_id, language) DO UPDATE
+		SET title = EXCLUDED.title, description = EXCLUDED.description, updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	args := []any{t.PodcastId, t.Language, t.Title, t.Description}
+
+	return tm.Db.QueryRowContext(ctx, query, args...).Scan(&t.CreatedAt, &t.UpdatedAt)
+}
+
+func (tm TranslationModel) Delete(podcastId int64, language string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := tm.Db.ExecContext(ctx, `DELETE FROM podcast_translations WHERE podcast_id = $1 AND language = $2`, podcastId, language)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// Localize shows each podcast in the first of languages, most preferred
+// first, that it is either recorded in or translated to. A podcast recorded in
+// a language the client prefers over every translation keeps its original
+// title and description, as does one with no translation asked for. A
+// translation missing its title or description falls back to the original.
+func (tm TranslationModel) Localize(podcasts []*Podcast, languages []string) error {
+	if len(podcasts) == 0 || len(languages) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	ids := make([]int64, len(podcasts))
+	for i, p := range podcasts {
+		ids[i] = p.Id
+	}
+
+	query := `
+		SELECT podcast_id, language, title, description
+		FROM podcast_translations
+		WHERE podcast_id = ANY($1) AND language = ANY($2)
+	`
+
+	rows, err := tm.Db.QueryContext(ctx, query, pq.Array(ids), pq.Array(languages))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type key struct {
+		podcastId int64
+		language  string
+	}
+
+	translations := map[key]Translation{}
+	for rows.Next() {
+		var t Translation
+		if err := rows.Scan(&t.PodcastId, &t.Language, &t.Title, &t.Description); err != nil {
+			return err
+		}
+		translations[key{t.PodcastId, t.Language}] = t
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, p := range podcasts {
+		for _, lang := range languages {
+			if slices.Contains(p.Languages, lang) {
+				break
+			}
+			t, ok := translations[key{p.Id, lang}]
+			if !ok {
+				continue
+			}
+			if t.Title != "" {
+				p.Title = t.Title
+			}
+			if t.Description != "" {
+				p.Description = t.Description
+			}
+			p.Locale = lang
+			break
+		}
+	}
+
+	return nil
+}
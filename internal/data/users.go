@@ -13,16 +13,36 @@ import (
 
 var ErrDuplicateEmail = errors.New("duplicate email")
 
+// Contributors can suggest changes to the catalog; editors review suggestions
+// and change the catalog directly.
+const (
+	RoleContributor = "contributor"
+	RoleEditor      = "editor"
+)
+
 type User struct {
 	Id        int64     `json:"id"`
 	Name      string    `json:"name"`
 	Email     string    `json:"email" binding:"required,email"`
 	Password  password  `json:"-" binding:"required,min=8,max=72"`
 	Activated bool      `json:"active"`
+	Role      string    `json:"role"`
+	SafeMode  bool      `json:"safe_mode"`
 	Version   int       `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// AnonymousUser stands for a request that carries no authentication token.
+var AnonymousUser = &User{}
+
+func (u *User) IsAnonymous() bool {
+	return u == AnonymousUser
+}
+
+func (u *User) IsEditor() bool {
+	return u.Role == RoleEditor
+}
+
 type UserModel struct {
 	Db *sql.DB
 }
@@ -33,6 +53,8 @@ type IUser interface {
 	Update(user *User) error
 	GetForToken(string, string) (*User, error)
 	ActivateUser(userI int64) error
+	SetSafeMode(userId int64, on bool) error
+	SetRole(userId int64, role string) error
 	Matches(*User, string) (bool, error)
 }
 
@@ -86,7 +108,7 @@ func (m UserModel) Insert(user *User) error {
 	query := `
 		INSERT INTO users (name, email, password_hash)
 		VALUES ($1, $2, $3)
-		RETURNING id, created_at, version
+		RETURNING id, created_at, role, version
 	`
 
 	args := []any{user.Name, user.Email, user.Password.Hash}
@@ -94,7 +116,7 @@ func (m UserModel) Insert(user *User) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := m.Db.QueryRowContext(ctx, query, args...).Scan(&user.Id, &user.CreatedAt, &user.Version)
+	err := m.Db.QueryRowContext(ctx, query, args...).Scan(&user.Id, &user.CreatedAt, &user.Role, &user.Version)
 
 	if err != nil {
 		switch {
@@ -112,7 +134,7 @@ func (m UserModel) Insert(user *User) error {
 func (m *UserModel) GetByEmail(email string) (*User, error) {
 
 	query := `
-		SELECT id, created_at, name, email, password_hash, activated, version
+		SELECT id, created_at, name, email, password_hash, activated, role, safe_mode, version
 		FROM users
 		WHERE email = $1
 	`
@@ -129,6 +151,8 @@ func (m *UserModel) GetByEmail(email string) (*User, error) {
 		&user.Email,
 		&user.Password.Hash,
 		&user.Activated,
+		&user.Role,
+		&user.SafeMode,
 		&user.Version,
 	)
 
@@ -189,7 +213,7 @@ func (m *UserModel) GetForToken(tokenScope string, token string) (*User, error)
 	tokenHash := sha256.Sum256([]byte(token))
 
 	query := `
-		SELECT users.id, users.name, users.email, users.created_at, users.activated, users.version 
+		SELECT users.id, users.name, users.email, users.created_at, users.activated, users.role, users.safe_mode, users.version 
 		FROM users
 		INNER JOIN tokens
 		ON users.id = tokens.user_id
@@ -211,6 +235,8 @@ func (m *UserModel) GetForToken(tokenScope string, token string) (*User, error)
 		&user.Email,
 		&user.CreatedAt,
 		&user.Activated,
+		&user.Role,
+		&user.SafeMode,
 		&user.Version,
 	)
 
@@ -238,3 +264,48 @@ func (m *UserModel) ActivateUser(userid int64) error {
 
 	return nil
 }
+
+// SetSafeMode turns safe mode, which leaves explicit podcasts and those aimed
+// at adults out of everything the user is shown, on or off for the user.
+func (m *UserModel) SetSafeMode(userId int64, on bool) error {
+
+	query := `
+		UPDATE users SET safe_mode = $1, version = version + 1
+		WHERE id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.Db.ExecContext(ctx, query, on, userId)
+	return err
+}
+
+// SetRole gives the user the role, which must be RoleContributor or
+// RoleEditor.
+func (m *UserModel) SetRole(userId int64, role string) error {
+
+	query := `
+		UPDATE users SET role = $1, version = version + 1
+		WHERE id = $2
+	`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	res, err := m.Db.ExecContext(ctx, query, role, userId)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package feed
+
+import "strings"
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+type atomDocument struct {
+	Title      string         `xml:"title"`
+	Subtitle   string         `xml:"subtitle"`
+	Links      []atomLink     `xml:"link"`
+	Authors    []atomPerson   `xml:"author"`
+	Language   string         `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Logo       string         `xml:"logo"`
+	Icon       string         `xml:"icon"`
+	Categories []atomCategory `xml:"category"`
+	Entries    []atomEntry    `xml:"entry"`
+}
+
+type atomLink struct {
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr,omitempty"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length string `xml:"length,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+}
+
+type atomCategory struct {
+	Term  string `xml:"term,attr"`
+	Label string `xml:"label,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Id        string       `xml:"id"`
+	Title     string       `xml:"title"`
+	Summary   string       `xml:"summary"`
+	Content   string       `xml:"content"`
+	Links     []atomLink   `xml:"link"`
+	Authors   []atomPerson `xml:"author"`
+	Published string       `xml:"published"`
+	Updated   string       `xml:"updated"`
+}
+
+func parseAtom(body []byte) (*Feed, error) {
+	var doc atomDocument
+	if err := newDecoder(body).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	f := &Feed{
+		Title:       strings.TrimSpace(doc.Title),
+		Link:        alternateLink(doc.Links),
+		Description: strings.TrimSpace(doc.Subtitle),
+		Author:      firstAuthor(doc.Authors),
+		Owner:       firstAuthor(doc.Authors),
+		Language:    strings.TrimSpace(doc.Language),
+		Image:       firstNonEmpty(doc.Logo, doc.Icon),
+	}
+
+	for _, c := range doc.Categories {
+		if t := firstNonEmpty(c.Label, c.Term); t != "" {
+			f.Categories = append(f.Categories, t)
+		}
+	}
+
+	for _, e := range doc.Entries {
+		item := Item{
+			Guid:        firstNonEmpty(e.Id, alternateLink(e.Links)),
+			Title:       strings.TrimSpace(e.Title),
+			Link:        alternateLink(e.Links),
+			Description: firstNonEmpty(e.Summary, e.Content),
+			Author:      firstAuthor(e.Authors),
+			Published:   parseDate(firstNonEmpty(e.Published, e.Updated)),
+		}
+		for _, l := range e.Links {
+			if l.Rel == "enclosure" {
+				item.AudioUrl = strings.TrimSpace(l.Href)
+				break
+			}
+		}
+		f.Items = append(f.Items, item)
+	}
+
+	return f, nil
+}
+
+func alternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	return ""
+}
+
+func firstAuthor(authors []atomPerson) string {
+	for _, a := range authors {
+		if n := strings.TrimSpace(a.Name); n != "" {
+			return n
+		}
+	}
+	return ""
+}
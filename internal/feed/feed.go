@@ -0,0 +1,135 @@
+package feed
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported feed format")
+
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	Author      string
+	Owner       string
+	Language    string
+	Image       string
+	Categories  []string
+	Explicit    bool
+	Items       []Item
+}
+
+type Item struct {
+	Guid        string
+	Title       string
+	Link        string
+	Description string
+	Author      string
+	AudioUrl    string
+	Duration    time.Duration
+	Published   time.Time
+}
+
+// Parse reads an RSS 2.0 or Atom document and returns the channel and its items.
+func Parse(r io.Reader) (*Feed, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := rootElement(body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case root.Local == "rss":
+		return parseRSS(body)
+	case root.Local == "feed" && root.Space == atomNamespace:
+		return parseAtom(body)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func newDecoder(body []byte) *xml.Decoder {
+	dec := xml.NewDecoder(bytes.NewReader(body))
+	dec.CharsetReader = charset.NewReaderLabel
+	dec.Strict = false
+	return dec
+}
+
+func rootElement(body []byte) (xml.Name, error) {
+	dec := newDecoder(body)
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return xml.Name{}, ErrUnsupportedFormat
+			}
+			return xml.Name{}, err
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			return se.Name, nil
+		}
+	}
+}
+
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC3339,
+	time.RFC3339Nano,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"02 Jan 2006 15:04:05 -0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// parseDuration accepts the itunes:duration forms "SS", "MM:SS" and "HH:MM:SS".
+func parseDuration(s string) time.Duration {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+
+	var total int64
+	for _, part := range strings.Split(s, ":") {
+		n, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0
+		}
+		total = total*60 + int64(n)
+	}
+	return time.Duration(total) * time.Second
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,213 @@
+package feed
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const rssFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd" xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel>
+    <title> Ngobrol Santai </title>
+    <atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+    <link>https://example.com/</link>
+    <description>Obrolan mingguan.</description>
+    <language>id</language>
+    <itunes:author>Rina</itunes:author>
+    <itunes:owner>
+      <itunes:name>Studio Rina</itunes:name>
+      <itunes:email>rina@example.com</itunes:email>
+    </itunes:owner>
+    <itunes:image href="https://example.com/cover.jpg"/>
+    <itunes:category text="Society &amp; Culture">
+      <itunes:category text="Personal Journals"/>
+    </itunes:category>
+    <itunes:explicit>yes</itunes:explicit>
+    <item>
+      <guid>ep-2</guid>
+      <title>Episode 2</title>
+      <link>https://example.com/2</link>
+      <description>Kedua.</description>
+      <pubDate>Tue, 02 Jan 2024 10:00:00 +0700</pubDate>
+      <enclosure url="https://example.com/2.mp3" type="audio/mpeg" length="1"/>
+      <itunes:duration>1:02:03</itunes:duration>
+    </item>
+    <item>
+      <itunes:title>Episode 1</itunes:title>
+      <itunes:summary>Pertama.</itunes:summary>
+      <itunes:author>Budi</itunes:author>
+      <pubDate>2024-01-01</pubDate>
+      <enclosure url="https://example.com/1.mp3" type="audio/mpeg" length="1"/>
+      <itunes:duration>95</itunes:duration>
+    </item>
+  </channel>
+</rss>`
+
+const atomFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">
+  <title>Weekly Talk</title>
+  <subtitle>Talking weekly.</subtitle>
+  <link rel="self" href="https://example.org/atom.xml"/>
+  <link rel="alternate" href="https://example.org/"/>
+  <author><name>Alex</name></author>
+  <icon>https://example.org/icon.png</icon>
+  <category term="tech" label="Technology"/>
+  <category term="news"/>
+  <entry>
+    <id>urn:uuid:1</id>
+    <title>First</title>
+    <link href="https://example.org/1"/>
+    <link rel="enclosure" href="https://example.org/1.mp3" type="audio/mpeg"/>
+    <content>Body.</content>
+    <updated>2024-03-04T05:06:07Z</updated>
+  </entry>
+</feed>`
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want *Feed
+		err  error
+	}{
+		{
+			name: "rss",
+			body: rssFeed,
+			want: &Feed{
+				Title:       "Ngobrol Santai",
+				Link:        "https://example.com/",
+				Description: "Obrolan mingguan.",
+				Author:      "Rina",
+				Owner:       "Studio Rina",
+				Language:    "id",
+				Image:       "https://example.com/cover.jpg",
+				Categories:  []string{"Society & Culture", "Personal Journals"},
+				Explicit:    true,
+				Items: []Item{
+					{
+						Guid:        "ep-2",
+						Title:       "Episode 2",
+						Link:        "https://example.com/2",
+						Description: "Kedua.",
+						AudioUrl:    "https://example.com/2.mp3",
+						Duration:    time.Hour + 2*time.Minute + 3*time.Second,
+						Published:   time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC),
+					},
+					{
+						Guid:        "https://example.com/1.mp3",
+						Title:       "Episode 1",
+						Description: "Pertama.",
+						Author:      "Budi",
+						AudioUrl:    "https://example.com/1.mp3",
+						Duration:    95 * time.Second,
+						Published:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+		{
+			name: "atom",
+			body: atomFeed,
+			want: &Feed{
+				Title:       "Weekly Talk",
+				Link:        "https://example.org/",
+				Description: "Talking weekly.",
+				Author:      "Alex",
+				Owner:       "Alex",
+				Language:    "en",
+				Image:       "https://example.org/icon.png",
+				Categories:  []string{"Technology", "news"},
+				Items: []Item{
+					{
+						Guid:        "urn:uuid:1",
+						Title:       "First",
+						Link:        "https://example.org/1",
+						Description: "Body.",
+						AudioUrl:    "https://example.org/1.mp3",
+						Published:   time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC),
+					},
+				},
+			},
+		},
+		{
+			name: "atom without namespace",
+			body: `<feed><title>x</title></feed>`,
+			err:  ErrUnsupportedFormat,
+		},
+		{
+			name: "html",
+			body: `<html><body>not a feed</body></html>`,
+			err:  ErrUnsupportedFormat,
+		},
+		{
+			name: "empty",
+			body: ``,
+			err:  ErrUnsupportedFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.body))
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			for i := range got.Items {
+				if !got.Items[i].Published.IsZero() {
+					got.Items[i].Published = got.Items[i].Published.UTC()
+				}
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"45", 45 * time.Second},
+		{"12:34", 12*time.Minute + 34*time.Second},
+		{"01:02:03", time.Hour + 2*time.Minute + 3*time.Second},
+		{" 90.5 ", 90 * time.Second},
+		{"1h", 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseDuration(tt.in); got != tt.want {
+			t.Errorf("parseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseExplicit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"true", true},
+		{"Yes", true},
+		{"explicit", true},
+		{"false", false},
+		{"clean", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := parseExplicit(tt.in); got != tt.want {
+			t.Errorf("parseExplicit(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const maxFeedSize = 10 << 20
+
+type Fetcher struct {
+	client    *http.Client
+	userAgent string
+}
+
+// Result holds the parsed feed and the validators to send on the next request.
+// Feed is nil when the server answered 304 Not Modified.
+type Result struct {
+	Feed         *Feed
+	ETag         string
+	LastModified string
+	NotModified  bool
+}
+
+func NewFetcher(timeout time.Duration, userAgent string) *Fetcher {
+	return &Fetcher{
+		client:    &http.Client{Timeout: timeout},
+		userAgent: userAgent,
+	}
+}
+
+// Fetch performs a conditional GET using the etag and lastModified values
+// returned by a previous fetch of the same url.
+func (f *Fetcher) Fetch(ctx context.Context, url, etag, lastModified string) (*Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml;q=0.9, text/xml;q=0.8, */*;q=0.5")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	result := &Result{
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotModified:
+		if result.ETag == "" {
+			result.ETag = etag
+		}
+		if result.LastModified == "" {
+			result.LastModified = lastModified
+		}
+		result.NotModified = true
+		return result, nil
+	case res.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("fetching %s: unexpected status %s", url, res.Status)
+	}
+
+	result.Feed, err = Parse(io.LimitReader(res.Body, maxFeedSize))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", url, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetch(t *testing.T) {
+	const (
+		etag         = `"v1"`
+		lastModified = "Mon, 01 Jan 2024 00:00:00 GMT"
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/feed":
+			if r.Header.Get("If-None-Match") == etag || r.Header.Get("If-Modified-Since") == lastModified {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Last-Modified", lastModified)
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Write([]byte(rssFeed))
+		case "/page":
+			w.Write([]byte(`<html></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		path         string
+		etag         string
+		lastModified string
+		notModified  bool
+		wantErr      bool
+	}{
+		{name: "first fetch", path: "/feed"},
+		{name: "matching etag", path: "/feed", etag: etag, notModified: true},
+		{name: "matching last modified", path: "/feed", lastModified: lastModified, notModified: true},
+		{name: "stale etag", path: "/feed", etag: `"v0"`},
+		{name: "not found", path: "/missing", wantErr: true},
+		{name: "not a feed", path: "/page", wantErr: true},
+	}
+
+	fetcher := NewFetcher(5*time.Second, "ipdb-test")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := fetcher.Fetch(context.Background(), srv.URL+tt.path, tt.etag, tt.lastModified)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if res.NotModified != tt.notModified {
+				t.Errorf("NotModified = %v, want %v", res.NotModified, tt.notModified)
+			}
+			if tt.notModified && res.Feed != nil {
+				t.Error("Feed should be nil when not modified")
+			}
+			if !tt.notModified && (res.Feed == nil || res.Feed.Title != "Ngobrol Santai") {
+				t.Errorf("Feed = %+v, want the parsed feed", res.Feed)
+			}
+
+			// The validators carry over to the next fetch either way.
+			if tt.notModified {
+				if res.ETag != tt.etag || res.LastModified != tt.lastModified {
+					t.Errorf("validators = %q, %q, want %q, %q", res.ETag, res.LastModified, tt.etag, tt.lastModified)
+				}
+			} else if res.ETag != etag || res.LastModified != lastModified {
+				t.Errorf("validators = %q, %q, want %q, %q", res.ETag, res.LastModified, etag, lastModified)
+			}
+		})
+	}
+}
+
+func TestFetchSendsUserAgent(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer srv.Close()
+
+	if _, err := NewFetcher(time.Second, "ipdb-test").Fetch(context.Background(), srv.URL, "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got != "ipdb-test" {
+		t.Errorf("User-Agent = %q, want %q", got, "ipdb-test")
+	}
+}
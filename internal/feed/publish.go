@@ -0,0 +1,175 @@
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Channel is a feed to be published; it is rendered as either RSS 2.0 or Atom.
+type Channel struct {
+	Title       string
+	Link        string
+	SelfLink    string
+	Description string
+	Language    string
+	Updated     time.Time
+	Entries     []Entry
+}
+
+type Entry struct {
+	Id         string
+	Title      string
+	Link       string
+	Summary    string
+	Author     string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+}
+
+type rssOut struct {
+	XMLName xml.Name      `xml:"rss"`
+	Version string        `xml:"version,attr"`
+	Atom    string        `xml:"xmlns:atom,attr"`
+	Channel rssOutChannel `xml:"channel"`
+}
+
+type rssOutChannel struct {
+	Title         string       `xml:"title"`
+	Link          string       `xml:"link"`
+	SelfLink      rssOutSelf   `xml:"atom:link"`
+	Description   string       `xml:"description"`
+	Language      string       `xml:"language,omitempty"`
+	LastBuildDate string       `xml:"lastBuildDate,omitempty"`
+	Items         []rssOutItem `xml:"item"`
+}
+
+type rssOutSelf struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssOutItem struct {
+	Title       string     `xml:"title"`
+	Link        string     `xml:"link,omitempty"`
+	Description string     `xml:"description,omitempty"`
+	Categories  []string   `xml:"category"`
+	Guid        rssOutGuid `xml:"guid"`
+	PubDate     string     `xml:"pubDate,omitempty"`
+}
+
+type rssOutGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomOut struct {
+	XMLName  xml.Name       `xml:"http://www.w3.org/2005/Atom feed"`
+	Lang     string         `xml:"xml:lang,attr,omitempty"`
+	Id       string         `xml:"id"`
+	Title    string         `xml:"title"`
+	Subtitle string         `xml:"subtitle,omitempty"`
+	Updated  string         `xml:"updated"`
+	Links    []atomLink     `xml:"link"`
+	Entries  []atomOutEntry `xml:"entry"`
+}
+
+type atomOutEntry struct {
+	Id         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Links      []atomLink     `xml:"link"`
+	Summary    string         `xml:"summary,omitempty"`
+	Authors    []atomPerson   `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Published  string         `xml:"published,omitempty"`
+	Updated    string         `xml:"updated"`
+}
+
+func WriteRSS(w io.Writer, ch *Channel) error {
+	doc := rssOut{
+		Version: "2.0",
+		Atom:    atomNamespace,
+		Channel: rssOutChannel{
+			Title:       ch.Title,
+			Link:        ch.Link,
+			SelfLink:    rssOutSelf{Href: ch.SelfLink, Rel: "self", Type: "application/rss+xml"},
+			Description: ch.Description,
+			Language:    ch.Language,
+		},
+	}
+
+	if !ch.Updated.IsZero() {
+		doc.Channel.LastBuildDate = ch.Updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, e := range ch.Entries {
+		item := rssOutItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			Description: e.Summary,
+			Categories:  e.Categories,
+			Guid:        rssOutGuid{Value: e.Id},
+		}
+		if !e.Published.IsZero() {
+			item.PubDate = e.Published.UTC().Format(time.RFC1123Z)
+		}
+		doc.Channel.Items = append(doc.Channel.Items, item)
+	}
+
+	return encode(w, doc)
+}
+
+func WriteAtom(w io.Writer, ch *Channel) error {
+	doc := atomOut{
+		Lang:     ch.Language,
+		Id:       ch.SelfLink,
+		Title:    ch.Title,
+		Subtitle: ch.Description,
+		Updated:  ch.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: ch.SelfLink, Rel: "self", Type: "application/atom+xml"},
+			{Href: ch.Link, Rel: "alternate"},
+		},
+	}
+
+	for _, e := range ch.Entries {
+		entry := atomOutEntry{
+			Id:      e.Id,
+			Title:   e.Title,
+			Summary: e.Summary,
+			Updated: e.Updated.UTC().Format(time.RFC3339),
+		}
+		if e.Link != "" {
+			entry.Links = append(entry.Links, atomLink{Href: e.Link, Rel: "alternate"})
+		}
+		if e.Author != "" {
+			entry.Authors = append(entry.Authors, atomPerson{Name: e.Author})
+		}
+		for _, c := range e.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+		if !e.Published.IsZero() {
+			entry.Published = e.Published.UTC().Format(time.RFC3339)
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return encode(w, doc)
+}
+
+func encode(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
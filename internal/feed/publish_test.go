@@ -0,0 +1,114 @@
+package feed
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	updated := time.Date(2024, 5, 6, 7, 8, 9, 0, time.FixedZone("WIB", 7*3600))
+	published := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	ch := &Channel{
+		Title:       "Recent <podcasts> & more",
+		Link:        "https://ipdb.example/v1/podcasts",
+		SelfLink:    "https://ipdb.example/v1/feeds/podcasts.rss?tag=news&page=1",
+		Description: "Recently added",
+		Language:    "en",
+		Updated:     updated,
+		Entries: []Entry{
+			{
+				Id:         "https://ipdb.example/v1/podcasts/1",
+				Title:      "First & Best",
+				Link:       "https://first.example/",
+				Summary:    "A show",
+				Author:     "Rina",
+				Categories: []string{"news", "culture"},
+				Published:  published,
+				Updated:    updated,
+			},
+			{
+				Id:      "https://ipdb.example/v1/podcasts/2",
+				Title:   "Second",
+				Updated: updated,
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		write func(io.Writer, *Channel) error
+		want  []string
+		items []Item
+	}{
+		{
+			name:  "rss",
+			write: WriteRSS,
+			want: []string{
+				`<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">`,
+				`<atom:link href="https://ipdb.example/v1/feeds/podcasts.rss?tag=news&amp;page=1" rel="self" type="application/rss+xml"></atom:link>`,
+				`<lastBuildDate>Mon, 06 May 2024 00:08:09 +0000</lastBuildDate>`,
+				`<guid isPermaLink="false">https://ipdb.example/v1/podcasts/1</guid>`,
+				`<category>culture</category>`,
+			},
+			items: []Item{
+				{Guid: "https://ipdb.example/v1/podcasts/1", Title: "First & Best", Link: "https://first.example/", Description: "A show", Published: published},
+				{Guid: "https://ipdb.example/v1/podcasts/2", Title: "Second"},
+			},
+		},
+		{
+			name:  "atom",
+			write: WriteAtom,
+			want: []string{
+				`<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="en">`,
+				`<updated>2024-05-06T00:08:09Z</updated>`,
+				`<link href="https://ipdb.example/v1/podcasts" rel="alternate"></link>`,
+				`<category term="news"></category>`,
+				`<published>2024-01-02T03:04:05Z</published>`,
+			},
+			items: []Item{
+				{Guid: "https://ipdb.example/v1/podcasts/1", Title: "First & Best", Link: "https://first.example/", Description: "A show", Author: "Rina", Published: published},
+				{Guid: "https://ipdb.example/v1/podcasts/2", Title: "Second", Published: updated.UTC()},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.write(&buf, ch); err != nil {
+				t.Fatal(err)
+			}
+
+			out := buf.String()
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("output does not contain %s\n%s", s, out)
+				}
+			}
+
+			// What is published reads back with the parser used for ingestion.
+			f, err := Parse(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Title != ch.Title || f.Link != ch.Link || f.Description != ch.Description || f.Language != ch.Language {
+				t.Errorf("channel = %q %q %q %q", f.Title, f.Link, f.Description, f.Language)
+			}
+			if len(f.Items) != len(tt.items) {
+				t.Fatalf("got %d items, want %d", len(f.Items), len(tt.items))
+			}
+			for i, want := range tt.items {
+				got := f.Items[i]
+				got.Published = got.Published.UTC()
+				if got.Guid != want.Guid || got.Title != want.Title || got.Link != want.Link ||
+					got.Description != want.Description || got.Author != want.Author || !got.Published.Equal(want.Published) {
+					t.Errorf("item %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
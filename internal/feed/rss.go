@@ -0,0 +1,144 @@
+package feed
+
+import "strings"
+
+type rssDocument struct {
+	Channel rssChannel `xml:"channel"`
+}
+
+// Namespaced fields come first: encoding/xml assigns an element to the first
+// field whose local name matches, and a tag without a namespace matches any.
+type rssChannel struct {
+	ItunesAuthor     string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+	ItunesSummary    string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+	ItunesOwner      itunesOwner      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd owner"`
+	ItunesImage      itunesImage      `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+	ItunesCategories []itunesCategory `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd category"`
+	ItunesExplicit   string           `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd explicit"`
+	Title            string           `xml:"title"`
+	Links            []rssLink        `xml:"link"`
+	Description      string           `xml:"description"`
+	Language         string           `xml:"language"`
+	ManagingEditor   string           `xml:"managingEditor"`
+	Categories       []string         `xml:"category"`
+	Image            rssImage         `xml:"image"`
+	Items            []rssItem        `xml:"item"`
+}
+
+// rssLink also matches atom:link, which carries an href attribute but no text.
+type rssLink struct {
+	Href  string `xml:"href,attr"`
+	Value string `xml:",chardata"`
+}
+
+type rssImage struct {
+	Url string `xml:"url"`
+}
+
+type itunesOwner struct {
+	Name  string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd name"`
+	Email string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd email"`
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type itunesCategory struct {
+	Text          string           `xml:"text,attr"`
+	Subcategories []itunesCategory `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd category"`
+}
+
+type rssItem struct {
+	ItunesTitle    string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd title"`
+	ItunesAuthor   string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd author"`
+	ItunesSummary  string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd summary"`
+	ItunesDuration string       `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+	Guid           string       `xml:"guid"`
+	Title          string       `xml:"title"`
+	Link           string       `xml:"link"`
+	Description    string       `xml:"description"`
+	Author         string       `xml:"author"`
+	PubDate        string       `xml:"pubDate"`
+	Enclosure      rssEnclosure `xml:"enclosure"`
+}
+
+type rssEnclosure struct {
+	Url  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+func parseRSS(body []byte) (*Feed, error) {
+	var doc rssDocument
+	if err := newDecoder(body).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	ch := doc.Channel
+
+	f := &Feed{
+		Title:       strings.TrimSpace(ch.Title),
+		Link:        channelLink(ch.Links),
+		Description: firstNonEmpty(ch.Description, ch.ItunesSummary),
+		Author:      firstNonEmpty(ch.ItunesAuthor, ch.ItunesOwner.Name, ch.ManagingEditor),
+		Owner:       firstNonEmpty(ch.ItunesOwner.Name, ch.ItunesAuthor),
+		Language:    strings.TrimSpace(ch.Language),
+		Image:       firstNonEmpty(ch.ItunesImage.Href, ch.Image.Url),
+		Explicit:    parseExplicit(ch.ItunesExplicit),
+	}
+
+	for _, c := range ch.ItunesCategories {
+		f.Categories = append(f.Categories, flattenCategory(c)...)
+	}
+	for _, c := range ch.Categories {
+		if c = strings.TrimSpace(c); c != "" {
+			f.Categories = append(f.Categories, c)
+		}
+	}
+
+	for _, it := range ch.Items {
+		f.Items = append(f.Items, Item{
+			Guid:        firstNonEmpty(it.Guid, it.Enclosure.Url, it.Link),
+			Title:       firstNonEmpty(it.Title, it.ItunesTitle),
+			Link:        strings.TrimSpace(it.Link),
+			Description: firstNonEmpty(it.Description, it.ItunesSummary),
+			Author:      firstNonEmpty(it.ItunesAuthor, it.Author),
+			AudioUrl:    strings.TrimSpace(it.Enclosure.Url),
+			Duration:    parseDuration(it.ItunesDuration),
+			Published:   parseDate(it.PubDate),
+		})
+	}
+
+	return f, nil
+}
+
+func channelLink(links []rssLink) string {
+	for _, l := range links {
+		if v := strings.TrimSpace(l.Value); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func flattenCategory(c itunesCategory) []string {
+	var out []string
+	if t := strings.TrimSpace(c.Text); t != "" {
+		out = append(out, t)
+	}
+	for _, sub := range c.Subcategories {
+		out = append(out, flattenCategory(sub)...)
+	}
+	return out
+}
+
+// parseExplicit reads itunes:explicit, which Apple documents as "true" or
+// "false" but older feeds give as "yes", "explicit" or "clean".
+func parseExplicit(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "yes", "explicit":
+		return true
+	default:
+		return false
+	}
+}
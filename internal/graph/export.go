@@ -0,0 +1,158 @@
+package graph
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// JGF is a graph in JSON Graph Format, version 2.
+type JGF struct {
+	Graph JGFGraph `json:"graph"`
+}
+
+type JGFGraph struct {
+	Label    string             `json:"label,omitempty"`
+	Type     string             `json:"type"`
+	Directed bool               `json:"directed"`
+	Nodes    map[string]JGFNode `json:"nodes"`
+	Edges    []JGFEdge          `json:"edges"`
+	Metadata map[string]any     `json:"metadata,omitempty"`
+}
+
+type JGFNode struct {
+	Label    string         `json:"label"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+type JGFEdge struct {
+	Source   string         `json:"source"`
+	Target   string         `json:"target"`
+	Relation string         `json:"relation"`
+	Metadata map[string]any `json:"metadata"`
+}
+
+type jgfPodcast struct {
+	Id    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+// JGF returns the graph in JSON Graph Format. Edges list the podcasts the two
+// people appeared on together.
+func (g *Graph) JGF(label string) JGF {
+	out := JGF{Graph: JGFGraph{
+		Label: label,
+		Type:  "co-appearance",
+		Nodes: make(map[string]JGFNode, len(g.nodes)),
+		Edges: []JGFEdge{},
+	}}
+
+	for _, n := range g.Nodes() {
+		out.Graph.Nodes[n.Id] = JGFNode{
+			Label:    n.Name,
+			Metadata: map[string]any{"podcasts": n.Podcasts, "depth": n.Depth},
+		}
+	}
+
+	for _, e := range g.Edges() {
+		podcasts := make([]jgfPodcast, len(e.Podcasts))
+		for i, id := range e.Podcasts {
+			podcasts[i] = jgfPodcast{Id: id, Title: g.titles[id]}
+		}
+
+		out.Graph.Edges = append(out.Graph.Edges, JGFEdge{
+			Source:   e.Source,
+			Target:   e.Target,
+			Relation: "appeared_with",
+			Metadata: map[string]any{"weight": e.Weight(), "podcasts": podcasts},
+		})
+	}
+
+	return out
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	Id   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	Id          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	Id   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph as GraphML. People are numbered in the order
+// of their ids, and their names kept as data.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{Id: "name", For: "node", Name: "name", Type: "string"},
+			{Id: "podcasts", For: "node", Name: "podcasts", Type: "int"},
+			{Id: "weight", For: "edge", Name: "weight", Type: "int"},
+		},
+		Graph: graphMLGraph{Id: "co-appearance", EdgeDefault: "undirected"},
+	}
+
+	ids := make(map[string]string, len(g.nodes))
+
+	for i, n := range g.Nodes() {
+		ids[n.Id] = fmt.Sprintf("n%d", i)
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			Id: ids[n.Id],
+			Data: []graphMLData{
+				{Key: "name", Value: n.Name},
+				{Key: "podcasts", Value: strconv.Itoa(n.Podcasts)},
+			},
+		})
+	}
+
+	for _, e := range g.Edges() {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: ids[e.Source],
+			Target: ids[e.Target],
+			Data:   []graphMLData{{Key: "weight", Value: strconv.Itoa(e.Weight())}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
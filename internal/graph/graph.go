@@ -0,0 +1,250 @@
+package graph
+
+import (
+	"sort"
+	"strings"
+)
+
+// Node is a person appearing on at least one podcast.
+type Node struct {
+	Id       string
+	Name     string
+	Podcasts int
+	// Depth is how many hops the node is from the person a neighbourhood was
+	// taken around. It is zero in the full graph.
+	Depth int
+}
+
+// Edge joins two people who appeared on the same podcasts.
+type Edge struct {
+	Source   string
+	Target   string
+	Podcasts []int64
+}
+
+// Weight is how many podcasts the two people appeared on together.
+func (e *Edge) Weight() int {
+	return len(e.Podcasts)
+}
+
+// Graph is an undirected co-appearance graph: people are connected when they
+// appeared on the same podcast, as host or guest.
+type Graph struct {
+	nodes  map[string]*Node
+	edges  map[string]map[string]*Edge
+	titles map[int64]string
+}
+
+func New() *Graph {
+	return &Graph{
+		nodes:  make(map[string]*Node),
+		edges:  make(map[string]map[string]*Edge),
+		titles: make(map[int64]string),
+	}
+}
+
+// Key returns the id a person's name is known by in the graph, so that
+// spelling the name with different case or spacing finds the same person.
+func Key(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// AddPodcast records that people appeared together on the podcast.
+func (g *Graph) AddPodcast(id int64, title string, people []string) {
+	g.titles[id] = title
+
+	keys := []string{}
+	seen := map[string]bool{}
+
+	for _, name := range people {
+		key := Key(name)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+
+		node, ok := g.nodes[key]
+		if !ok {
+			node = &Node{Id: key, Name: strings.Join(strings.Fields(name), " ")}
+			g.nodes[key] = node
+		}
+		node.Podcasts++
+	}
+
+	for i := range keys {
+		for j := i + 1; j < len(keys); j++ {
+			e := g.connect(keys[i], keys[j])
+			e.Podcasts = append(e.Podcasts, id)
+		}
+	}
+}
+
+// connect returns the edge between a and b, creating it if needed. Both
+// directions share the same edge.
+func (g *Graph) connect(a, b string) *Edge {
+	if e, ok := g.edges[a][b]; ok {
+		return e
+	}
+
+	source, target := a, b
+	if target < source {
+		source, target = target, source
+	}
+	e := &Edge{Source: source, Target: target}
+
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		if g.edges[pair[0]] == nil {
+			g.edges[pair[0]] = make(map[string]*Edge)
+		}
+		g.edges[pair[0]][pair[1]] = e
+	}
+
+	return e
+}
+
+// Node returns the person with the given name.
+func (g *Graph) Node(name string) (*Node, bool) {
+	n, ok := g.nodes[Key(name)]
+	return n, ok
+}
+
+// Title returns the title of a podcast in the graph.
+func (g *Graph) Title(podcastId int64) string {
+	return g.titles[podcastId]
+}
+
+// Nodes returns every person, ordered by id.
+func (g *Graph) Nodes() []*Node {
+	nodes := make([]*Node, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Id < nodes[j].Id })
+	return nodes
+}
+
+// Edges returns every connection once, ordered by source then target.
+func (g *Graph) Edges() []*Edge {
+	edges := []*Edge{}
+	for a, m := range g.edges {
+		for b, e := range m {
+			if a < b {
+				edges = append(edges, e)
+			}
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		return edges[i].Target < edges[j].Target
+	})
+	return edges
+}
+
+// neighbours returns the ids connected to id in a stable order.
+func (g *Graph) neighbours(id string) []string {
+	ids := make([]string, 0, len(g.edges[id]))
+	for n := range g.edges[id] {
+		ids = append(ids, n)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Neighbourhood returns the people within depth hops of name and the
+// connections between them. At most maxNodes people are included, nearest
+// first; truncated reports whether some were left out.
+func (g *Graph) Neighbourhood(name string, depth, maxNodes int) (sub *Graph, truncated bool, ok bool) {
+	root, ok := g.nodes[Key(name)]
+	if !ok {
+		return nil, false, false
+	}
+
+	sub = New()
+	sub.nodes[root.Id] = &Node{Id: root.Id, Name: root.Name, Podcasts: root.Podcasts}
+
+	frontier := []string{root.Id}
+
+	for d := 1; d <= depth && len(frontier) > 0; d++ {
+		next := []string{}
+		for _, id := range frontier {
+			for _, n := range g.neighbours(id) {
+				if _, seen := sub.nodes[n]; seen {
+					continue
+				}
+				if len(sub.nodes) >= maxNodes {
+					truncated = true
+					break
+				}
+				node := g.nodes[n]
+				sub.nodes[n] = &Node{Id: node.Id, Name: node.Name, Podcasts: node.Podcasts, Depth: d}
+				next = append(next, n)
+			}
+		}
+		frontier = next
+	}
+
+	for a := range sub.nodes {
+		for b, e := range g.edges[a] {
+			if _, in := sub.nodes[b]; !in || a > b {
+				continue
+			}
+			edge := sub.connect(a, b)
+			edge.Podcasts = e.Podcasts
+			for _, id := range e.Podcasts {
+				sub.titles[id] = g.titles[id]
+			}
+		}
+	}
+
+	return sub, truncated, true
+}
+
+// Hop is a step along a path: the person reached and the podcasts they shared
+// with the person before them.
+type Hop struct {
+	Node     *Node
+	Podcasts []int64
+}
+
+// ShortestPath returns the fewest hops from one person to another, starting
+// with from itself. ok is false when either is unknown or they are not
+// connected.
+func (g *Graph) ShortestPath(from, to string) (path []Hop, ok bool) {
+	start, end := Key(from), Key(to)
+	if g.nodes[start] == nil || g.nodes[end] == nil {
+		return nil, false
+	}
+
+	parent := map[string]string{start: ""}
+	queue := []string{start}
+
+	for len(queue) > 0 && parent[end] == "" && start != end {
+		id := queue[0]
+		queue = queue[1:]
+
+		for _, n := range g.neighbours(id) {
+			if _, seen := parent[n]; seen {
+				continue
+			}
+			parent[n] = id
+			queue = append(queue, n)
+		}
+	}
+
+	if _, reached := parent[end]; !reached {
+		return nil, false
+	}
+
+	for id := end; id != ""; id = parent[id] {
+		hop := Hop{Node: g.nodes[id]}
+		if p := parent[id]; p != "" {
+			hop.Podcasts = g.edges[p][id].Podcasts
+		}
+		path = append([]Hop{hop}, path...)
+	}
+
+	return path, true
+}
@@ -0,0 +1,287 @@
+package graph
+
+import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testGraph connects Ana, Budi and Citra on one podcast, chains Budi to Dedi
+// and Dedi to Eka, and leaves Fajar on a podcast of their own.
+func testGraph() *Graph {
+	g := New()
+	g.AddPodcast(1, "Satu", []string{"Ana", "Budi", "Citra"})
+	g.AddPodcast(2, "Dua", []string{"budi", "Dedi"})
+	g.AddPodcast(3, "Tiga", []string{"Dedi", "Eka", " eka "})
+	g.AddPodcast(4, "Empat", []string{"Ana", "BUDI"})
+	g.AddPodcast(5, "Lima", []string{"Fajar"})
+	return g
+}
+
+func edgeKeys(g *Graph) []string {
+	keys := []string{}
+	for _, e := range g.Edges() {
+		keys = append(keys, e.Source+"-"+e.Target)
+	}
+	return keys
+}
+
+func TestAddPodcast(t *testing.T) {
+	g := testGraph()
+
+	wantNodes := map[string]int{"ana": 2, "budi": 3, "citra": 1, "dedi": 2, "eka": 1, "fajar": 1}
+	nodes := g.Nodes()
+	if len(nodes) != len(wantNodes) {
+		t.Fatalf("got %d nodes, want %d", len(nodes), len(wantNodes))
+	}
+	for _, n := range nodes {
+		if n.Podcasts != wantNodes[n.Id] {
+			t.Errorf("%s appeared on %d podcasts, want %d", n.Id, n.Podcasts, wantNodes[n.Id])
+		}
+	}
+
+	if n, ok := g.Node("  BUDI "); !ok || n.Name != "Budi" {
+		t.Errorf("Node(%q) = %+v, %v, want Budi", "  BUDI ", n, ok)
+	}
+
+	wantEdges := []string{"ana-budi", "ana-citra", "budi-citra", "budi-dedi", "dedi-eka"}
+	if got := edgeKeys(g); !reflect.DeepEqual(got, wantEdges) {
+		t.Errorf("edges = %v, want %v", got, wantEdges)
+	}
+
+	if e := g.edges["budi"]["ana"]; e == nil || e.Weight() != 2 || !reflect.DeepEqual(e.Podcasts, []int64{1, 4}) {
+		t.Errorf("ana-budi edge = %+v, want podcasts 1 and 4", e)
+	}
+}
+
+func TestNeighbourhood(t *testing.T) {
+	tests := []struct {
+		name      string
+		person    string
+		depth     int
+		maxNodes  int
+		wantDepth map[string]int
+		wantEdges []string
+		truncated bool
+		ok        bool
+	}{
+		{
+			name: "depth 1", person: "Ana", depth: 1, maxNodes: 10,
+			wantDepth: map[string]int{"ana": 0, "budi": 1, "citra": 1},
+			wantEdges: []string{"ana-budi", "ana-citra", "budi-citra"},
+			ok:        true,
+		},
+		{
+			name: "depth 2", person: " ana ", depth: 2, maxNodes: 10,
+			wantDepth: map[string]int{"ana": 0, "budi": 1, "citra": 1, "dedi": 2},
+			wantEdges: []string{"ana-budi", "ana-citra", "budi-citra", "budi-dedi"},
+			ok:        true,
+		},
+		{
+			name: "depth beyond the component", person: "Ana", depth: 5, maxNodes: 10,
+			wantDepth: map[string]int{"ana": 0, "budi": 1, "citra": 1, "dedi": 2, "eka": 3},
+			wantEdges: []string{"ana-budi", "ana-citra", "budi-citra", "budi-dedi", "dedi-eka"},
+			ok:        true,
+		},
+		{
+			name: "truncated nearest first", person: "Ana", depth: 3, maxNodes: 3,
+			wantDepth: map[string]int{"ana": 0, "budi": 1, "citra": 1},
+			wantEdges: []string{"ana-budi", "ana-citra", "budi-citra"},
+			truncated: true,
+			ok:        true,
+		},
+		{
+			name: "exactly at the cap", person: "Dedi", depth: 1, maxNodes: 3,
+			wantDepth: map[string]int{"dedi": 0, "budi": 1, "eka": 1},
+			wantEdges: []string{"budi-dedi", "dedi-eka"},
+			ok:        true,
+		},
+		{
+			name: "alone", person: "Fajar", depth: 2, maxNodes: 10,
+			wantDepth: map[string]int{"fajar": 0},
+			wantEdges: []string{},
+			ok:        true,
+		},
+		{
+			name: "unknown", person: "Gita", depth: 1, maxNodes: 10,
+		},
+	}
+
+	g := testGraph()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, truncated, ok := g.Neighbourhood(tt.person, tt.depth, tt.maxNodes)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if truncated != tt.truncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.truncated)
+			}
+
+			depths := map[string]int{}
+			for _, n := range sub.Nodes() {
+				depths[n.Id] = n.Depth
+			}
+			if !reflect.DeepEqual(depths, tt.wantDepth) {
+				t.Errorf("depths = %v, want %v", depths, tt.wantDepth)
+			}
+			if got := edgeKeys(sub); !reflect.DeepEqual(got, tt.wantEdges) {
+				t.Errorf("edges = %v, want %v", got, tt.wantEdges)
+			}
+			for _, e := range sub.Edges() {
+				for _, id := range e.Podcasts {
+					if sub.Title(id) != g.Title(id) {
+						t.Errorf("title of podcast %d = %q, want %q", id, sub.Title(id), g.Title(id))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	type hop struct {
+		id       string
+		podcasts []int64
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		want     []hop
+		ok       bool
+	}{
+		{
+			name: "several hops", from: "Ana", to: "Eka",
+			want: []hop{{"ana", nil}, {"budi", []int64{1, 4}}, {"dedi", []int64{2}}, {"eka", []int64{3}}},
+			ok:   true,
+		},
+		{
+			name: "reverse", from: "eka", to: "CITRA",
+			want: []hop{{"eka", nil}, {"dedi", []int64{3}}, {"budi", []int64{2}}, {"citra", []int64{1}}},
+			ok:   true,
+		},
+		{
+			name: "neighbours", from: "Ana", to: "Citra",
+			want: []hop{{"ana", nil}, {"citra", []int64{1}}},
+			ok:   true,
+		},
+		{
+			name: "same person", from: "Ana", to: "ana",
+			want: []hop{{"ana", nil}},
+			ok:   true,
+		},
+		{name: "not connected", from: "Ana", to: "Fajar"},
+		{name: "unknown start", from: "Gita", to: "Ana"},
+		{name: "unknown end", from: "Ana", to: "Gita"},
+	}
+
+	g := testGraph()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, ok := g.ShortestPath(tt.from, tt.to)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+
+			got := []hop{}
+			for _, h := range path {
+				got = append(got, hop{h.Node.Id, h.Podcasts})
+			}
+			if tt.want == nil {
+				tt.want = []hop{}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("path = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJGF(t *testing.T) {
+	sub, _, _ := testGraph().Neighbourhood("Citra", 1, 10)
+	out := sub.JGF("Citra")
+
+	if out.Graph.Label != "Citra" || out.Graph.Type != "co-appearance" || out.Graph.Directed {
+		t.Errorf("graph = %q, %q, directed %v", out.Graph.Label, out.Graph.Type, out.Graph.Directed)
+	}
+
+	wantNodes := map[string]JGFNode{
+		"ana":   {Label: "Ana", Metadata: map[string]any{"podcasts": 2, "depth": 1}},
+		"budi":  {Label: "Budi", Metadata: map[string]any{"podcasts": 3, "depth": 1}},
+		"citra": {Label: "Citra", Metadata: map[string]any{"podcasts": 1, "depth": 0}},
+	}
+	if !reflect.DeepEqual(out.Graph.Nodes, wantNodes) {
+		t.Errorf("nodes = %v, want %v", out.Graph.Nodes, wantNodes)
+	}
+
+	wantEdges := []JGFEdge{
+		{
+			Source: "ana", Target: "budi", Relation: "appeared_with",
+			Metadata: map[string]any{"weight": 2, "podcasts": []jgfPodcast{{1, "Satu"}, {4, "Empat"}}},
+		},
+		{
+			Source: "ana", Target: "citra", Relation: "appeared_with",
+			Metadata: map[string]any{"weight": 1, "podcasts": []jgfPodcast{{1, "Satu"}}},
+		},
+		{
+			Source: "budi", Target: "citra", Relation: "appeared_with",
+			Metadata: map[string]any{"weight": 1, "podcasts": []jgfPodcast{{1, "Satu"}}},
+		},
+	}
+	if !reflect.DeepEqual(out.Graph.Edges, wantEdges) {
+		t.Errorf("edges = %+v, want %+v", out.Graph.Edges, wantEdges)
+	}
+
+	if empty := New().JGF(""); empty.Graph.Edges == nil || len(empty.Graph.Nodes) != 0 {
+		t.Errorf("empty graph = %+v, want no nodes and an empty edge list", empty.Graph)
+	}
+}
+
+func TestWriteGraphML(t *testing.T) {
+	g := New()
+	g.AddPodcast(1, "Satu", []string{"Budi", "Ana & Co"})
+	g.AddPodcast(2, "Dua", []string{"Budi", "ana & co"})
+
+	var buf bytes.Buffer
+	if err := g.WriteGraphML(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("output does not start with the XML header:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "Ana &amp; Co") {
+		t.Errorf("names are not escaped:\n%s", buf.String())
+	}
+
+	var doc graphML
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := graphMLGraph{
+		Id:          "co-appearance",
+		EdgeDefault: "undirected",
+		Nodes: []graphMLNode{
+			{Id: "n0", Data: []graphMLData{{Key: "name", Value: "Ana & Co"}, {Key: "podcasts", Value: "2"}}},
+			{Id: "n1", Data: []graphMLData{{Key: "name", Value: "Budi"}, {Key: "podcasts", Value: "2"}}},
+		},
+		Edges: []graphMLEdge{
+			{Source: "n0", Target: "n1", Data: []graphMLData{{Key: "weight", Value: "2"}}},
+		},
+	}
+	if !reflect.DeepEqual(doc.Graph, want) {
+		t.Errorf("graph = %+v, want %+v", doc.Graph, want)
+	}
+	if len(doc.Keys) != 3 || doc.Xmlns != "http://graphml.graphdrawing.org/xmlns" {
+		t.Errorf("keys = %+v, xmlns = %q", doc.Keys, doc.Xmlns)
+	}
+}
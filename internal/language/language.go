@@ -0,0 +1,246 @@
+// Package language validates and normalizes podcast languages against an
+// embedded ISO 639-1/639-3 table.
+package language
+
+import (
+	"bufio"
+	_ "embed"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed iso639.tsv
+var iso639 string
+
+type Language struct {
+	// Code is the ISO 639-1 code when the language has one and the ISO 639-3
+	// code otherwise.
+	Code    string `json:"code"`
+	ISO6393 string `json:"iso639_3"`
+	Name    string `json:"name"`
+}
+
+var (
+	languages []Language
+	byCode    = map[string]int{}
+	byName    = map[string]int{}
+)
+
+// aliases are names commonly used for a language that differ from its English
+// name in the table.
+var aliases = map[string]string{
+	"bahasa":           "id",
+	"bahasa indonesia": "id",
+	"indonesia":        "id",
+	"bahasa melayu":    "ms",
+	"melayu":           "ms",
+	"basa jawa":        "jv",
+	"jawa":             "jv",
+	"basa sunda":       "su",
+	"sunda":            "su",
+	"bengali":          "bn",
+	"farsi":            "fa",
+	"mandarin":         "zh",
+	"castilian":        "es",
+	"flemish":          "nl",
+}
+
+// bibliographic maps the ISO 639-2/B codes that differ from the terminology
+// codes used in ISO 639-3 onto the latter, so that "ger" is German rather than
+// a language of its own.
+var bibliographic = map[string]string{
+	"alb": "sqi",
+	"arm": "hye",
+	"baq": "eus",
+	"bur": "mya",
+	"chi": "zho",
+	"cze": "ces",
+	"dut": "nld",
+	"fre": "fra",
+	"geo": "kat",
+	"ger": "deu",
+	"gre": "ell",
+	"ice": "isl",
+	"mac": "mkd",
+	"mao": "mri",
+	"may": "msa",
+	"per": "fas",
+	"rum": "ron",
+	"slo": "slk",
+	"tib": "bod",
+	"wel": "cym",
+}
+
+func init() {
+	s := bufio.NewScanner(strings.NewReader(iso639))
+
+	for s.Scan() {
+		line := s.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 || bibliographic[fields[0]] != "" {
+			continue
+		}
+
+		lang := Language{Code: fields[1], ISO6393: fields[0], Name: fields[2]}
+		if lang.Code == "" {
+			lang.Code = lang.ISO6393
+		}
+		if lang.Name == "" {
+			lang.Name = lang.ISO6393
+		}
+
+		i := len(languages)
+		languages = append(languages, lang)
+
+		byCode[lang.ISO6393] = i
+		byCode[lang.Code] = i
+
+		// Several individual languages share a name with their macrolanguage;
+		// the one with a 639-1 code wins.
+		name := strings.ToLower(lang.Name)
+		if j, ok := byName[name]; !ok || (len(languages[j].Code) != 2 && len(lang.Code) == 2) {
+			byName[name] = i
+		}
+	}
+
+	for alias, code := range aliases {
+		byName[alias] = byCode[code]
+	}
+	for b, t := range bibliographic {
+		byCode[b] = byCode[t]
+	}
+}
+
+// Lookup finds the language named by s, which may be an ISO 639-1, 639-2 or
+// 639-3 code, a BCP 47 tag such as "en-US" or "zh_Hant_TW" whose primary
+// subtag is used, or an English name such as "English". Codes win over names,
+// so "en" is English and not the language called En. Case is ignored.
+func Lookup(s string) (Language, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Language{}, false
+	}
+
+	if i, ok := byCode[s]; ok {
+		return languages[i], true
+	}
+	if i, ok := byName[s]; ok {
+		return languages[i], true
+	}
+
+	primary, ok := primarySubtag(s)
+	if !ok {
+		return Language{}, false
+	}
+
+	i, ok := byCode[primary]
+	if !ok {
+		return Language{}, false
+	}
+
+	return languages[i], true
+}
+
+// Normalize returns the code of the language named by s.
+func Normalize(s string) (string, bool) {
+	lang, ok := Lookup(s)
+	return lang.Code, ok
+}
+
+// Valid reports whether code is the normalized code of a known language.
+func Valid(code string) bool {
+	i, ok := byCode[code]
+	return ok && languages[i].Code == code
+}
+
+// Name returns the English name of the language with the given code, or code
+// itself if it is not known.
+func Name(code string) string {
+	if i, ok := byCode[code]; ok {
+		return languages[i].Name
+	}
+	return code
+}
+
+// primarySubtag returns the language subtag of a BCP 47 tag after checking the
+// tag is well formed: 2 or 3 letters followed by subtags of 1 to 8 letters or
+// digits.
+func primarySubtag(tag string) (string, bool) {
+	subtags := strings.Split(strings.ReplaceAll(tag, "_", "-"), "-")
+
+	for i, subtag := range subtags {
+		if len(subtag) < 1 || len(subtag) > 8 {
+			return "", false
+		}
+		for _, r := range subtag {
+			letter := r >= 'a' && r <= 'z'
+			if !letter && (i == 0 || r < '0' || r > '9') {
+				return "", false
+			}
+		}
+	}
+
+	if len(subtags[0]) < 2 || len(subtags[0]) > 3 {
+		return "", false
+	}
+
+	return subtags[0], true
+}
+
+// ParseAcceptLanguage returns the codes of the known languages an
+// Accept-Language header asks for, most preferred first. Ranges with a
+// quality of zero, the "*" wildcard and unknown languages are left out; a
+// language asked for more than once, for example as "en" and "en-US", is
+// ranked by the highest quality it was given.
+func ParseAcceptLanguage(header string) []string {
+	type ranged struct {
+		code string
+		q    float64
+	}
+
+	var ranges []ranged
+	index := map[string]int{}
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(name), "q") {
+				parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+				if err != nil || parsed < 0 || parsed > 1 {
+					parsed = 0
+				}
+				q = parsed
+			}
+		}
+
+		code, ok := Normalize(tag)
+		if !ok || q == 0 {
+			continue
+		}
+
+		if i, seen := index[code]; seen {
+			ranges[i].q = max(ranges[i].q, q)
+			continue
+		}
+		index[code] = len(ranges)
+
+		ranges = append(ranges, ranged{code: code, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	codes := make([]string, len(ranges))
+	for i, r := range ranges {
+		codes[i] = r.code
+	}
+
+	return codes
+}
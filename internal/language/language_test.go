@@ -0,0 +1,166 @@
+package language
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Language
+		ok   bool
+	}{
+		{in: "id", want: Language{Code: "id", ISO6393: "ind", Name: "Indonesian"}, ok: true},
+		{in: "IND", want: Language{Code: "id", ISO6393: "ind", Name: "Indonesian"}, ok: true},
+		{in: " jv ", want: Language{Code: "jv", ISO6393: "jav", Name: "Javanese"}, ok: true},
+		{in: "bew", want: Language{Code: "bew", ISO6393: "bew", Name: "Betawi"}, ok: true},
+		{in: "en", want: Language{Code: "en", ISO6393: "eng", Name: "English"}, ok: true},
+		{in: "en-US", want: Language{Code: "en", ISO6393: "eng", Name: "English"}, ok: true},
+		{in: "zh_Hant_TW", want: Language{Code: "zh", ISO6393: "zho", Name: "Chinese"}, ok: true},
+		{in: "sr-Latn-RS", want: Language{Code: "sr", ISO6393: "srp", Name: "Serbian"}, ok: true},
+		{in: "de-1996", want: Language{Code: "de", ISO6393: "deu", Name: "German"}, ok: true},
+		{in: "ger", want: Language{Code: "de", ISO6393: "deu", Name: "German"}, ok: true},
+		{in: "chi", want: Language{Code: "zh", ISO6393: "zho", Name: "Chinese"}, ok: true},
+		{in: "may", want: Language{Code: "ms", ISO6393: "msa", Name: "Malay"}, ok: true},
+		{in: "English", want: Language{Code: "en", ISO6393: "eng", Name: "English"}, ok: true},
+		{in: "chinese", want: Language{Code: "zh", ISO6393: "zho", Name: "Chinese"}, ok: true},
+		{in: "Malay", want: Language{Code: "ms", ISO6393: "msa", Name: "Malay"}, ok: true},
+		{in: "Bahasa Indonesia", want: Language{Code: "id", ISO6393: "ind", Name: "Indonesian"}, ok: true},
+		{in: "Farsi", want: Language{Code: "fa", ISO6393: "fas", Name: "Persian"}, ok: true},
+		{in: "", ok: false},
+		{in: "xx", ok: false},
+		{in: "engl", ok: false},
+		{in: "en-", ok: false},
+		{in: "en-toolongsubtag", ok: false},
+		{in: "1en", ok: false},
+		{in: "Klingonese", ok: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Lookup(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Lookup(%q) = %+v, %v, want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"id", "id", true},
+		{"ind", "id", true},
+		{"pt-BR", "pt", true},
+		{"fre", "fr", true},
+		{"Sundanese", "su", true},
+		{"Betawi", "bew", true},
+		{"tlh-x", "tlh", true},
+		{"qq", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := Normalize(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Normalize(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPrimarySubtag(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"en", "en", true},
+		{"eng", "eng", true},
+		{"en-us", "en", true},
+		{"zh_hant_tw", "zh", true},
+		{"es-419", "es", true},
+		{"en-a-bbb-x-ccc", "en", true},
+		{"e", "", false},
+		{"engl", "", false},
+		{"en--us", "", false},
+		{"en-us-", "", false},
+		{"en-abcdefghi", "", false},
+		{"e1", "", false},
+		{"en us", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := primarySubtag(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("primarySubtag(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"id", "Indonesian"},
+		{"ind", "Indonesian"},
+		{"bew", "Betawi"},
+		{"ger", "German"},
+		{"unknown", "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := Name(tt.code); got != tt.want {
+			t.Errorf("Name(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"id", true},
+		{"bew", true},
+		{"ind", false},
+		{"ger", false},
+		{"ID", false},
+		{"xx", false},
+	}
+
+	for _, tt := range tests {
+		if got := Valid(tt.code); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"id", []string{"id"}},
+		{"id-ID,jv;q=0.9,su;q=0.8", []string{"id", "jv", "su"}},
+		{"su;q=0.5, ja;q=0.9", []string{"ja", "su"}},
+		{"jv, su;q=1", []string{"jv", "su"}},
+		{"en-US,en;q=0.9,id;q=0.8", []string{"en", "id"}},
+		{"en;q=0.1, en-US", []string{"en"}},
+		{"en;q=0.1, id;q=0.5, en-GB;q=0.8", []string{"en", "id"}},
+		{"id;q=0.8, en-US;q=0.8, en;q=0.8", []string{"id", "en"}},
+		{"fr;q=0, de", []string{"de"}},
+		{"*, ms;q=0.2", []string{"ms"}},
+		{"xx, indonesian;q=0.3", []string{"id"}},
+		{"ja;q=abc, id;q=2, ms; q = 0.4", []string{"ms"}},
+		{"ind;Q=0.7", []string{"id"}},
+	}
+
+	for _, tt := range tests {
+		if got := ParseAcceptLanguage(tt.header); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package linkcheck
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Result struct {
+	Url        string
+	StatusCode int
+	Err        error
+}
+
+// Broken reports whether the link could not be fetched or answered with an
+// error status once redirects were followed.
+func (r Result) Broken() bool {
+	return r.Err != nil || r.StatusCode >= 400
+}
+
+type Checker struct {
+	client      *http.Client
+	concurrency int
+	userAgent   string
+}
+
+func New(timeout time.Duration, concurrency int, userAgent string) *Checker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Checker{
+		client:      &http.Client{Timeout: timeout},
+		concurrency: concurrency,
+		userAgent:   userAgent,
+	}
+}
+
+// Check sends a HEAD request to url, falling back to GET for servers that do
+// not allow HEAD.
+func (c *Checker) Check(ctx context.Context, url string) Result {
+	res := c.do(ctx, http.MethodHead, url)
+	if res.Err == nil && (res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusNotImplemented) {
+		res = c.do(ctx, http.MethodGet, url)
+	}
+	return res
+}
+
+// CheckAll checks every url with at most the configured number of requests in
+// flight. Results are returned in the order of urls.
+func (c *Checker) CheckAll(ctx context.Context, urls []string) []Result {
+	results := make([]Result, len(urls))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.concurrency)
+
+	for i, url := range urls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, url string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = c.Check(ctx, url)
+		}(i, url)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+func (c *Checker) do(ctx context.Context, method, url string) Result {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return Result{Url: url, Err: err}
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return Result{Url: url, Err: err}
+	}
+	defer res.Body.Close()
+
+	// Drain a little of the body so the connection can be reused.
+	io.CopyN(io.Discard, res.Body, 4096)
+
+	return Result{Url: url, StatusCode: res.StatusCode}
+}
@@ -0,0 +1,106 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/gone":
+			http.Redirect(w, r, "/missing", http.StatusFound)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Write([]byte("hello"))
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		statusCode int
+		broken     bool
+	}{
+		{name: "ok", url: srv.URL + "/ok", statusCode: http.StatusOK},
+		{name: "redirect to ok", url: srv.URL + "/moved", statusCode: http.StatusOK},
+		{name: "redirect to missing", url: srv.URL + "/gone", statusCode: http.StatusNotFound, broken: true},
+		{name: "head not allowed", url: srv.URL + "/no-head", statusCode: http.StatusOK},
+		{name: "server error", url: srv.URL + "/error", statusCode: http.StatusInternalServerError, broken: true},
+		{name: "unreachable", url: "http://127.0.0.1:1/", broken: true},
+		{name: "invalid url", url: "http://%zz", broken: true},
+	}
+
+	checker := New(2*time.Second, 1, "ipdb-test")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := checker.Check(context.Background(), tt.url)
+			if res.StatusCode != tt.statusCode {
+				t.Errorf("StatusCode = %d, want %d", res.StatusCode, tt.statusCode)
+			}
+			if res.Broken() != tt.broken {
+				t.Errorf("Broken() = %v, want %v (err %v)", res.Broken(), tt.broken, res.Err)
+			}
+			if res.Url != tt.url {
+				t.Errorf("Url = %q, want %q", res.Url, tt.url)
+			}
+		})
+	}
+}
+
+func TestCheckAll(t *testing.T) {
+	var inFlight, peak atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	urls := []string{srv.URL + "/a", srv.URL + "/missing", srv.URL + "/b", srv.URL + "/c", srv.URL + "/d"}
+
+	results := New(2*time.Second, 2, "ipdb-test").CheckAll(context.Background(), urls)
+
+	if len(results) != len(urls) {
+		t.Fatalf("got %d results, want %d", len(results), len(urls))
+	}
+	for i, res := range results {
+		if res.Url != urls[i] {
+			t.Errorf("results[%d].Url = %q, want %q", i, res.Url, urls[i])
+		}
+		if want := urls[i] == srv.URL+"/missing"; res.Broken() != want {
+			t.Errorf("results[%d].Broken() = %v, want %v", i, res.Broken(), want)
+		}
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("%d requests in flight, want at most 2", p)
+	}
+}
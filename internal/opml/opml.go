@@ -0,0 +1,164 @@
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+type Document struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+	Docs        string `xml:"docs,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	URL      string    `xml:"url,attr,omitempty"`
+	Language string    `xml:"language,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+func New(title string) *Document {
+	return &Document{
+		Version: "2.0",
+		Head:    newHead(title),
+	}
+}
+
+func newHead(title string) Head {
+	return Head{
+		Title:       title,
+		DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		Docs:        "http://opml.org/spec2.opml",
+	}
+}
+
+func Parse(r io.Reader) (*Document, error) {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = charset.NewReaderLabel
+	dec.Strict = false
+
+	var doc Document
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+func (d *Document) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(d); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Encoder writes an OPML document one outline at a time, for lists too long to
+// build in memory first.
+type Encoder struct {
+	w   io.Writer
+	enc *xml.Encoder
+}
+
+var (
+	opmlStart = xml.StartElement{Name: xml.Name{Local: "opml"}, Attr: []xml.Attr{{Name: xml.Name{Local: "version"}, Value: "2.0"}}}
+	bodyStart = xml.StartElement{Name: xml.Name{Local: "body"}}
+)
+
+// NewEncoder writes the start of a document titled title to w.
+func NewEncoder(w io.Writer, title string) (*Encoder, error) {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return nil, err
+	}
+
+	e := &Encoder{w: w, enc: xml.NewEncoder(w)}
+	e.enc.Indent("", "  ")
+
+	if err := e.enc.EncodeToken(opmlStart); err != nil {
+		return nil, err
+	}
+	if err := e.enc.EncodeElement(newHead(title), xml.StartElement{Name: xml.Name{Local: "head"}}); err != nil {
+		return nil, err
+	}
+	if err := e.enc.EncodeToken(bodyStart); err != nil {
+		return nil, err
+	}
+
+	return e, e.enc.Flush()
+}
+
+// Encode writes o to the document's body.
+func (e *Encoder) Encode(o Outline) error {
+	return e.enc.EncodeElement(o, xml.StartElement{Name: xml.Name{Local: "outline"}})
+}
+
+// Close writes the end of the document.
+func (e *Encoder) Close() error {
+	if err := e.enc.EncodeToken(bodyStart.End()); err != nil {
+		return err
+	}
+	if err := e.enc.EncodeToken(opmlStart.End()); err != nil {
+		return err
+	}
+	if err := e.enc.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(e.w, "\n")
+	return err
+}
+
+// Flatten returns every outline that points at a feed or a site, skipping the
+// category outlines that only group others.
+func (d *Document) Flatten() []Outline {
+	var out []Outline
+
+	var walk func([]Outline)
+	walk = func(outlines []Outline) {
+		for _, o := range outlines {
+			children := o.Outlines
+			if o.XMLURL != "" || o.HTMLURL != "" || o.URL != "" {
+				o.Outlines = nil
+				out = append(out, o)
+			}
+			walk(children)
+		}
+	}
+	walk(d.Body.Outlines)
+
+	return out
+}
+
+func (o Outline) Name() string {
+	if t := strings.TrimSpace(o.Title); t != "" {
+		return t
+	}
+	return strings.TrimSpace(o.Text)
+}
@@ -0,0 +1,165 @@
+package opml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		title string
+		want  []Outline
+	}{
+		{
+			name: "flat",
+			body: `<?xml version="1.0"?>
+<opml version="2.0">
+  <head><title>Mine</title></head>
+  <body>
+    <outline text="A" type="rss" xmlUrl="https://a.example/feed" htmlUrl="https://a.example/"/>
+    <outline text="B" title="Bee" type="link" url="https://b.example/"/>
+  </body>
+</opml>`,
+			title: "Mine",
+			want: []Outline{
+				{Text: "A", Type: "rss", XMLURL: "https://a.example/feed", HTMLURL: "https://a.example/"},
+				{Text: "B", Title: "Bee", Type: "link", URL: "https://b.example/"},
+			},
+		},
+		{
+			name: "latin-1 with an unclosed outline",
+			body: "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>\n" +
+				"<opml version=\"1.0\"><head><title>Caf\xe9</title></head><body>" +
+				"<outline text=\"Caf\xe9\" xmlUrl=\"https://c.example/feed\"></body></opml>",
+			title: "Café",
+			want: []Outline{
+				{Text: "Café", XMLURL: "https://c.example/feed"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse(strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if doc.Head.Title != tt.title {
+				t.Errorf("title = %q, want %q", doc.Head.Title, tt.title)
+			}
+			if !reflect.DeepEqual(doc.Body.Outlines, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", doc.Body.Outlines, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	doc := &Document{Body: Body{Outlines: []Outline{
+		{Text: "News", Outlines: []Outline{
+			{Text: "A", XMLURL: "https://a.example/feed"},
+			{Text: "Local", Outlines: []Outline{
+				{Text: "B", HTMLURL: "https://b.example/"},
+			}},
+		}},
+		{Text: "C", XMLURL: "https://c.example/feed", Outlines: []Outline{
+			{Text: "C extra", URL: "https://c.example/extra"},
+		}},
+		{Text: "Empty category"},
+	}}}
+
+	want := []Outline{
+		{Text: "A", XMLURL: "https://a.example/feed"},
+		{Text: "B", HTMLURL: "https://b.example/"},
+		{Text: "C", XMLURL: "https://c.example/feed"},
+		{Text: "C extra", URL: "https://c.example/extra"},
+	}
+
+	if got := doc.Flatten(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		outline Outline
+		want    string
+	}{
+		{Outline{Text: "text", Title: "title"}, "title"},
+		{Outline{Text: " text ", Title: "  "}, "text"},
+		{Outline{}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.outline.Name(); got != tt.want {
+			t.Errorf("%+v.Name() = %q, want %q", tt.outline, got, tt.want)
+		}
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	outlines := []Outline{
+		{Text: "A & B", Title: "A & B", Type: "rss", XMLURL: "https://a.example/feed?x=1&y=2", Language: "en"},
+		{Text: "C", Type: "link", URL: "https://c.example/"},
+	}
+
+	tests := []struct {
+		name     string
+		outlines []Outline
+	}{
+		{name: "empty", outlines: nil},
+		{name: "outlines", outlines: outlines},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc, err := NewEncoder(&buf, "Export")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, o := range tt.outlines {
+				if err := enc.Encode(o); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := enc.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			doc, err := Parse(&buf)
+			if err != nil {
+				t.Fatalf("%v in\n%s", err, buf.String())
+			}
+			if doc.Version != "2.0" || doc.Head.Title != "Export" || doc.Head.DateCreated == "" {
+				t.Errorf("head = %q %+v", doc.Version, doc.Head)
+			}
+			if !reflect.DeepEqual(doc.Body.Outlines, tt.outlines) {
+				t.Errorf("got  %+v\nwant %+v", doc.Body.Outlines, tt.outlines)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	doc := New("Mine")
+	doc.Body.Outlines = []Outline{{Text: "A", XMLURL: "https://a.example/feed"}}
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Body, doc.Body) || got.Head != doc.Head {
+		t.Errorf("got  %+v\nwant %+v", got, doc)
+	}
+}
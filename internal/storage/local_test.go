@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := NewLocal(filepath.Join(dir, "files"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		key         string
+		data        string
+		contentType string
+	}{
+		{name: "nested", key: "artwork/abc/600.jpg", data: "jpeg", contentType: "image/jpeg"},
+		{name: "leading slash", key: "/artwork/abc/300.png", data: "png", contentType: "image/png"},
+		{name: "unknown extension", key: "misc/blob", data: "blob", contentType: "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := store.Put(ctx, tt.key, []byte(tt.data), tt.contentType); err != nil {
+				t.Fatal(err)
+			}
+
+			obj, err := store.Open(ctx, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(obj.Body)
+			obj.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(body) != tt.data || obj.Size != int64(len(tt.data)) || obj.ContentType != tt.contentType {
+				t.Errorf("got %q, %d bytes, %q; want %q, %d bytes, %q",
+					body, obj.Size, obj.ContentType, tt.data, len(tt.data), tt.contentType)
+			}
+
+			if err := store.Delete(ctx, tt.key); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Open(ctx, tt.key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Open after Delete err = %v, want %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestLocalReplace(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := NewLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range []string{"first", "second"} {
+		if err := store.Put(ctx, "a/b.txt", []byte(data), "text/plain"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "a", "b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "second" {
+		t.Errorf("got %q, want %q", got, "second")
+	}
+
+	// Nothing but the file itself is left behind by the upload.
+	entries, err := os.ReadDir(filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("got %d entries, want 1", len(entries))
+	}
+}
+
+func TestLocalErrors(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := NewLocal(filepath.Join(dir, "store"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Put(ctx, "dir/file", []byte("x"), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		err  error
+	}{
+		{name: "missing", key: "nope.jpg", err: ErrNotFound},
+		{name: "directory", key: "dir", err: ErrNotFound},
+		{name: "outside the store", key: "../secret", err: ErrInvalidKey},
+		{name: "empty", key: "", err: ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := store.Open(ctx, tt.key); !errors.Is(err, tt.err) {
+				t.Errorf("Open err = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	if err := store.Put(ctx, "../secret", []byte("y"), ""); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Put outside the store err = %v, want %v", err, ErrInvalidKey)
+	}
+	if err := store.Delete(ctx, "missing.jpg"); err != nil {
+		t.Errorf("Delete of a missing key err = %v, want nil", err)
+	}
+}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testAccessKey = "AKIDEXAMPLE"
+	testSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	testRegion    = "us-east-1"
+)
+
+// fakeS3 is a bucket kept in memory that, like S3, rejects requests whose
+// signature does not match the one it computes itself.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+type fakeObject struct {
+	data        []byte
+	contentType string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	if err := verifySignature(r, body); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := r.URL.Path
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[key] = fakeObject{data: body, contentType: r.Header.Get("Content-Type")}
+	case http.MethodGet:
+		obj, ok := f.objects[key]
+		if !ok {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Write(obj.data)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// verifySignature recomputes the Signature Version 4 of r from what the server
+// received and compares it with the one in the Authorization header.
+func verifySignature(r *http.Request, body []byte) error {
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash != sha256Hex(body) {
+		return errors.New("payload hash mismatch")
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	if len(amzDate) < 8 {
+		return errors.New("missing date")
+	}
+	scope := amzDate[:8] + "/" + testRegion + "/s3/aws4_request"
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		"host:" + r.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		"host;x-amz-content-sha256;x-amz-date",
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+testSecretKey), amzDate[:8])
+	key = hmacSHA256(key, testRegion)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	want := fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=%x",
+		testAccessKey, scope, hmacSHA256(key, stringToSign))
+
+	if !hmac.Equal([]byte(r.Header.Get("Authorization")), []byte(want)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+func TestS3(t *testing.T) {
+	ctx := context.Background()
+
+	bucket := &fakeS3{objects: make(map[string]fakeObject)}
+	srv := httptest.NewServer(bucket)
+	defer srv.Close()
+
+	store, err := NewS3(srv.URL+"/base/", testRegion, "media", testAccessKey, testSecretKey, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		key         string
+		path        string
+		data        string
+		contentType string
+	}{
+		{name: "plain", key: "artwork/abc/600.jpg", path: "/base/media/artwork/abc/600.jpg", data: "jpeg", contentType: "image/jpeg"},
+		{name: "leading slash", key: "/artwork/abc/300.png", path: "/base/media/artwork/abc/300.png", data: "png", contentType: "image/png"},
+		{name: "escaped", key: "misc/a b+c@é.txt", path: "/base/media/misc/a b+c@é.txt", data: "text", contentType: "text/plain"},
+		{name: "empty", key: "misc/empty", path: "/base/media/misc/empty", data: "", contentType: "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := store.Put(ctx, tt.key, []byte(tt.data), tt.contentType); err != nil {
+				t.Fatal(err)
+			}
+
+			bucket.mu.Lock()
+			_, stored := bucket.objects[tt.path]
+			bucket.mu.Unlock()
+			if !stored {
+				t.Fatalf("nothing stored at %s", tt.path)
+			}
+
+			obj, err := store.Open(ctx, tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(obj.Body)
+			obj.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(body) != tt.data || obj.Size != int64(len(tt.data)) || obj.ContentType != tt.contentType {
+				t.Errorf("got %q, %d bytes, %q; want %q, %d bytes, %q",
+					body, obj.Size, obj.ContentType, tt.data, len(tt.data), tt.contentType)
+			}
+
+			if err := store.Delete(ctx, tt.key); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.Open(ctx, tt.key); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Open after Delete err = %v, want %v", err, ErrNotFound)
+			}
+		})
+	}
+}
+
+func TestS3Errors(t *testing.T) {
+	ctx := context.Background()
+
+	srv := httptest.NewServer(&fakeS3{objects: make(map[string]fakeObject)})
+	defer srv.Close()
+
+	wrongSecret, err := NewS3(srv.URL, testRegion, "media", testAccessKey, "wrong", 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wrongSecret.Put(ctx, "a.jpg", []byte("x"), "image/jpeg"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Put with a wrong secret err = %v, want a 403", err)
+	}
+	if _, err := wrongSecret.Open(ctx, "a.jpg"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Open with a wrong secret err = %v, want a 403", err)
+	}
+	if _, err := wrongSecret.Open(ctx, "../a.jpg"); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("Open outside the bucket err = %v, want %v", err, ErrInvalidKey)
+	}
+
+	for _, tt := range []struct {
+		endpoint string
+		bucket   string
+	}{
+		{endpoint: "ftp://example.com", bucket: "media"},
+		{endpoint: "http://", bucket: "media"},
+		{endpoint: "http://example.com", bucket: ""},
+	} {
+		if _, err := NewS3(tt.endpoint, testRegion, tt.bucket, testAccessKey, testSecretKey, time.Second); err == nil {
+			t.Errorf("NewS3(%q, bucket %q) succeeded, want an error", tt.endpoint, tt.bucket)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCleanKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+		err  error
+	}{
+		{key: "artwork/abc/600.jpg", want: "artwork/abc/600.jpg"},
+		{key: "/artwork/abc/600.jpg", want: "artwork/abc/600.jpg"},
+		{key: "a.jpg", want: "a.jpg"},
+		{key: "", err: ErrInvalidKey},
+		{key: "/", err: ErrInvalidKey},
+		{key: ".", err: ErrInvalidKey},
+		{key: "..", err: ErrInvalidKey},
+		{key: "../etc/passwd", err: ErrInvalidKey},
+		{key: "artwork/../../etc/passwd", err: ErrInvalidKey},
+		{key: "artwork/./a.jpg", err: ErrInvalidKey},
+		{key: "artwork//a.jpg", err: ErrInvalidKey},
+		{key: "artwork/", err: ErrInvalidKey},
+		{key: `artwork\a.jpg`, err: ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		got, err := CleanKey(tt.key)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("CleanKey(%q) err = %v, want %v", tt.key, err, tt.err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("CleanKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatText = "text"
+	FormatVTT  = "vtt"
+	FormatSRT  = "srt"
+)
+
+var ErrEmpty = errors.New("transcript: no text found")
+
+// Segment is a stretch of speech. Plain text transcripts have no timing, so
+// their segments are paragraphs with zero Start and End.
+type Segment struct {
+	Start   time.Duration
+	End     time.Duration
+	Speaker string
+	Text    string
+}
+
+// SyntaxError reports a malformed cue, with the line it starts on.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("transcript: line %d: %s", e.Line, e.Msg)
+}
+
+// DetectFormat guesses the format of data from a WebVTT header or an SRT
+// timing line, falling back to plain text.
+func DetectFormat(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	if bytes.HasPrefix(data, []byte("WEBVTT")) {
+		return FormatVTT
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for i := 0; i < 3 && sc.Scan(); i++ {
+		if srtTiming.MatchString(strings.TrimSpace(sc.Text())) {
+			return FormatSRT
+		}
+	}
+
+	return FormatText
+}
+
+// Parse splits data, in the given format, into segments.
+func Parse(format string, data []byte) ([]Segment, error) {
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+
+	var (
+		segments []Segment
+		err      error
+	)
+
+	switch format {
+	case FormatVTT:
+		segments, err = parseVTT(text)
+	case FormatSRT:
+		segments, err = parseSRT(text)
+	case FormatText:
+		segments = parseText(text)
+	default:
+		return nil, fmt.Errorf("transcript: unknown format %q", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(segments) == 0 {
+		return nil, ErrEmpty
+	}
+
+	return segments, nil
+}
+
+// block is a run of non-blank lines and the number of the first one.
+type block struct {
+	line  int
+	lines []string
+}
+
+func blocks(text string) []block {
+	var out []block
+	var cur *block
+
+	for i, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			cur = nil
+			continue
+		}
+		if cur == nil {
+			out = append(out, block{line: i + 1})
+			cur = &out[len(out)-1]
+		}
+		cur.lines = append(cur.lines, line)
+	}
+
+	return out
+}
+
+func parseText(text string) []Segment {
+	var segments []Segment
+	for _, b := range blocks(text) {
+		if body := strings.Join(strings.Fields(strings.Join(b.lines, " ")), " "); body != "" {
+			segments = append(segments, Segment{Text: body})
+		}
+	}
+	return segments
+}
+
+var (
+	vttTiming = regexp.MustCompile(`^((?:\d+:)?\d{2}:\d{2}\.\d{3})\s+-->\s+((?:\d+:)?\d{2}:\d{2}\.\d{3})(?:\s|$)`)
+	srtTiming = regexp.MustCompile(`^(\d+:\d{2}:\d{2}[,.]\d{3})\s+-->\s+(\d+:\d{2}:\d{2}[,.]\d{3})`)
+	voiceTag  = regexp.MustCompile(`<v(?:\.[^\s>]*)?\s+([^>]+)>`)
+	anyTag    = regexp.MustCompile(`<[^>]*>`)
+	// Speakers in SRT are conventionally named in capitals: "JOHN: Hello".
+	srtSpeech = regexp.MustCompile(`^(?:>>\s*)?([A-Z][A-Z0-9 .'-]{0,40}):\s+`)
+)
+
+func parseVTT(text string) ([]Segment, error) {
+	bs := blocks(text)
+	if len(bs) == 0 || !strings.HasPrefix(bs[0].lines[0], "WEBVTT") {
+		return nil, &SyntaxError{Line: 1, Msg: "missing WEBVTT header"}
+	}
+
+	var segments []Segment
+
+	for _, b := range bs[1:] {
+		first := b.lines[0]
+		if strings.HasPrefix(first, "NOTE") || first == "STYLE" || first == "REGION" {
+			continue
+		}
+
+		lines := b.lines
+		line := b.line
+		// A cue may start with an identifier line.
+		if !strings.Contains(lines[0], "-->") {
+			lines = lines[1:]
+			line++
+		}
+		if len(lines) == 0 {
+			return nil, &SyntaxError{Line: b.line, Msg: "cue without timing"}
+		}
+
+		m := vttTiming.FindStringSubmatch(strings.TrimSpace(lines[0]))
+		if m == nil {
+			return nil, &SyntaxError{Line: line, Msg: "malformed cue timing"}
+		}
+
+		seg, err := cue(line, m[1], m[2], lines[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if match := voiceTag.FindStringSubmatch(strings.Join(lines[1:], " ")); match != nil {
+			seg.Speaker = strings.TrimSpace(match[1])
+		}
+
+		if seg.Text != "" {
+			segments = append(segments, seg)
+		}
+	}
+
+	return segments, nil
+}
+
+func parseSRT(text string) ([]Segment, error) {
+	var segments []Segment
+
+	for _, b := range blocks(text) {
+		lines := b.lines
+		line := b.line
+		// The sequence number is optional in practice.
+		if _, err := strconv.Atoi(strings.TrimSpace(lines[0])); err == nil {
+			lines = lines[1:]
+			line++
+		}
+		if len(lines) == 0 {
+			return nil, &SyntaxError{Line: b.line, Msg: "cue without timing"}
+		}
+
+		m := srtTiming.FindStringSubmatch(strings.TrimSpace(lines[0]))
+		if m == nil {
+			return nil, &SyntaxError{Line: line, Msg: "malformed cue timing"}
+		}
+
+		seg, err := cue(line, m[1], m[2], lines[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if match := srtSpeech.FindStringSubmatch(seg.Text); match != nil {
+			seg.Speaker = match[1]
+			seg.Text = seg.Text[len(match[0]):]
+		}
+
+		if seg.Text != "" {
+			segments = append(segments, seg)
+		}
+	}
+
+	return segments, nil
+}
+
+func cue(line int, start, end string, text []string) (Segment, error) {
+	s, err := timestamp(start)
+	if err != nil {
+		return Segment{}, &SyntaxError{Line: line, Msg: err.Error()}
+	}
+
+	e, err := timestamp(end)
+	if err != nil {
+		return Segment{}, &SyntaxError{Line: line, Msg: err.Error()}
+	}
+
+	if e < s {
+		return Segment{}, &SyntaxError{Line: line, Msg: "cue ends before it starts"}
+	}
+
+	body := anyTag.ReplaceAllString(strings.Join(text, " "), "")
+	body = strings.Join(strings.Fields(body), " ")
+
+	return Segment{Start: s, End: e, Text: body}, nil
+}
+
+// timestamp parses [hh:]mm:ss.ttt, with a comma or a dot before the
+// milliseconds.
+func timestamp(s string) (time.Duration, error) {
+	s = strings.Replace(s, ",", ".", 1)
+
+	parts := strings.Split(s, ":")
+	if len(parts) == 2 {
+		parts = append([]string{"0"}, parts...)
+	}
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("malformed timestamp %q", s)
+	}
+
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("malformed timestamp %q", s)
+	}
+
+	m, err := strconv.Atoi(parts[1])
+	if err != nil || m > 59 {
+		return 0, fmt.Errorf("malformed timestamp %q", s)
+	}
+
+	secs, millis, ok := strings.Cut(parts[2], ".")
+	sec, err := strconv.Atoi(secs)
+	if err != nil || !ok || sec > 59 {
+		return 0, fmt.Errorf("malformed timestamp %q", s)
+	}
+
+	ms, err := strconv.Atoi(millis)
+	if err != nil {
+		return 0, fmt.Errorf("malformed timestamp %q", s)
+	}
+
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute +
+		time.Duration(sec)*time.Second + time.Duration(ms)*time.Millisecond, nil
+}
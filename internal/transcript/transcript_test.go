@@ -0,0 +1,181 @@
+package transcript
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func ms(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "vtt", data: "WEBVTT\n\n00:01.000 --> 00:02.000\nHi\n", want: FormatVTT},
+		{name: "vtt with bom", data: "\ufeffWEBVTT - title\n", want: FormatVTT},
+		{name: "srt", data: "1\n00:00:01,000 --> 00:00:02,000\nHi\n", want: FormatSRT},
+		{name: "srt without numbers", data: "00:00:01,000 --> 00:00:02,000\nHi\n", want: FormatSRT},
+		{name: "text", data: "Hello there.\n\nSecond paragraph.\n", want: FormatText},
+		{name: "timing too late", data: "a\nb\nc\n00:00:01,000 --> 00:00:02,000\n", want: FormatText},
+	}
+
+	for _, tt := range tests {
+		if got := DetectFormat([]byte(tt.data)); got != tt.want {
+			t.Errorf("%s: DetectFormat = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		want   []Segment
+	}{
+		{
+			name:   "vtt",
+			format: FormatVTT,
+			data: "WEBVTT\r\n\r\nNOTE made by hand\r\n\r\nSTYLE\r\n::cue { color: red }\r\n\r\n" +
+				"intro\r\n00:01.000 --> 00:04.500 align:start\r\n<v.loud Ana Maria>Hello <b>and</b>\r\nwelcome.</v>\r\n\r\n" +
+				"01:00:00.000 --> 01:00:01.250\r\nBye\r\n",
+			want: []Segment{
+				{Start: ms(1000), End: ms(4500), Speaker: "Ana Maria", Text: "Hello and welcome."},
+				{Start: time.Hour, End: time.Hour + ms(1250), Text: "Bye"},
+			},
+		},
+		{
+			name:   "srt",
+			format: FormatSRT,
+			data: "1\n00:00:01,000 --> 00:00:02,500\nJOHN: Hi there\n\n" +
+				"2\n00:00:03,000 --> 00:00:04,000\n>> MARY ANN: Hello\nagain\n\n" +
+				"00:00:05.000 --> 00:00:06,000\nNo speaker: lowercase\n",
+			want: []Segment{
+				{Start: ms(1000), End: ms(2500), Speaker: "JOHN", Text: "Hi there"},
+				{Start: ms(3000), End: ms(4000), Speaker: "MARY ANN", Text: "Hello again"},
+				{Start: ms(5000), End: ms(6000), Text: "No speaker: lowercase"},
+			},
+		},
+		{
+			name:   "text",
+			format: FormatText,
+			data:   "\ufeffFirst   paragraph\nwraps.\n\n\n  Second one.  \n",
+			want: []Segment{
+				{Text: "First paragraph wraps."},
+				{Text: "Second one."},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		line   int
+		err    error
+	}{
+		{name: "missing header", format: FormatVTT, data: "00:01.000 --> 00:02.000\nHi\n", line: 1},
+		{name: "bad vtt timing", format: FormatVTT, data: "WEBVTT\n\nid\n00:01 --> 00:02\nHi\n", line: 4},
+		{name: "vtt cue without timing", format: FormatVTT, data: "WEBVTT\n\nid\n", line: 3},
+		{name: "ends before start", format: FormatSRT, data: "1\n00:00:05,000 --> 00:00:04,000\nHi\n", line: 2},
+		{name: "minutes out of range", format: FormatSRT, data: "\n\n1\n00:61:00,000 --> 00:62:00,000\nHi\n", line: 4},
+		{name: "srt cue without timing", format: FormatSRT, data: "7\n", line: 1},
+		{name: "empty text", format: FormatText, data: " \n\n", err: ErrEmpty},
+		{name: "only empty cues", format: FormatVTT, data: "WEBVTT\n\n00:01.000 --> 00:02.000\n<b></b>\n", err: ErrEmpty},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.format, []byte(tt.data))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("err = %v, want %v", err, tt.err)
+				}
+				return
+			}
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("err = %v, want a SyntaxError", err)
+			}
+			if syntaxErr.Line != tt.line {
+				t.Errorf("line = %d, want %d (%v)", syntaxErr.Line, tt.line, err)
+			}
+		})
+	}
+
+	if _, err := Parse("docx", []byte("x")); err == nil {
+		t.Error("Parse of an unknown format succeeded")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	segments := []Segment{
+		{Start: ms(1500), End: ms(3000), Speaker: "ANA", Text: "Hello."},
+		{Start: time.Hour + ms(61_001), End: time.Hour + ms(62_000), Text: "Bye."},
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: FormatVTT,
+			want:   "WEBVTT\n\n00:00:01.500 --> 00:00:03.000\n<v ANA>Hello.\n\n01:01:01.001 --> 01:01:02.000\nBye.\n",
+		},
+		{
+			format: FormatSRT,
+			want:   "1\n00:00:01,500 --> 00:00:03,000\nANA: Hello.\n\n2\n01:01:01,001 --> 01:01:02,000\nBye.\n",
+		},
+		{
+			format: FormatText,
+			want:   "ANA: Hello.\n\nBye.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := Write(tt.format, segments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%q\nwant\n%q", got, tt.want)
+			}
+
+			// Timed formats read back as they were written.
+			if tt.format == FormatText {
+				return
+			}
+			back, err := Parse(tt.format, got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(back, segments) {
+				t.Errorf("round trip got  %+v\nwant %+v", back, segments)
+			}
+		})
+	}
+
+	if _, err := Write("docx", segments); err == nil {
+		t.Error("Write of an unknown format succeeded")
+	}
+}
@@ -0,0 +1,56 @@
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Write renders segments in the given format. Timings are written as zero for
+// segments that have none.
+func Write(format string, segments []Segment) ([]byte, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case FormatVTT:
+		buf.WriteString("WEBVTT\n")
+		for _, s := range segments {
+			fmt.Fprintf(&buf, "\n%s --> %s\n", clock(s.Start, '.'), clock(s.End, '.'))
+			if s.Speaker != "" {
+				fmt.Fprintf(&buf, "<v %s>", s.Speaker)
+			}
+			buf.WriteString(s.Text + "\n")
+		}
+	case FormatSRT:
+		for i, s := range segments {
+			if i > 0 {
+				buf.WriteString("\n")
+			}
+			fmt.Fprintf(&buf, "%d\n%s --> %s\n", i+1, clock(s.Start, ','), clock(s.End, ','))
+			if s.Speaker != "" {
+				buf.WriteString(s.Speaker + ": ")
+			}
+			buf.WriteString(s.Text + "\n")
+		}
+	case FormatText:
+		for i, s := range segments {
+			if i > 0 {
+				buf.WriteString("\n")
+			}
+			if s.Speaker != "" {
+				buf.WriteString(s.Speaker + ": ")
+			}
+			buf.WriteString(s.Text + "\n")
+		}
+	default:
+		return nil, fmt.Errorf("transcript: unknown format %q", format)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// clock formats d as hh:mm:ss followed by sep and milliseconds.
+func clock(d time.Duration, sep byte) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3_600_000, ms/60_000%60, ms/1000%60, sep, ms%1000)
+}
@@ -0,0 +1,42 @@
+package views
+
+import "sync"
+
+// Counter tallies podcast views in memory so that reads do not each cost a
+// database write. The tallies are drained and stored in batches.
+type Counter struct {
+	mu     sync.Mutex
+	counts map[int64]int64
+}
+
+func NewCounter() *Counter {
+	return &Counter{counts: make(map[int64]int64)}
+}
+
+// Add records a view of the podcast.
+func (c *Counter) Add(podcastId int64) {
+	c.mu.Lock()
+	c.counts[podcastId]++
+	c.mu.Unlock()
+}
+
+// Drain returns the views recorded since the last drain and starts counting
+// from zero.
+func (c *Counter) Drain() map[int64]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := c.counts
+	c.counts = make(map[int64]int64, len(counts))
+	return counts
+}
+
+// Merge adds counts back, for when storing a drained batch failed.
+func (c *Counter) Merge(counts map[int64]int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, n := range counts {
+		c.counts[id] += n
+	}
+}
@@ -0,0 +1,93 @@
+package views
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	c := NewCounter()
+
+	c.Add(1)
+	c.Add(2)
+	c.Add(1)
+
+	if got, want := c.Drain(), map[int64]int64{1: 2, 2: 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Drain() = %v, want %v", got, want)
+	}
+	if got := c.Drain(); len(got) != 0 {
+		t.Errorf("second Drain() = %v, want nothing", got)
+	}
+
+	c.Add(2)
+	c.Merge(map[int64]int64{1: 2, 2: 1})
+
+	if got, want := c.Drain(), map[int64]int64{1: 2, 2: 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Drain() after Merge = %v, want %v", got, want)
+	}
+}
+
+// TestCounterConcurrent drains while views are being added, merging back
+// every other batch as a failed flush does, and checks no view is lost or
+// counted twice. Run it with -race.
+func TestCounterConcurrent(t *testing.T) {
+	const (
+		workers   = 8
+		perWorker = 2000
+		podcasts  = 5
+	)
+
+	c := NewCounter()
+
+	var adders sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		adders.Add(1)
+		go func() {
+			defer adders.Done()
+			for i := 0; i < perWorker; i++ {
+				c.Add(int64(i%podcasts) + 1)
+			}
+		}()
+	}
+
+	stored := map[int64]int64{}
+	done := make(chan struct{})
+	flushed := make(chan struct{})
+
+	go func() {
+		defer close(flushed)
+		for fail := false; ; fail = !fail {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			batch := c.Drain()
+			if fail {
+				c.Merge(batch)
+				continue
+			}
+			for id, n := range batch {
+				stored[id] += n
+			}
+		}
+	}()
+
+	adders.Wait()
+	close(done)
+	<-flushed
+
+	for id, n := range c.Drain() {
+		stored[id] += n
+	}
+
+	want := map[int64]int64{}
+	for id := int64(1); id <= podcasts; id++ {
+		want[id] = workers * perWorker / podcasts
+	}
+	if !reflect.DeepEqual(stored, want) {
+		t.Errorf("stored %v, want %v", stored, want)
+	}
+}
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS episodes;
\ No newline at end of file
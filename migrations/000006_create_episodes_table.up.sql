@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS episodes (
+    id              BIGSERIAL PRIMARY KEY,
+    podcast_id      BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    guid            TEXT NOT NULL,
+    title           TEXT NOT NULL,
+    description     TEXT NOT NULL DEFAULT '',
+    url             TEXT NOT NULL DEFAULT '',
+    audio_url       TEXT NOT NULL DEFAULT '',
+    duration        INT NOT NULL DEFAULT 0,
+    published_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (podcast_id, guid)
+);
\ No newline at end of file
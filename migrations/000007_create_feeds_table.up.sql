@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS feeds (
+    id              BIGSERIAL PRIMARY KEY,
+    podcast_id      BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    url             TEXT NOT NULL UNIQUE,
+    etag            TEXT NOT NULL DEFAULT '',
+    last_modified   TEXT NOT NULL DEFAULT '',
+    last_fetched_at TIMESTAMPTZ,
+    last_error      TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS podcasts_updated_at_idx;
+
+ALTER TABLE podcasts
+    DROP COLUMN IF EXISTS updated_at;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE podcasts
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+UPDATE podcasts SET updated_at = created_at WHERE created_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS podcasts_updated_at_idx ON podcasts (updated_at DESC);
\ No newline at end of file
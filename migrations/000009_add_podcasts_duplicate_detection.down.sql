@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS podcasts_title_trgm_idx;
+
+DROP INDEX IF EXISTS podcasts_url_key_idx;
+
+DROP FUNCTION IF EXISTS podcast_url_key(TEXT);
+
+DROP EXTENSION IF EXISTS pg_trgm;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE OR REPLACE FUNCTION podcast_url_key(url TEXT) RETURNS TEXT
+LANGUAGE SQL IMMUTABLE AS $$
+    SELECT rtrim(regexp_replace(regexp_replace(lower(url), '^[a-z][a-z0-9+.-]*://(www\.)?', ''), '#.*$', ''), '/')
+$$;
+
+CREATE INDEX IF NOT EXISTS podcasts_url_key_idx ON podcasts (podcast_url_key(url));
+
+CREATE INDEX IF NOT EXISTS podcasts_title_trgm_idx ON podcasts USING GIN (title gin_trgm_ops);
\ No newline at end of file
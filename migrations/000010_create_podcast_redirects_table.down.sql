@@ -0,0 +1 @@
+DROP TABLE IF EXISTS podcast_redirects;
\ No newline at end of file
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS podcast_redirects (
+    old_id      BIGINT PRIMARY KEY,
+    new_id      BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
\ No newline at end of file
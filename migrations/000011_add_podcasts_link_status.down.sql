@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS podcasts_link_checked_at_idx;
+
+ALTER TABLE podcasts
+    DROP CONSTRAINT check_link_status;
+
+ALTER TABLE podcasts
+    DROP COLUMN IF EXISTS link_status,
+    DROP COLUMN IF EXISTS link_status_code,
+    DROP COLUMN IF EXISTS link_checked_at;
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE podcasts
+    ADD COLUMN link_status TEXT NOT NULL DEFAULT 'unknown',
+    ADD COLUMN link_status_code INT NOT NULL DEFAULT 0,
+    ADD COLUMN link_checked_at TIMESTAMPTZ;
+
+ALTER TABLE podcasts
+    ADD CONSTRAINT check_link_status CHECK (link_status IN ('unknown', 'ok', 'broken'));
+
+CREATE INDEX IF NOT EXISTS podcasts_link_checked_at_idx ON podcasts (link_checked_at NULLS FIRST);
\ No newline at end of file
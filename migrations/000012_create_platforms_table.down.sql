@@ -0,0 +1 @@
+DROP TABLE IF EXISTS platforms;
\ No newline at end of file
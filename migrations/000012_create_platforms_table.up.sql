@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS platforms (
+    id              BIGSERIAL PRIMARY KEY,
+    name            TEXT NOT NULL UNIQUE,
+    slug            TEXT NOT NULL UNIQUE,
+    aliases         TEXT[] NOT NULL DEFAULT '{}',
+    url_patterns    TEXT[] NOT NULL DEFAULT '{}',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    version         INT NOT NULL DEFAULT 1
+);
+
+INSERT INTO platforms (name, slug, aliases, url_patterns) VALUES
+    ('Spotify', 'spotify', '{"spotify podcasts","spotify podcast","spotify for podcasters","anchor"}', '{"^https://open\\.spotify\\.com/(show|episode)/","^https://(www\\.)?podcasters\\.spotify\\.com/","^https://anchor\\.fm/"}'),
+    ('Apple Podcasts', 'apple-podcasts', '{"apple","apple podcast","itunes","itunes podcasts"}', '{"^https://podcasts\\.apple\\.com/","^https://itunes\\.apple\\.com/"}'),
+    ('YouTube', 'youtube', '{"yt","youtube podcasts","youtube music"}', '{"^https://((www|m|music)\\.)?youtube\\.com/","^https://youtu\\.be/"}'),
+    ('Google Podcasts', 'google-podcasts', '{"google","google podcast"}', '{"^https://podcasts\\.google\\.com/"}'),
+    ('SoundCloud', 'soundcloud', '{"sound cloud"}', '{"^https://((www|m)\\.)?soundcloud\\.com/"}'),
+    ('Amazon Music', 'amazon-music', '{"amazon","audible"}', '{"^https://music\\.amazon\\.[a-z.]+/podcasts/","^https://(www\\.)?audible\\.[a-z.]+/"}'),
+    ('Pocket Casts', 'pocket-casts', '{"pocketcasts"}', '{"^https://(play\\.)?pocketcasts\\.com/","^https://pca\\.st/"}'),
+    ('Castbox', 'castbox', '{"castbox.fm"}', '{"^https://castbox\\.fm/"}'),
+    ('Podbean', 'podbean', '{}', '{"^https://([a-z0-9-]+\\.)?podbean\\.com/"}'),
+    ('RSS', 'rss', '{"feed","rss feed","website","web"}', '{}')
+ON CONFLICT DO NOTHING;
\ No newline at end of file
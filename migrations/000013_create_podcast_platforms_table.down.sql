@@ -0,0 +1 @@
+DROP TABLE IF EXISTS podcast_platforms;
\ No newline at end of file
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS podcast_platforms (
+    podcast_id      BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    platform_id     BIGINT NOT NULL REFERENCES platforms ON DELETE RESTRICT,
+    url             TEXT NOT NULL,
+    PRIMARY KEY (podcast_id, platform_id)
+);
+
+CREATE INDEX IF NOT EXISTS podcast_platforms_platform_id_idx ON podcast_platforms (platform_id);
+
+-- Values that match no known platform or alias become platforms of their own.
+INSERT INTO platforms (name, slug)
+SELECT DISTINCT ON (slug) name, slug
+FROM (
+    SELECT trim(p.platform) AS name,
+           trim(BOTH '-' FROM regexp_replace(lower(trim(p.platform)), '[^a-z0-9]+', '-', 'g')) AS slug
+    FROM podcasts p
+    WHERE NOT EXISTS (
+        SELECT 1 FROM platforms pl
+        WHERE lower(pl.name) = lower(trim(p.platform))
+        OR pl.slug = lower(trim(p.platform))
+        OR lower(trim(p.platform)) = ANY(pl.aliases)
+    )
+) unknown
+WHERE slug <> ''
+ORDER BY slug, name
+ON CONFLICT DO NOTHING;
+
+UPDATE podcasts p
+SET platform = pl.name
+FROM platforms pl
+WHERE lower(pl.name) = lower(trim(p.platform))
+OR pl.slug = lower(trim(p.platform))
+OR pl.slug = trim(BOTH '-' FROM regexp_replace(lower(trim(p.platform)), '[^a-z0-9]+', '-', 'g'))
+OR lower(trim(p.platform)) = ANY(pl.aliases);
+
+INSERT INTO podcast_platforms (podcast_id, platform_id, url)
+SELECT p.id, pl.id, p.url
+FROM podcasts p
+JOIN platforms pl ON pl.name = p.platform
+ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS podcasts_tags_idx;
+
+DROP FUNCTION IF EXISTS tag_descendants(TEXT);
+
+DROP FUNCTION IF EXISTS tag_slug(TEXT);
+
+DROP TABLE IF EXISTS tag_synonyms;
+
+DROP TABLE IF EXISTS tags;
\ No newline at end of file
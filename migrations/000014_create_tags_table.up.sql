@@ -0,0 +1,58 @@
+CREATE TABLE IF NOT EXISTS tags (
+    id              BIGSERIAL PRIMARY KEY,
+    slug            TEXT NOT NULL UNIQUE,
+    name            TEXT NOT NULL,
+    parent_id       BIGINT REFERENCES tags ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    version         INT NOT NULL DEFAULT 1,
+    CONSTRAINT check_tags_parent CHECK (parent_id <> id)
+);
+
+CREATE INDEX IF NOT EXISTS tags_parent_id_idx ON tags (parent_id);
+CREATE INDEX IF NOT EXISTS tags_slug_prefix_idx ON tags (slug text_pattern_ops);
+
+CREATE TABLE IF NOT EXISTS tag_synonyms (
+    synonym         TEXT PRIMARY KEY,
+    tag_id          BIGINT NOT NULL REFERENCES tags ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS tag_synonyms_tag_id_idx ON tag_synonyms (tag_id);
+
+CREATE OR REPLACE FUNCTION tag_slug(name TEXT) RETURNS TEXT
+LANGUAGE SQL IMMUTABLE AS $$
+    SELECT trim(BOTH '-' FROM regexp_replace(lower(trim(name)), '[^[:alnum:]]+', '-', 'g'))
+$$;
+
+-- tag_descendants returns root and the slugs of every tag below it.
+CREATE OR REPLACE FUNCTION tag_descendants(root TEXT) RETURNS TEXT[]
+LANGUAGE SQL STABLE AS $$
+    WITH RECURSIVE tree AS (
+        SELECT id, slug FROM tags WHERE slug = root
+        UNION
+        SELECT t.id, t.slug FROM tags t JOIN tree ON t.parent_id = tree.id
+    )
+    SELECT array_append(COALESCE((SELECT array_agg(slug) FROM tree WHERE slug <> root), '{}'), root)
+$$;
+
+UPDATE podcasts p
+SET tags = normalized.tags
+FROM (
+    SELECT id, array_agg(slug ORDER BY position) AS tags
+    FROM (
+        SELECT p.id, tag_slug(u.tag) AS slug, min(u.position) AS position
+        FROM podcasts p, unnest(p.tags) WITH ORDINALITY AS u(tag, position)
+        WHERE tag_slug(u.tag) <> ''
+        GROUP BY p.id, tag_slug(u.tag)
+    ) slugs
+    GROUP BY id
+) normalized
+WHERE normalized.id = p.id;
+
+INSERT INTO tags (slug, name)
+SELECT DISTINCT ON (tag_slug(u.tag)) tag_slug(u.tag), trim(u.tag)
+FROM podcasts p, unnest(p.tags) AS u(tag)
+WHERE tag_slug(u.tag) <> ''
+ORDER BY tag_slug(u.tag)
+ON CONFLICT DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS podcasts_tags_idx ON podcasts USING GIN (tags);
\ No newline at end of file
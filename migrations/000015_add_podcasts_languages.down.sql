@@ -0,0 +1,7 @@
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS language TEXT NOT NULL DEFAULT '';
+
+UPDATE podcasts SET language = COALESCE(languages[1], '');
+
+DROP INDEX IF EXISTS podcasts_languages_idx;
+
+ALTER TABLE podcasts DROP COLUMN IF EXISTS languages;
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS languages TEXT[] NOT NULL DEFAULT '{}';
+
+-- Copy the free-form language values as they are. The API maps them onto
+-- ISO 639 codes from its language table when it starts, and values naming no
+-- known language are kept so they show up as invalid the next time the
+-- podcast is edited.
+UPDATE podcasts
+SET languages = ARRAY[lower(trim(language))]
+WHERE trim(language) <> '';
+
+ALTER TABLE podcasts DROP COLUMN IF EXISTS language;
+
+CREATE INDEX IF NOT EXISTS podcasts_languages_idx ON podcasts USING GIN (languages);
\ No newline at end of file
@@ -0,0 +1,16 @@
+ALTER TABLE podcasts
+    ADD COLUMN IF NOT EXISTS host TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS program TEXT NOT NULL DEFAULT '';
+
+UPDATE podcasts p
+SET host = h.name, program = pr.name
+FROM hosts h, programs pr
+WHERE h.id = p.host_id AND pr.id = p.program_id;
+
+ALTER TABLE podcasts
+    DROP COLUMN IF EXISTS host_id,
+    DROP COLUMN IF EXISTS program_id;
+
+DROP TABLE IF EXISTS programs;
+
+DROP TABLE IF EXISTS hosts;
\ No newline at end of file
@@ -0,0 +1,57 @@
+CREATE TABLE IF NOT EXISTS hosts (
+    id              BIGSERIAL PRIMARY KEY,
+    name            TEXT NOT NULL,
+    kind            TEXT NOT NULL DEFAULT 'person',
+    url             TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    version         INT NOT NULL DEFAULT 1,
+    CONSTRAINT check_hosts_kind CHECK (kind IN ('person', 'organization'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS hosts_name_key ON hosts (lower(name));
+
+CREATE TABLE IF NOT EXISTS programs (
+    id              BIGSERIAL PRIMARY KEY,
+    name            TEXT NOT NULL,
+    url             TEXT NOT NULL DEFAULT '',
+    description     TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    version         INT NOT NULL DEFAULT 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS programs_name_key ON programs (lower(name));
+
+INSERT INTO hosts (name)
+SELECT DISTINCT ON (lower(trim(host))) trim(host)
+FROM podcasts
+ORDER BY lower(trim(host)), created_at
+ON CONFLICT DO NOTHING;
+
+INSERT INTO programs (name)
+SELECT DISTINCT ON (lower(trim(program))) trim(program)
+FROM podcasts
+ORDER BY lower(trim(program)), created_at
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE podcasts
+    ADD COLUMN IF NOT EXISTS host_id BIGINT REFERENCES hosts ON DELETE RESTRICT,
+    ADD COLUMN IF NOT EXISTS program_id BIGINT REFERENCES programs ON DELETE RESTRICT;
+
+UPDATE podcasts p
+SET host_id = h.id
+FROM hosts h
+WHERE lower(h.name) = lower(trim(p.host));
+
+UPDATE podcasts p
+SET program_id = pr.id
+FROM programs pr
+WHERE lower(pr.name) = lower(trim(p.program));
+
+ALTER TABLE podcasts
+    ALTER COLUMN host_id SET NOT NULL,
+    ALTER COLUMN program_id SET NOT NULL,
+    DROP COLUMN IF EXISTS host,
+    DROP COLUMN IF EXISTS program;
+
+CREATE INDEX IF NOT EXISTS podcasts_host_id_idx ON podcasts (host_id);
+CREATE INDEX IF NOT EXISTS podcasts_program_id_idx ON podcasts (program_id);
\ No newline at end of file
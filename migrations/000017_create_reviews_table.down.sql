@@ -0,0 +1,5 @@
+ALTER TABLE podcasts
+    DROP COLUMN IF EXISTS rating_count,
+    DROP COLUMN IF EXISTS rating_average;
+
+DROP TABLE IF EXISTS reviews;
\ No newline at end of file
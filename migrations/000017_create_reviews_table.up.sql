@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS reviews (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users ON DELETE CASCADE,
+    podcast_id      BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    score           SMALLINT NOT NULL,
+    body            TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    version         INT NOT NULL DEFAULT 1,
+    CONSTRAINT check_reviews_score CHECK (score BETWEEN 1 AND 10),
+    CONSTRAINT reviews_user_podcast_key UNIQUE (user_id, podcast_id)
+);
+
+CREATE INDEX IF NOT EXISTS reviews_podcast_id_idx ON reviews (podcast_id, created_at DESC);
+
+ALTER TABLE podcasts
+    ADD COLUMN IF NOT EXISTS rating_count INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS rating_average DOUBLE PRECISION NOT NULL DEFAULT 0;
\ No newline at end of file
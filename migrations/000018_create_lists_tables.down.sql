@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS list_items;
+
+DROP TABLE IF EXISTS lists;
\ No newline at end of file
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS lists (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users ON DELETE CASCADE,
+    kind            TEXT NOT NULL DEFAULT 'custom',
+    name            TEXT NOT NULL,
+    description     TEXT NOT NULL DEFAULT '',
+    public          BOOLEAN NOT NULL DEFAULT FALSE,
+    share_id        TEXT NOT NULL UNIQUE,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    version         INT NOT NULL DEFAULT 1,
+    CONSTRAINT check_lists_kind CHECK (kind IN ('favorites', 'custom'))
+);
+
+CREATE INDEX IF NOT EXISTS lists_user_id_idx ON lists (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS lists_user_favorites_key ON lists (user_id) WHERE kind = 'favorites';
+
+CREATE TABLE IF NOT EXISTS list_items (
+    list_id         BIGINT NOT NULL REFERENCES lists ON DELETE CASCADE,
+    podcast_id      BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    position        INT NOT NULL,
+    note            TEXT NOT NULL DEFAULT '',
+    added_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (list_id, podcast_id)
+);
+
+CREATE INDEX IF NOT EXISTS list_items_podcast_id_idx ON list_items (podcast_id);
+CREATE INDEX IF NOT EXISTS list_items_position_idx ON list_items (list_id, position);
\ No newline at end of file
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS listening_history (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users ON DELETE CASCADE,
+    podcast_id      BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    episode_id      BIGINT REFERENCES episodes ON DELETE CASCADE,
+    position        INT NOT NULL DEFAULT 0,
+    completed       BOOLEAN NOT NULL DEFAULT FALSE,
+    listened_at     TIMESTAMPTZ NOT NULL,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_listening_history_position CHECK (position >= 0)
+);
+
+-- One row per episode, plus one per podcast for progress not tied to an episode.
+CREATE UNIQUE INDEX IF NOT EXISTS listening_history_key ON listening_history (user_id, podcast_id, COALESCE(episode_id, 0));
+CREATE INDEX IF NOT EXISTS listening_history_user_listened_at_idx ON listening_history (user_id, listened_at DESC);
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS podcast_similarities;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS podcast_similarities (
+    podcast_id      BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    similar_id      BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    score           DOUBLE PRECISION NOT NULL,
+    computed_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (podcast_id, similar_id)
+);
+
+CREATE INDEX IF NOT EXISTS podcast_similarities_score_idx ON podcast_similarities (podcast_id, score DESC);
\ No newline at end of file
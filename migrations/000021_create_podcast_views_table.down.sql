@@ -0,0 +1 @@
+DROP TABLE IF EXISTS podcast_views;
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS podcast_views (
+    podcast_id      BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    day             DATE NOT NULL,
+    views           BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (podcast_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS podcast_views_day_idx ON podcast_views (day);
\ No newline at end of file
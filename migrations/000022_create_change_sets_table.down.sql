@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS change_sets;
+
+ALTER TABLE podcasts DROP COLUMN IF EXISTS updated_by;
+ALTER TABLE podcasts DROP COLUMN IF EXISTS created_by;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS check_users_role;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
\ No newline at end of file
@@ -0,0 +1,28 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'contributor';
+ALTER TABLE users ADD CONSTRAINT check_users_role CHECK (role IN ('contributor', 'editor'));
+
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS created_by BIGINT REFERENCES users ON DELETE SET NULL;
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS updated_by BIGINT REFERENCES users ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS change_sets (
+    id              BIGSERIAL PRIMARY KEY,
+    user_id         BIGINT NOT NULL REFERENCES users ON DELETE CASCADE,
+    action          TEXT NOT NULL,
+    -- Not a foreign key: the history of a deleted podcast is kept.
+    podcast_id      BIGINT,
+    payload         JSONB,
+    base_updated_at TIMESTAMPTZ,
+    comment         TEXT NOT NULL DEFAULT '',
+    status          TEXT NOT NULL DEFAULT 'pending',
+    reviewer_id     BIGINT REFERENCES users ON DELETE SET NULL,
+    review_comment  TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reviewed_at     TIMESTAMPTZ,
+    CONSTRAINT check_change_sets_action CHECK (action IN ('create', 'update', 'delete')),
+    CONSTRAINT check_change_sets_status CHECK (status IN ('pending', 'approved', 'rejected')),
+    CONSTRAINT check_change_sets_podcast CHECK (action = 'create' OR podcast_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS change_sets_status_idx ON change_sets (status, created_at);
+CREATE INDEX IF NOT EXISTS change_sets_user_id_idx ON change_sets (user_id);
+CREATE INDEX IF NOT EXISTS change_sets_podcast_id_idx ON change_sets (podcast_id);
\ No newline at end of file
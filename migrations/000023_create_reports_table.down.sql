@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reports;
+
+ALTER TABLE podcasts DROP COLUMN IF EXISTS hidden;
\ No newline at end of file
@@ -0,0 +1,21 @@
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS hidden BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS reports (
+    id                 BIGSERIAL PRIMARY KEY,
+    podcast_id         BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    user_id            BIGINT NOT NULL REFERENCES users ON DELETE CASCADE,
+    reason             TEXT NOT NULL,
+    details            TEXT NOT NULL DEFAULT '',
+    status             TEXT NOT NULL DEFAULT 'open',
+    resolver_id        BIGINT REFERENCES users ON DELETE SET NULL,
+    resolution_comment TEXT NOT NULL DEFAULT '',
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    resolved_at        TIMESTAMPTZ,
+    CONSTRAINT check_reports_reason CHECK (reason IN ('incorrect', 'spam', 'offensive', 'duplicate', 'copyright', 'other')),
+    CONSTRAINT check_reports_status CHECK (status IN ('open', 'upheld', 'dismissed'))
+);
+
+-- A user can have one open report per podcast; once it is resolved they may
+-- report the podcast again.
+CREATE UNIQUE INDEX IF NOT EXISTS reports_open_user_podcast_idx ON reports (podcast_id, user_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS reports_status_idx ON reports (status, created_at);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE podcasts DROP COLUMN IF EXISTS artwork;
\ No newline at end of file
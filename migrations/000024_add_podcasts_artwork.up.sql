@@ -0,0 +1 @@
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS artwork JSONB;
\ No newline at end of file
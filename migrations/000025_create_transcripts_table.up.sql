@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS transcripts (
+    id         BIGSERIAL PRIMARY KEY,
+    episode_id BIGINT NOT NULL UNIQUE REFERENCES episodes ON DELETE CASCADE,
+    format     TEXT NOT NULL,
+    language   TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_transcripts_format CHECK (format IN ('text', 'vtt', 'srt'))
+);
+
+CREATE TABLE IF NOT EXISTS transcript_segments (
+    transcript_id BIGINT NOT NULL REFERENCES transcripts ON DELETE CASCADE,
+    position      INTEGER NOT NULL,
+    start_ms      BIGINT,
+    end_ms        BIGINT,
+    speaker       TEXT NOT NULL DEFAULT '',
+    body          TEXT NOT NULL,
+    -- The simple configuration does no stemming, which suits a catalog whose
+    -- episodes are in many languages.
+    search        TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', speaker || ' ' || body)) STORED,
+    PRIMARY KEY (transcript_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS transcript_segments_search_idx ON transcript_segments USING GIN (search);
\ No newline at end of file
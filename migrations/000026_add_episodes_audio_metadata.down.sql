@@ -0,0 +1,3 @@
+ALTER TABLE episodes DROP COLUMN IF EXISTS artwork;
+ALTER TABLE episodes DROP COLUMN IF EXISTS chapters;
+ALTER TABLE episodes DROP COLUMN IF EXISTS bitrate;
\ No newline at end of file
@@ -0,0 +1,3 @@
+ALTER TABLE episodes ADD COLUMN IF NOT EXISTS bitrate INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE episodes ADD COLUMN IF NOT EXISTS chapters JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE episodes ADD COLUMN IF NOT EXISTS artwork JSONB;
\ No newline at end of file
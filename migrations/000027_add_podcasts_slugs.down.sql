@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS podcast_slugs;
+DROP INDEX IF EXISTS podcasts_slug_key;
+DROP INDEX IF EXISTS podcasts_public_id_key;
+ALTER TABLE podcasts DROP COLUMN IF EXISTS slug;
+ALTER TABLE podcasts DROP COLUMN IF EXISTS public_id;
\ No newline at end of file
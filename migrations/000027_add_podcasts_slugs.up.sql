@@ -0,0 +1,48 @@
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS public_id TEXT;
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS slug TEXT;
+
+UPDATE podcasts SET public_id = (
+    SELECT string_agg(substr('abcdefghijklmnopqrstuvwxyz234567', floor(random() * 32)::int + 1, 1), '')
+    FROM generate_series(1, 16)
+    WHERE podcasts.id IS NOT NULL
+)
+WHERE public_id IS NULL;
+
+WITH bases AS (
+    SELECT id, CASE
+        WHEN base = '' THEN 'podcast'
+        WHEN base ~ '^[0-9]+$' THEN 'podcast-' || base
+        WHEN base IN ('duplicates', 'import', 'export') THEN base || '-podcast'
+        ELSE base
+    END AS base
+    FROM (
+        SELECT id, rtrim(left(trim(both '-' FROM regexp_replace(lower(title), '[^[:alnum:]]+', '-', 'g')), 80), '-') AS base
+        FROM podcasts
+    ) b
+), ranked AS (
+    SELECT id, base, row_number() OVER (PARTITION BY base ORDER BY id) AS n FROM bases
+)
+UPDATE podcasts SET slug = CASE WHEN ranked.n = 1 THEN ranked.base ELSE ranked.base || '-' || ranked.n END
+FROM ranked
+WHERE ranked.id = podcasts.id AND podcasts.slug IS NULL;
+
+UPDATE podcasts p SET slug = p.slug || '-' || p.id
+WHERE EXISTS (SELECT 1 FROM podcasts q WHERE q.slug = p.slug AND q.id < p.id);
+
+ALTER TABLE podcasts ALTER COLUMN public_id SET NOT NULL;
+ALTER TABLE podcasts ALTER COLUMN slug SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS podcasts_public_id_key ON podcasts (public_id);
+CREATE UNIQUE INDEX IF NOT EXISTS podcasts_slug_key ON podcasts (slug);
+
+CREATE TABLE IF NOT EXISTS podcast_slugs (
+    slug        TEXT PRIMARY KEY,
+    podcast_id  BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS podcast_slugs_podcast_id_idx ON podcast_slugs (podcast_id);
+
+INSERT INTO podcast_slugs (slug, podcast_id)
+SELECT slug, id FROM podcasts
+ON CONFLICT DO NOTHING;
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS podcast_translations;
+ALTER TABLE podcasts DROP COLUMN IF EXISTS description;
\ No newline at end of file
@@ -0,0 +1,12 @@
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS podcast_translations (
+    podcast_id  BIGINT NOT NULL REFERENCES podcasts ON DELETE CASCADE,
+    language    TEXT NOT NULL,
+    title       TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (podcast_id, language),
+    CONSTRAINT check_podcast_translations_text CHECK (title <> '' OR description <> '')
+);
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE users DROP COLUMN IF EXISTS safe_mode;
+
+DROP INDEX IF EXISTS podcasts_country_idx;
+DROP INDEX IF EXISTS podcasts_audience_idx;
+
+ALTER TABLE podcasts DROP CONSTRAINT IF EXISTS check_podcasts_country;
+ALTER TABLE podcasts DROP CONSTRAINT IF EXISTS check_podcasts_explicit_audience;
+ALTER TABLE podcasts DROP CONSTRAINT IF EXISTS check_podcasts_audience;
+
+ALTER TABLE podcasts DROP COLUMN IF EXISTS country;
+ALTER TABLE podcasts DROP COLUMN IF EXISTS audience;
+ALTER TABLE podcasts DROP COLUMN IF EXISTS advisories;
+ALTER TABLE podcasts DROP COLUMN IF EXISTS explicit;
\ No newline at end of file
@@ -0,0 +1,13 @@
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS explicit BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS advisories TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS audience TEXT NOT NULL DEFAULT 'general';
+ALTER TABLE podcasts ADD COLUMN IF NOT EXISTS country TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE podcasts ADD CONSTRAINT check_podcasts_audience CHECK (audience IN ('general', 'kids', 'teens', 'adults'));
+ALTER TABLE podcasts ADD CONSTRAINT check_podcasts_explicit_audience CHECK (NOT (explicit AND audience = 'kids'));
+ALTER TABLE podcasts ADD CONSTRAINT check_podcasts_country CHECK (country ~ '^([A-Z]{2})?$');
+
+CREATE INDEX IF NOT EXISTS podcasts_audience_idx ON podcasts (audience);
+CREATE INDEX IF NOT EXISTS podcasts_country_idx ON podcasts (country) WHERE country <> '';
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS safe_mode BOOLEAN NOT NULL DEFAULT FALSE;
\ No newline at end of file
@@ -0,0 +1 @@
+-- The original form of canonicalized urls is not kept, so they stay as they are.
\ No newline at end of file
@@ -0,0 +1,62 @@
+-- Bring urls stored before CanonicalURL existed into the same form, so that
+-- duplicate detection matches them against new ones: lowercase scheme and
+-- host, no user info, default port, fragment or tracking parameters, "/" for
+-- an empty path and the remaining parameters sorted by name. Urls that are not
+-- absolute http or https urls are left alone for validation to report.
+CREATE FUNCTION pg_temp.canonical_url(raw TEXT) RETURNS TEXT
+LANGUAGE plpgsql IMMUTABLE AS $$
+DECLARE
+    parts     TEXT[];
+    scheme    TEXT;
+    authority TEXT;
+    host      TEXT;
+    port      TEXT;
+    params    TEXT;
+BEGIN
+    parts := regexp_match(trim(raw), '^([A-Za-z][A-Za-z0-9+.-]*)://([^/?#]*)([^?#]*)(\?[^#]*)?(#.*)?$');
+    IF parts IS NULL THEN
+        RETURN raw;
+    END IF;
+
+    scheme := lower(parts[1]);
+    IF scheme NOT IN ('http', 'https') THEN
+        RETURN raw;
+    END IF;
+
+    authority := regexp_replace(parts[2], '^.*@', '');
+    IF authority LIKE '[%' THEN
+        host := lower(substring(authority FROM '^(\[[^\]]*\])'));
+        port := substring(authority FROM '^\[[^\]]*\]:([0-9]+)$');
+    ELSE
+        host := rtrim(lower(split_part(authority, ':', 1)), '.');
+        port := NULLIF(split_part(authority, ':', 2), '');
+    END IF;
+
+    IF host IS NULL OR host = '' THEN
+        RETURN raw;
+    END IF;
+
+    IF (scheme = 'http' AND port = '80') OR (scheme = 'https' AND port = '443') THEN
+        port := NULL;
+    END IF;
+
+    SELECT string_agg(param, '&' ORDER BY split_part(param, '=', 1) COLLATE "C", i)
+    INTO params
+    FROM unnest(string_to_array(substr(COALESCE(parts[4], ''), 2), '&')) WITH ORDINALITY AS q(param, i)
+    WHERE param <> ''
+    AND lower(split_part(param, '=', 1)) NOT LIKE 'utm\_%'
+    AND lower(split_part(param, '=', 1)) NOT IN (
+        'fbclid', 'gclid', 'dclid', 'msclkid', 'yclid', 'igshid', 'mc_cid', 'mc_eid', '_hsenc', '_hsmi', 'ref_src', 'si'
+    );
+
+    RETURN scheme || '://' || host || COALESCE(':' || port, '') || COALESCE(NULLIF(parts[3], ''), '/') || COALESCE('?' || params, '');
+END
+$$;
+
+UPDATE podcasts
+SET url = pg_temp.canonical_url(url)
+WHERE url <> pg_temp.canonical_url(url);
+
+UPDATE podcast_platforms
+SET url = pg_temp.canonical_url(url)
+WHERE url <> pg_temp.canonical_url(url);
\ No newline at end of file
@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS programs_touch_catalog ON programs;
+
+DROP TRIGGER IF EXISTS hosts_touch_catalog ON hosts;
+
+DROP TRIGGER IF EXISTS podcast_translations_touch_catalog ON podcast_translations;
+
+DROP TRIGGER IF EXISTS podcasts_touch_catalog ON podcasts;
+
+DROP FUNCTION IF EXISTS touch_catalog();
+
+DROP TABLE IF EXISTS catalog_changes;
\ No newline at end of file
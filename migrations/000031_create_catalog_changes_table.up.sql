@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS catalog_changes (
+    id         BOOLEAN PRIMARY KEY DEFAULT true,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT check_catalog_changes_single_row CHECK (id)
+);
+
+INSERT INTO catalog_changes DEFAULT VALUES ON CONFLICT DO NOTHING;
+
+-- touch_catalog records that something published about podcasts changed, so
+-- the syndication feeds can tell clients whether to fetch them again.
+CREATE OR REPLACE FUNCTION touch_catalog() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    UPDATE catalog_changes SET changed_at = NOW();
+    RETURN NULL;
+END
+$$;
+
+-- Link checks, ratings and favorites update podcasts without changing what
+-- the feeds publish, so only the columns the feeds show or filter on count.
+DROP TRIGGER IF EXISTS podcasts_touch_catalog ON podcasts;
+CREATE TRIGGER podcasts_touch_catalog
+    AFTER INSERT OR DELETE OR TRUNCATE
+        OR UPDATE OF title, description, platform, url, host_id, program_id, guest_speakers, year, languages, tags,
+            explicit, advisories, audience, country, hidden, slug, artwork, updated_at
+    ON podcasts
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog();
+
+DROP TRIGGER IF EXISTS podcast_translations_touch_catalog ON podcast_translations;
+CREATE TRIGGER podcast_translations_touch_catalog
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON podcast_translations
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog();
+
+DROP TRIGGER IF EXISTS hosts_touch_catalog ON hosts;
+CREATE TRIGGER hosts_touch_catalog
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON hosts
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog();
+
+DROP TRIGGER IF EXISTS programs_touch_catalog ON programs;
+CREATE TRIGGER programs_touch_catalog
+    AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON programs
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalog();
\ No newline at end of file